@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
 	"net"
@@ -16,6 +17,8 @@ import (
 
 	"github.com/razorpay/trino-gateway/internal/boot"
 	// guiserver "github.com/razorpay/trino-gateway/internal/frontend/server"
+	apikeyapi "github.com/razorpay/trino-gateway/internal/gatewayserver/apiKeyApi"
+	authexemptionapi "github.com/razorpay/trino-gateway/internal/gatewayserver/authExemptionApi"
 	backendapi "github.com/razorpay/trino-gateway/internal/gatewayserver/backendApi"
 	"github.com/razorpay/trino-gateway/internal/gatewayserver/database/dbRepo"
 	groupapi "github.com/razorpay/trino-gateway/internal/gatewayserver/groupApi"
@@ -23,10 +26,13 @@ import (
 	"github.com/razorpay/trino-gateway/internal/gatewayserver/hooks"
 	policyapi "github.com/razorpay/trino-gateway/internal/gatewayserver/policyApi"
 	queryapi "github.com/razorpay/trino-gateway/internal/gatewayserver/queryApi"
+	quotaapi "github.com/razorpay/trino-gateway/internal/gatewayserver/quotaApi"
 	"github.com/razorpay/trino-gateway/internal/gatewayserver/repo"
 	"github.com/razorpay/trino-gateway/internal/monitor"
 	"github.com/razorpay/trino-gateway/internal/provider"
 	"github.com/razorpay/trino-gateway/internal/router"
+	"github.com/razorpay/trino-gateway/internal/tlsconfig"
+	"github.com/razorpay/trino-gateway/internal/utils"
 	"github.com/razorpay/trino-gateway/pkg/fetcher"
 	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
 	// "github.com/razorpay/trino-gateway/twirpql"
@@ -97,6 +103,9 @@ func startGatewayServers(_ctx *context.Context) []*http.Server {
 		Policy:  gatewayv1.NewPolicyApiProtobufClient(gatewayApiUrl, &http.Client{}),
 		Backend: gatewayv1.NewBackendApiProtobufClient(gatewayApiUrl, &http.Client{}),
 		Query:   gatewayv1.NewQueryApiProtobufClient(gatewayApiUrl, &http.Client{}),
+		Quota:   gatewayv1.NewQuotaApiProtobufClient(gatewayApiUrl, &http.Client{}),
+
+		AuthExemption: gatewayv1.NewAuthExemptionApiProtobufClient(gatewayApiUrl, &http.Client{}),
 	}
 
 	header := make(http.Header)
@@ -107,21 +116,69 @@ func startGatewayServers(_ctx *context.Context) []*http.Server {
 		return nil
 	}
 
+	tlsConfigs, err := gatewayTlsConfigs(ctx)
+	if err != nil {
+		log.Fatalf("failed to init gateway tls listeners: %v", err)
+	}
+
+	authenticators, err := router.NewAuthenticatorChains(&ctx)
+	if err != nil {
+		log.Fatalf("failed to init gateway authenticators: %v", err)
+	}
+	impersonation, err := router.NewImpersonationRules()
+	if err != nil {
+		log.Fatalf("failed to init impersonation rules: %v", err)
+	}
+
+	shared, err := router.NewSharedState(&ctx, &gatewayClient)
+	if err != nil {
+		log.Fatalf("failed to init gateway router state: %v", err)
+	}
+
 	servers := make([]*http.Server, len(boot.Config.Gateway.Ports))
 	for i, port := range boot.Config.Gateway.Ports {
-		server := router.Server(&ctx, port, &gatewayClient, boot.Config.App.ServiceExternalHostname)
+		server := router.Server(&ctx, port, &gatewayClient, boot.Config.App.ServiceExternalHostname, authenticators[port], impersonation, shared)
 		servers[i] = server
 
-		go listenHttp(&ctx, server, port)
+		go listenHttp(&ctx, server, port, tlsConfigs[port])
 	}
 	return servers
 }
 
-func listenHttp(ctx *context.Context, server *http.Server, port int) {
+// gatewayTlsConfigs returns TLS config of each of the gateway ports terminating TLS as per `gateway.tls`
+func gatewayTlsConfigs(ctx context.Context) (map[int]*tls.Config, error) {
+	pairs := make(map[int][]tlsconfig.KeyPair)
+	for _, l := range boot.Config.Gateway.Tls.Listeners {
+		if !utils.SliceContains(boot.Config.Gateway.Ports, l.Port) {
+			return nil, fmt.Errorf("tls listener port %d is not one of the gateway ports", l.Port)
+		}
+		pairs[l.Port] = append(pairs[l.Port], tlsconfig.KeyPair{CertFile: l.CertFile, KeyFile: l.KeyFile})
+	}
+
+	reloadInterval, _ := time.ParseDuration(boot.Config.Gateway.Tls.ReloadInterval)
+	configs := make(map[int]*tls.Config, len(pairs))
+	for port, p := range pairs {
+		reloader, err := tlsconfig.NewCertReloader(p)
+		if err != nil {
+			return nil, err
+		}
+		if reloadInterval > 0 {
+			go reloader.Run(ctx, reloadInterval)
+		}
+		configs[port] = reloader.ServerConfig()
+	}
+	return configs, nil
+}
+
+// listenHttp serves the server on the port, over TLS if tlsConfig isn't nil
+func listenHttp(ctx *context.Context, server *http.Server, port int, tlsConfig *tls.Config) {
 	listener, err := net.Listen("tcp4", fmt.Sprint(boot.Config.Gateway.Network, ":", port))
 	if err != nil {
 		panic(err)
 	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
 
 	if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
 		provider.Logger(*ctx).WithContext(*ctx, nil).Fatalw("Failed to start http listener", map[string]interface{}{"error": err})
@@ -142,7 +199,12 @@ func startMonitor(_ctx *context.Context) {
 		return
 	}
 
-	m := monitor.NewMonitor(core)
+	m, err := monitor.NewMonitor(core)
+	if err != nil {
+		provider.Logger(ctx).WithError(err).Fatal(
+			"Invalid config of Monitoring module",
+		)
+	}
 	err = m.Schedule(&ctx, boot.Config.Monitor.Interval)
 	if err != nil {
 		provider.Logger(ctx).WithError(err).Fatal(
@@ -159,7 +221,7 @@ func startMonitor(_ctx *context.Context) {
 // 	appFrontendPath := "/"
 // 	mux.Handle(appFrontendPath, http.StripPrefix(appFrontendPath, fs))
 // 	httpServer := http.Server{Handler: mux}
-// 	go listenHttp(ctx, &httpServer, boot.Config.App.GuiPort)
+// 	go listenHttp(ctx, &httpServer, boot.Config.App.GuiPort, nil)
 // 	return &httpServer
 // }
 
@@ -176,20 +238,37 @@ func startApiServer(ctx *context.Context) *http.Server {
 
 	fetcherClient := fetcher.New(boot.DB.Instance(*ctx))
 
-	gatewayBackendCore := backendapi.NewCore(gatewayBackendRepo)
+	gatewayBackendCore := backendapi.NewCore(gatewayBackendRepo, repo.NewBackendHealthEventRepo(gatewayDbRepo), fetcherClient)
 	gatewayGroupCore := groupapi.NewCore(repo.NewGroupRepo(gatewayDbRepo), gatewayBackendRepo)
-	gatewayPolicyCore := policyapi.NewCore(repo.NewPolicyRepo(gatewayDbRepo))
-	gatewayQueryCore := queryapi.NewCore(repo.NewQueryRepo(gatewayDbRepo), fetcherClient)
+	userGroupProvider, err := policyapi.NewUserGroupProvider(*ctx, repo.NewUserGroupMembershipRepo(gatewayDbRepo))
+	if err != nil {
+		log.Fatalf("failed to init user group provider: %v", err)
+	}
+	gatewayPolicyCore, err := policyapi.NewCore(repo.NewPolicyRepo(gatewayDbRepo), userGroupProvider)
+	if err != nil {
+		log.Fatalf("failed to init policy core: %v", err)
+	}
+	transactionTtl, _ := time.ParseDuration(boot.Config.Gateway.Transaction.BindingTtl)
+	gatewayQueryCore := queryapi.NewCore(repo.NewQueryRepo(gatewayDbRepo), repo.NewTransactionRepo(gatewayDbRepo), fetcherClient, transactionTtl)
+	gatewayQuotaCore := quotaapi.NewCore(repo.NewQuotaRepo(gatewayDbRepo))
+	gatewayAuthExemptionCore := authexemptionapi.NewCore(repo.NewAuthExemptionRepo(gatewayDbRepo))
+	gatewayApiKeyCore := apikeyapi.NewCore(repo.NewApiKeyRepo(gatewayDbRepo))
 
 	gatewayBackendServer := backendapi.NewServer(gatewayBackendCore)
 	gatewayGroupServer := groupapi.NewServer(gatewayGroupCore)
 	gatewayPolicyServer := policyapi.NewServer(gatewayPolicyCore)
 	gatewayQueryServer := queryapi.NewServer(gatewayQueryCore)
-
-	gatewayBackendServerHandler := gatewayv1.NewBackendApiServer(gatewayBackendServer, twirpHooks())
-	gatewayGroupServerHandler := gatewayv1.NewGroupApiServer(gatewayGroupServer, twirpHooks())
-	gatewayPolicyServerHandler := gatewayv1.NewPolicyApiServer(gatewayPolicyServer, twirpHooks())
-	gatewayQueryServerHandler := gatewayv1.NewQueryApiServer(gatewayQueryServer, twirpHooks())
+	gatewayQuotaServer := quotaapi.NewServer(gatewayQuotaCore)
+	gatewayAuthExemptionServer := authexemptionapi.NewServer(gatewayAuthExemptionCore)
+	gatewayApiKeyServer := apikeyapi.NewServer(gatewayApiKeyCore)
+
+	gatewayBackendServerHandler := gatewayv1.NewBackendApiServer(gatewayBackendServer, twirpHooks(gatewayApiKeyCore))
+	gatewayGroupServerHandler := gatewayv1.NewGroupApiServer(gatewayGroupServer, twirpHooks(gatewayApiKeyCore))
+	gatewayPolicyServerHandler := gatewayv1.NewPolicyApiServer(gatewayPolicyServer, twirpHooks(gatewayApiKeyCore))
+	gatewayQueryServerHandler := gatewayv1.NewQueryApiServer(gatewayQueryServer, twirpHooks(gatewayApiKeyCore))
+	gatewayQuotaServerHandler := gatewayv1.NewQuotaApiServer(gatewayQuotaServer, twirpHooks(gatewayApiKeyCore))
+	gatewayAuthExemptionServerHandler := gatewayv1.NewAuthExemptionApiServer(gatewayAuthExemptionServer, twirpHooks(gatewayApiKeyCore))
+	gatewayApiKeyServerHandler := gatewayv1.NewApiKeyApiServer(gatewayApiKeyServer, twirpHooks(gatewayApiKeyCore))
 
 	// // Ensure defaultRoutingGroup is present in healthcheck
 	mux.Handle(gatewayv1.HealthCheckAPIPathPrefix, healthServerHandler)
@@ -199,6 +278,9 @@ func startApiServer(ctx *context.Context) *http.Server {
 	mux.Handle(gatewayv1.GroupApiPathPrefix, hooks.WithAuth(gatewayGroupServerHandler))
 	mux.Handle(gatewayv1.PolicyApiPathPrefix, hooks.WithAuth(gatewayPolicyServerHandler))
 	mux.Handle(gatewayv1.QueryApiPathPrefix, hooks.WithAuth(gatewayQueryServerHandler))
+	mux.Handle(gatewayv1.QuotaApiPathPrefix, hooks.WithAuth(gatewayQuotaServerHandler))
+	mux.Handle(gatewayv1.AuthExemptionApiPathPrefix, hooks.WithAuth(gatewayAuthExemptionServerHandler))
+	mux.Handle(gatewayv1.ApiKeyApiPathPrefix, hooks.WithAuth(gatewayApiKeyServerHandler))
 
 	// Serve the current git commit hash
 	mux.HandleFunc("/commit.txt", func(w http.ResponseWriter, _ *http.Request) {
@@ -218,23 +300,23 @@ func startApiServer(ctx *context.Context) *http.Server {
 	httpServer := http.Server{Handler: mux}
 
 	// Start app server listener
-	go listenHttp(ctx, &httpServer, boot.Config.App.Port)
+	go listenHttp(ctx, &httpServer, boot.Config.App.Port, nil)
 
 	return &httpServer
 }
 
 func startMetricsServer(ctx *context.Context) *http.Server {
 	httpServer := http.Server{Handler: promhttp.Handler()}
-	go listenHttp(ctx, &httpServer, boot.Config.App.MetricsPort)
+	go listenHttp(ctx, &httpServer, boot.Config.App.MetricsPort, nil)
 	return &httpServer
 }
 
 // twirpHooks register common twirp hooks applicable to all endpoints.
-func twirpHooks() *twirp.ServerHooks {
+func twirpHooks(apiKeys hooks.ApiKeyAuthenticator) *twirp.ServerHooks {
 	return twirp.ChainHooks(
 		hooks.Metric(),
 		hooks.RequestID(),
-		hooks.Auth(),
+		hooks.Auth(apiKeys),
 		hooks.Ctx())
 }
 
@@ -7,7 +7,10 @@ require (
 	github.com/NYTimes/gziphandler v1.1.1
 	github.com/dlmiddlecote/sqlstats v1.0.2
 	github.com/fatih/structs v1.1.0
+	github.com/go-asn1-ber/asn1-ber v1.5.5
 	github.com/go-co-op/gocron v1.35.0 // v1.35.0+ are broken for v1
+	github.com/go-jose/go-jose/v4 v4.0.4
+	github.com/go-ldap/ldap/v3 v3.4.8
 	github.com/go-ozzo/ozzo-validation v3.6.0+incompatible
 	github.com/go-ozzo/ozzo-validation/v4 v4.3.0
 	github.com/go-sql-driver/mysql v1.8.1
@@ -26,6 +29,8 @@ require (
 	github.com/trinodb/trino-go-client v0.320.0
 	github.com/twitchtv/twirp v8.1.3+incompatible
 	go.uber.org/zap v1.27.0
+	golang.org/x/crypto v0.27.0
+	golang.org/x/sync v0.9.0
 	google.golang.org/protobuf v1.35.2
 	gorm.io/driver/mysql v1.1.2
 	gorm.io/driver/postgres v1.1.2
@@ -35,6 +40,7 @@ require (
 
 require (
 	filippo.io/edwards25519 v1.1.0 // indirect
+	github.com/Azure/go-ntlmssp v0.0.0-20221128193559-754e69321358 // indirect
 	github.com/beorn7/perks v1.0.1 // indirect
 	github.com/cespare/xxhash/v2 v2.3.0 // indirect
 	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
@@ -85,10 +91,8 @@ require (
 	github.com/visualfc/goembed v0.3.3 // indirect
 	go.uber.org/atomic v1.11.0 // indirect
 	go.uber.org/multierr v1.11.0 // indirect
-	golang.org/x/crypto v0.27.0 // indirect
 	golang.org/x/exp v0.0.0-20240525044651-4c93da0ed11d // indirect
 	golang.org/x/net v0.29.0 // indirect
-	golang.org/x/sync v0.9.0 // indirect
 	golang.org/x/sys v0.25.0 // indirect
 	golang.org/x/term v0.24.0 // indirect
 	golang.org/x/text v0.20.0 // indirect
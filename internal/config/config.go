@@ -29,11 +29,45 @@ type App struct {
 type Auth struct {
 	Token          string
 	TokenHeaderKey string
-	Router         struct {
+	// role of requests to the apis without an api key, e.g. "viewer", except for the AuthExemptionApi & ApiKeyApi.
+	// Empty requires a key for all requests.
+	AnonymousRole string
+	Router        struct {
 		DelegatedAuth struct {
 			ValidationProviderURL   string
 			ValidationProviderToken string
-			CacheTTLMinutes         string
+			// ttl of accepted credentials in the auth cache
+			CacheTTLMinutes string
+			// ttl of rejected credentials in the auth cache
+			CacheFailureTTL string
+			CacheMaxEntries int
+		}
+		// authenticators referenced by chains of `Ports`, fields apply as per their type
+		Authenticators []struct {
+			Name string
+			// one of "htpasswd", "ldap", "jwt", "delegated"
+			Type           string
+			File           string
+			Url            string
+			UserDnTemplate string
+			CaFile         string
+			Timeout        string
+			JwksFile       string
+			Issuer         string
+			Audience       string
+			PrincipalClaim string
+		}
+		// chains of authenticators of gateway ports, tried in order till one accepts the credentials.
+		// Ports not listed authenticate via the delegated provider.
+		Ports []struct {
+			Port           int
+			Authenticators []string
+		}
+		// rules of principals allowed to act as other Trino users
+		Impersonation struct {
+			// json file of the rules, principals can act only as themselves if empty
+			File            string
+			RefreshInterval string
 		}
 	}
 }
@@ -42,6 +76,76 @@ type Gateway struct {
 	DefaultRoutingGroup string
 	Ports               []int
 	Network             string
+	RewriteResponseUris bool
+	PolicyCacheTTL      string
+	UserGroups          struct {
+		// one of "file", "db"; empty disables user_group rules
+		Source          string
+		File            string
+		RefreshInterval string
+	}
+	PassiveHealth struct {
+		// empty disables passive health detection
+		Window             string
+		MinRequests        int
+		ErrorRateThreshold float64
+		ConnErrorThreshold int
+	}
+	RoutingSnapshot struct {
+		// empty routes every request via the gateway apis
+		RefreshInterval string
+	}
+	Admission struct {
+		// max duration a poll of a queued query waits for its admission
+		PollWait string
+		// queued & running queries not polled by clients for this long are considered abandoned
+		ClientTimeout string
+	}
+	Quota struct {
+		// queries counted against quotas not polled by clients for this long are considered abandoned
+		ClientTimeout string
+	}
+	SessionState struct {
+		// state of client sessions idle for this long is forgotten, empty disables tracking
+		Ttl string
+		// least recently seen sessions are forgotten beyond this many sessions
+		MaxEntries int
+		// header identifying sessions of clients, state is replayed only for sessions identified by it
+		SessionIdHeader string
+	}
+	Tls struct {
+		// ports of `gateway.ports` terminating TLS, a port can have multiple certificates,
+		// the one valid for the server name sent by the client is served
+		Listeners []struct {
+			Port     int
+			CertFile string
+			KeyFile  string
+		}
+		// interval of checking certificate files for changes, empty disables reloading
+		ReloadInterval string
+	}
+	Transaction struct {
+		// bindings of transactions to backends are expired this long after the transaction started,
+		// for transactions clients never committed or rolled back. Empty never expires them.
+		BindingTtl string
+	}
+	SubmissionRetry struct {
+		// queries failed by a backend before starting are resubmitted to the next eligible backend
+		// at most this many times, 0 disables retries
+		MaxRetries int
+	}
+	// connection pool of each backend the router proxies requests to
+	BackendTransport struct {
+		MaxIdleConns int
+		// 0 is unlimited, requests wait for a connection once the limit is reached
+		MaxConns              int
+		IdleConnTimeout       string
+		DialTimeout           string
+		TlsHandshakeTimeout   string
+		ResponseHeaderTimeout string
+		// attempt HTTP/2 with backends serving HTTPS
+		Http2 bool
+	}
 }
 
 type Monitor struct {
@@ -52,4 +156,11 @@ type Monitor struct {
 		Password string
 	}
 	HealthCheckSql string
+	Probe          struct {
+		Interval         string
+		Timeout          string
+		MaxBackoff       string
+		FailureThreshold int
+		SuccessThreshold int
+	}
 }
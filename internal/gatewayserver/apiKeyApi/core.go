@@ -0,0 +1,159 @@
+package apikeyapi
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/hooks"
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/repo"
+	"github.com/razorpay/trino-gateway/pkg/spine"
+)
+
+// Secrets of keys are prefixed, so leaked keys are recognisable by secret scanners
+const (
+	keyPrefix       = "tgw_"
+	keyPrefixLength = len(keyPrefix) + 8
+)
+
+type Core struct {
+	apiKeyRepo repo.IApiKeyRepo
+}
+
+type ICore interface {
+	CreateOrUpdateApiKey(ctx context.Context, params *ApiKeyCreateParams) (string, error)
+	GetApiKey(ctx context.Context, id string) (*models.ApiKey, error)
+	GetAllApiKeys(ctx context.Context) ([]models.ApiKey, error)
+	DeleteApiKey(ctx context.Context, id string) error
+	EnableApiKey(ctx context.Context, id string) error
+	DisableApiKey(ctx context.Context, id string) error
+}
+
+// NewCore returns a new instance of *Core
+func NewCore(apiKey repo.IApiKeyRepo) *Core {
+	return &Core{apiKeyRepo: apiKey}
+}
+
+// ApiKeyCreateParams has attributes that are required for apiKey.Create()
+type ApiKeyCreateParams struct {
+	ID          string
+	Role        string
+	Permissions []string
+	Description string
+	IsEnabled   bool
+}
+
+// CreateOrUpdateApiKey returns the secret of the key if it was created, existing keys retain their secret
+func (c *Core) CreateOrUpdateApiKey(ctx context.Context, params *ApiKeyCreateParams) (string, error) {
+	if err := params.Validate(); err != nil {
+		return "", err
+	}
+	permissions, err := json.Marshal(append([]string{}, params.Permissions...))
+	if err != nil {
+		return "", err
+	}
+
+	apiKey := models.ApiKey{
+		Role:        params.Role,
+		Permissions: string(permissions),
+		Description: params.Description,
+		IsEnabled:   &params.IsEnabled,
+	}
+	apiKey.ID = params.ID
+
+	existing, err := c.apiKeyRepo.Find(ctx, params.ID)
+	if err == nil { // update
+		apiKey.KeyHash = existing.KeyHash
+		apiKey.KeyPrefix = existing.KeyPrefix
+		return "", c.apiKeyRepo.Update(ctx, &apiKey)
+	}
+	if !errors.Is(err, spine.RecordNotFound) {
+		// creating a key with a new secret would replace the existing secret
+		return "", err
+	}
+
+	// create
+	secret, err := newSecret()
+	if err != nil {
+		return "", err
+	}
+	apiKey.KeyHash = hashSecret(secret)
+	apiKey.KeyPrefix = secret[:keyPrefixLength]
+	if err := c.apiKeyRepo.Create(ctx, &apiKey); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+func (c *Core) GetApiKey(ctx context.Context, id string) (*models.ApiKey, error) {
+	apiKey, err := c.apiKeyRepo.Find(ctx, id)
+	return apiKey, err
+}
+
+func (c *Core) GetAllApiKeys(ctx context.Context) ([]models.ApiKey, error) {
+	apiKeys, err := c.apiKeyRepo.FindMany(ctx, make(map[string]interface{}))
+	return apiKeys, err
+}
+
+func (c *Core) DeleteApiKey(ctx context.Context, id string) error {
+	return c.apiKeyRepo.Delete(ctx, id)
+}
+
+func (c *Core) EnableApiKey(ctx context.Context, id string) error {
+	return c.apiKeyRepo.Enable(ctx, id)
+}
+
+func (c *Core) DisableApiKey(ctx context.Context, id string) error {
+	return c.apiKeyRepo.Disable(ctx, id)
+}
+
+// AuthenticateApiKey returns the enabled api key having the secret
+func (c *Core) AuthenticateApiKey(ctx context.Context, secret string) (*hooks.ApiKey, error) {
+	apiKey, err := c.apiKeyRepo.FindByKeyHash(ctx, hashSecret(secret))
+	if errors.Is(err, spine.RecordNotFound) {
+		return nil, hooks.ErrInvalidApiKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if apiKey.IsEnabled == nil || !*apiKey.IsEnabled {
+		return nil, hooks.ErrInvalidApiKey
+	}
+	permissions, err := decodePermissions(apiKey.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	return &hooks.ApiKey{
+		ID:          apiKey.ID,
+		Role:        apiKey.Role,
+		Permissions: permissions,
+	}, nil
+}
+
+func decodePermissions(permissions string) ([]string, error) {
+	var decoded []string
+	if permissions == "" {
+		return decoded, nil
+	}
+	err := json.Unmarshal([]byte(permissions), &decoded)
+	return decoded, err
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return keyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashSecret returns the hex encoded sha256 of the secret, secrets are random so they aren't salted
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,146 @@
+package apikeyapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
+	"github.com/razorpay/trino-gateway/internal/provider"
+	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
+)
+
+// Server has methods implementing of server rpc.
+type Server struct {
+	core ICore
+}
+
+// NewServer returns a server.
+func NewServer(core ICore) *Server {
+	return &Server{
+		core: core,
+	}
+}
+
+// CreateOrUpdateApiKey creates a new api key or updates the existing one
+func (s *Server) CreateOrUpdateApiKey(ctx context.Context, req *gatewayv1.ApiKey) (*gatewayv1.ApiKeyCreateOrUpdateResponse, error) {
+	provider.Logger(ctx).Debugw("CreateOrUpdateApiKey", map[string]interface{}{
+		"request": req.String(),
+	})
+
+	createParams := ApiKeyCreateParams{
+		ID:          req.GetId(),
+		Role:        req.GetRole().Enum().String(),
+		Permissions: req.GetPermissions(),
+		Description: req.GetDescription(),
+		IsEnabled:   req.GetIsEnabled(),
+	}
+
+	key, err := s.core.CreateOrUpdateApiKey(ctx, &createParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gatewayv1.ApiKeyCreateOrUpdateResponse{Key: key}, nil
+}
+
+// GetApiKey retrieves a single api key record
+func (s *Server) GetApiKey(ctx context.Context, req *gatewayv1.ApiKeyGetRequest) (*gatewayv1.ApiKeyGetResponse, error) {
+	provider.Logger(ctx).Debugw("GetApiKey", map[string]interface{}{
+		"request": req.String(),
+	})
+	apiKey, err := s.core.GetApiKey(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	apiKeyProto, err := toApiKeyResponseProto(apiKey)
+	if err != nil {
+		return nil, err
+	}
+	return &gatewayv1.ApiKeyGetResponse{ApiKey: apiKeyProto}, nil
+}
+
+// ListAllApiKeys fetches all api key records
+func (s *Server) ListAllApiKeys(ctx context.Context, req *gatewayv1.Empty) (*gatewayv1.ApiKeyListAllResponse, error) {
+	provider.Logger(ctx).Debugw("ListAllApiKeys", map[string]interface{}{
+		"request": req.String(),
+	})
+	apiKeys, err := s.core.GetAllApiKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeysProto := make([]*gatewayv1.ApiKey, len(apiKeys))
+	for i := range apiKeys {
+		apiKey, err := toApiKeyResponseProto(&apiKeys[i])
+		if err != nil {
+			return nil, err
+		}
+		apiKeysProto[i] = apiKey
+	}
+
+	return &gatewayv1.ApiKeyListAllResponse{Items: apiKeysProto}, nil
+}
+
+func (s *Server) EnableApiKey(ctx context.Context, req *gatewayv1.ApiKeyEnableRequest) (*gatewayv1.Empty, error) {
+	provider.Logger(ctx).Debugw("EnableApiKey", map[string]interface{}{
+		"request": req.String(),
+	})
+	err := s.core.EnableApiKey(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &gatewayv1.Empty{}, nil
+}
+
+func (s *Server) DisableApiKey(ctx context.Context, req *gatewayv1.ApiKeyDisableRequest) (*gatewayv1.Empty, error) {
+	provider.Logger(ctx).Debugw("DisableApiKey", map[string]interface{}{
+		"request": req.String(),
+	})
+	err := s.core.DisableApiKey(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &gatewayv1.Empty{}, nil
+}
+
+// DeleteApiKey deletes an api key
+func (s *Server) DeleteApiKey(ctx context.Context, req *gatewayv1.ApiKeyDeleteRequest) (*gatewayv1.Empty, error) {
+	provider.Logger(ctx).Debugw("DeleteApiKey", map[string]interface{}{
+		"request": req.String(),
+	})
+	err := s.core.DeleteApiKey(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &gatewayv1.Empty{}, nil
+}
+
+func toApiKeyResponseProto(apiKey *models.ApiKey) (*gatewayv1.ApiKey, error) {
+	if apiKey == nil {
+		return &gatewayv1.ApiKey{}, nil
+	}
+	role, ok := gatewayv1.ApiKey_Role_value[apiKey.Role]
+	if !ok {
+		return nil, errors.New(fmt.Sprint("error encoding response: invalid role ", apiKey.Role))
+	}
+	permissions, err := decodePermissions(apiKey.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	response := gatewayv1.ApiKey{
+		Id:          apiKey.ID,
+		Role:        *gatewayv1.ApiKey_Role(role).Enum(),
+		Permissions: permissions,
+		Description: apiKey.Description,
+		KeyPrefix:   apiKey.KeyPrefix,
+	}
+	if apiKey.IsEnabled != nil {
+		response.IsEnabled = *apiKey.IsEnabled
+	}
+
+	return &response, nil
+}
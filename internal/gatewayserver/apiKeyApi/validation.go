@@ -0,0 +1,23 @@
+package apikeyapi
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/rbac"
+)
+
+func (p *ApiKeyCreateParams) Validate() error {
+	if p.ID == "" {
+		return errors.New("id of api key is required")
+	}
+	if !rbac.IsRole(p.Role) {
+		return fmt.Errorf("invalid role %s of api key", p.Role)
+	}
+	for _, permission := range p.Permissions {
+		if err := rbac.ValidatePermission(permission); err != nil {
+			return err
+		}
+	}
+	return nil
+}
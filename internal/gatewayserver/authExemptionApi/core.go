@@ -0,0 +1,85 @@
+package authexemptionapi
+
+import (
+	"context"
+
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/repo"
+	"github.com/razorpay/trino-gateway/internal/routing"
+)
+
+type Core struct {
+	authExemptionRepo repo.IAuthExemptionRepo
+}
+
+type ICore interface {
+	CreateOrUpdateAuthExemption(ctx context.Context, params *AuthExemptionCreateParams) error
+	GetAuthExemption(ctx context.Context, id string) (*models.AuthExemption, error)
+	GetAllAuthExemptions(ctx context.Context) ([]models.AuthExemption, error)
+	DeleteAuthExemption(ctx context.Context, id string) error
+	EnableAuthExemption(ctx context.Context, id string) error
+	DisableAuthExemption(ctx context.Context, id string) error
+}
+
+// NewCore returns a new instance of *Core
+func NewCore(authExemption repo.IAuthExemptionRepo) *Core {
+	return &Core{authExemptionRepo: authExemption}
+}
+
+// AuthExemptionCreateParams has attributes that are required for authExemption.Create()
+type AuthExemptionCreateParams struct {
+	ID          string
+	KeyType     string
+	KeyValue    string
+	Description string
+	IsEnabled   bool
+}
+
+func (c *Core) CreateOrUpdateAuthExemption(ctx context.Context, params *AuthExemptionCreateParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	exemption := models.AuthExemption{
+		KeyType:     params.KeyType,
+		KeyValue:    params.KeyValue,
+		Description: params.Description,
+		IsEnabled:   &params.IsEnabled,
+	}
+	exemption.ID = params.ID
+
+	// exemptions are applied by the router from its routing snapshot
+	defer routing.NotifyChanged()
+
+	_, exists := c.authExemptionRepo.Find(ctx, params.ID)
+	if exists == nil { // update
+		return c.authExemptionRepo.Update(ctx, &exemption)
+	} else { // create
+		return c.authExemptionRepo.Create(ctx, &exemption)
+	}
+}
+
+func (c *Core) GetAuthExemption(ctx context.Context, id string) (*models.AuthExemption, error) {
+	exemption, err := c.authExemptionRepo.Find(ctx, id)
+	return exemption, err
+}
+
+func (c *Core) GetAllAuthExemptions(ctx context.Context) ([]models.AuthExemption, error) {
+	exemptions, err := c.authExemptionRepo.FindMany(ctx, make(map[string]interface{}))
+	return exemptions, err
+}
+
+func (c *Core) DeleteAuthExemption(ctx context.Context, id string) error {
+	defer routing.NotifyChanged()
+	return c.authExemptionRepo.Delete(ctx, id)
+}
+
+func (c *Core) EnableAuthExemption(ctx context.Context, id string) error {
+	defer routing.NotifyChanged()
+	return c.authExemptionRepo.Enable(ctx, id)
+}
+
+func (c *Core) DisableAuthExemption(ctx context.Context, id string) error {
+	defer routing.NotifyChanged()
+	return c.authExemptionRepo.Disable(ctx, id)
+}
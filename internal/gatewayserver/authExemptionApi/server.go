@@ -0,0 +1,141 @@
+package authexemptionapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
+	"github.com/razorpay/trino-gateway/internal/provider"
+	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
+)
+
+// Server has methods implementing of server rpc.
+type Server struct {
+	core ICore
+}
+
+// NewServer returns a server.
+func NewServer(core ICore) *Server {
+	return &Server{
+		core: core,
+	}
+}
+
+// CreateOrUpdateAuthExemption creates a new auth exemption or updates the existing one
+func (s *Server) CreateOrUpdateAuthExemption(ctx context.Context, req *gatewayv1.AuthExemption) (*gatewayv1.Empty, error) {
+	provider.Logger(ctx).Debugw("CreateOrUpdateAuthExemption", map[string]interface{}{
+		"request": req.String(),
+	})
+
+	createParams := AuthExemptionCreateParams{
+		ID:          req.GetId(),
+		KeyType:     req.GetKeyType().Enum().String(),
+		KeyValue:    req.GetKeyValue(),
+		Description: req.GetDescription(),
+		IsEnabled:   req.GetIsEnabled(),
+	}
+
+	err := s.core.CreateOrUpdateAuthExemption(ctx, &createParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gatewayv1.Empty{}, nil
+}
+
+// GetAuthExemption retrieves a single auth exemption record
+func (s *Server) GetAuthExemption(ctx context.Context, req *gatewayv1.AuthExemptionGetRequest) (*gatewayv1.AuthExemptionGetResponse, error) {
+	provider.Logger(ctx).Debugw("GetAuthExemption", map[string]interface{}{
+		"request": req.String(),
+	})
+	exemption, err := s.core.GetAuthExemption(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	exemptionProto, err := toAuthExemptionResponseProto(exemption)
+	if err != nil {
+		return nil, err
+	}
+	return &gatewayv1.AuthExemptionGetResponse{AuthExemption: exemptionProto}, nil
+}
+
+// ListAllAuthExemptions fetches all auth exemption records
+func (s *Server) ListAllAuthExemptions(ctx context.Context, req *gatewayv1.Empty) (*gatewayv1.AuthExemptionListAllResponse, error) {
+	provider.Logger(ctx).Debugw("ListAllAuthExemptions", map[string]interface{}{
+		"request": req.String(),
+	})
+	exemptions, err := s.core.GetAllAuthExemptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	exemptionsProto := make([]*gatewayv1.AuthExemption, len(exemptions))
+	for i := range exemptions {
+		exemption, err := toAuthExemptionResponseProto(&exemptions[i])
+		if err != nil {
+			return nil, err
+		}
+		exemptionsProto[i] = exemption
+	}
+
+	return &gatewayv1.AuthExemptionListAllResponse{Items: exemptionsProto}, nil
+}
+
+func (s *Server) EnableAuthExemption(ctx context.Context, req *gatewayv1.AuthExemptionEnableRequest) (*gatewayv1.Empty, error) {
+	provider.Logger(ctx).Debugw("EnableAuthExemption", map[string]interface{}{
+		"request": req.String(),
+	})
+	err := s.core.EnableAuthExemption(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &gatewayv1.Empty{}, nil
+}
+
+func (s *Server) DisableAuthExemption(ctx context.Context, req *gatewayv1.AuthExemptionDisableRequest) (*gatewayv1.Empty, error) {
+	provider.Logger(ctx).Debugw("DisableAuthExemption", map[string]interface{}{
+		"request": req.String(),
+	})
+	err := s.core.DisableAuthExemption(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &gatewayv1.Empty{}, nil
+}
+
+// DeleteAuthExemption deletes an auth exemption
+func (s *Server) DeleteAuthExemption(ctx context.Context, req *gatewayv1.AuthExemptionDeleteRequest) (*gatewayv1.Empty, error) {
+	provider.Logger(ctx).Debugw("DeleteAuthExemption", map[string]interface{}{
+		"request": req.String(),
+	})
+	err := s.core.DeleteAuthExemption(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &gatewayv1.Empty{}, nil
+}
+
+func toAuthExemptionResponseProto(exemption *models.AuthExemption) (*gatewayv1.AuthExemption, error) {
+	if exemption == nil {
+		return &gatewayv1.AuthExemption{}, nil
+	}
+	keyType, ok := gatewayv1.AuthExemption_KeyType_value[exemption.KeyType]
+	if !ok {
+		return nil, errors.New(fmt.Sprint("error encoding response: invalid key_type ", exemption.KeyType))
+	}
+	response := gatewayv1.AuthExemption{
+		Id:          exemption.ID,
+		KeyType:     *gatewayv1.AuthExemption_KeyType(keyType).Enum(),
+		KeyValue:    exemption.KeyValue,
+		Description: exemption.Description,
+	}
+	if exemption.IsEnabled != nil {
+		response.IsEnabled = *exemption.IsEnabled
+	}
+
+	return &response, nil
+}
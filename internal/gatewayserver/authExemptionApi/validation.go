@@ -0,0 +1,28 @@
+package authexemptionapi
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+)
+
+func (p *AuthExemptionCreateParams) Validate() error {
+	if p.ID == "" {
+		return errors.New("id of auth exemption is required")
+	}
+	if p.KeyValue == "" {
+		return errors.New("key_value of auth exemption is required")
+	}
+	switch p.KeyType {
+	case "listening_port":
+		if _, err := strconv.Atoi(p.KeyValue); err != nil {
+			return fmt.Errorf("key_value %s of listening_port auth exemption is not a port", p.KeyValue)
+		}
+	case "source_ip":
+		if _, _, err := net.ParseCIDR(p.KeyValue); err != nil {
+			return fmt.Errorf("key_value %s of source_ip auth exemption is not a CIDR", p.KeyValue)
+		}
+	}
+	return nil
+}
@@ -6,10 +6,17 @@ import (
 	"github.com/fatih/structs"
 	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
 	"github.com/razorpay/trino-gateway/internal/gatewayserver/repo"
+	"github.com/razorpay/trino-gateway/internal/provider"
+	"github.com/razorpay/trino-gateway/internal/routing"
+	fetcherPkg "github.com/razorpay/trino-gateway/pkg/fetcher"
 )
 
+var healthEventEntityName string = (&models.BackendHealthEvent{}).EntityName()
+
 type Core struct {
-	backendRepo repo.IBackendRepo
+	backendRepo     repo.IBackendRepo
+	healthEventRepo repo.IBackendHealthEventRepo
+	fetcher         fetcherPkg.IClient
 }
 
 type ICore interface {
@@ -21,12 +28,20 @@ type ICore interface {
 	DeleteBackend(ctx context.Context, id string) error
 	EnableBackend(ctx context.Context, id string) error
 	DisableBackend(ctx context.Context, id string) error
-	MarkHealthyBackend(ctx context.Context, id string) error
-	MarkUnhealthyBackend(ctx context.Context, id string) error
+	MarkHealthyBackend(ctx context.Context, id string, reason string) error
+	MarkUnhealthyBackend(ctx context.Context, id string, reason string) error
+	ListHealthEvents(ctx context.Context, params IHealthEventsListParams) ([]models.BackendHealthEvent, error)
 }
 
-func NewCore(backend repo.IBackendRepo) *Core {
-	return &Core{backendRepo: backend}
+func NewCore(backend repo.IBackendRepo, healthEvent repo.IBackendHealthEventRepo, fetcher fetcherPkg.IClient) *Core {
+	if !fetcher.IsEntityRegistered(healthEventEntityName) {
+		fetcher.Register(healthEventEntityName, &models.BackendHealthEvent{}, &[]models.BackendHealthEvent{})
+	}
+	return &Core{
+		backendRepo:     backend,
+		healthEventRepo: healthEvent,
+		fetcher:         fetcher,
+	}
 }
 
 // CreateParams has attributes that are required for backend.Create()
@@ -41,9 +56,15 @@ type BackendCreateParams struct {
 	ClusterLoad          int32
 	ThresholdClusterLoad int32
 	StatsUpdatedAt       int64
+	// nil if defaults apply
+	Tls *Tls
 }
 
 func (c *Core) CreateOrUpdateBackend(ctx context.Context, params *BackendCreateParams) error {
+	tls, err := encodeTls(params.Tls)
+	if err != nil {
+		return err
+	}
 	backend := models.Backend{
 		Hostname:             params.Hostname,
 		Scheme:               params.Scheme,
@@ -54,9 +75,12 @@ func (c *Core) CreateOrUpdateBackend(ctx context.Context, params *BackendCreateP
 		ClusterLoad:          &params.ClusterLoad,
 		ThresholdClusterLoad: &params.ThresholdClusterLoad,
 		StatsUpdatedAt:       &params.StatsUpdatedAt,
+		Tls:                  &tls,
 	}
 	backend.ID = params.ID
 
+	defer routing.NotifyChanged()
+
 	_, exists := c.backendRepo.Find(ctx, params.ID)
 	if exists == nil { // update
 		return c.backendRepo.Update(ctx, &backend)
@@ -75,6 +99,7 @@ func (c *Core) UpdateBackend(ctx context.Context, b *models.Backend) error {
 	if exists != nil {
 		return exists
 	}
+	defer routing.NotifyChanged()
 	return c.backendRepo.Update(ctx, b)
 }
 
@@ -123,23 +148,100 @@ func (c *Core) GetAllActiveBackends(ctx context.Context) ([]models.Backend, erro
 }
 
 func (c *Core) DeleteBackend(ctx context.Context, id string) error {
+	defer routing.NotifyChanged()
 	return c.backendRepo.Delete(ctx, id)
 }
 
 func (c *Core) EnableBackend(ctx context.Context, id string) error {
+	defer routing.NotifyChanged()
 	return c.backendRepo.Enable(ctx, id)
 }
 
 func (c *Core) DisableBackend(ctx context.Context, id string) error {
+	defer routing.NotifyChanged()
 	return c.backendRepo.Disable(ctx, id)
 }
 
-func (c *Core) MarkHealthyBackend(ctx context.Context, id string) error {
-	return c.backendRepo.MarkHealthy(ctx, id)
+func (c *Core) MarkHealthyBackend(ctx context.Context, id string, reason string) error {
+	return c.markHealth(ctx, id, true, reason)
+}
+
+func (c *Core) MarkUnhealthyBackend(ctx context.Context, id string, reason string) error {
+	return c.markHealth(ctx, id, false, reason)
+}
+
+// Max length of reason stored in health events
+const maxHealthEventReasonLength = 1024
+
+// markHealth updates health state of the backend, recording an event if the state changes
+func (c *Core) markHealth(ctx context.Context, id string, healthy bool, reason string) error {
+	backend, err := c.backendRepo.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+	if backend.IsHealthy != nil && *backend.IsHealthy == healthy {
+		return nil
+	}
+
+	if healthy {
+		err = c.backendRepo.MarkHealthy(ctx, id)
+	} else {
+		err = c.backendRepo.MarkUnhealthy(ctx, id)
+	}
+	if err != nil {
+		return err
+	}
+	routing.NotifyChanged()
+
+	if len(reason) > maxHealthEventReasonLength {
+		reason = reason[:maxHealthEventReasonLength]
+	}
+	event := models.BackendHealthEvent{BackendId: id, IsHealthy: healthy, Reason: reason}
+	if err := c.healthEventRepo.Create(ctx, &event); err != nil {
+		// health state is already updated, history is best effort
+		provider.Logger(ctx).WithError(err).Errorw(
+			"Unable to record backend health event",
+			map[string]interface{}{"backend_id": id, "is_healthy": healthy, "reason": reason})
+	}
+	return nil
+}
+
+type IHealthEventsListParams interface {
+	GetBackendId() string
+	GetCount() int32
+	GetSkip() int32
+}
+
+type HealthEventsFilters struct {
+	BackendId string `json:"backend_id,omitempty"`
 }
 
-func (c *Core) MarkUnhealthyBackend(ctx context.Context, id string) error {
-	return c.backendRepo.MarkUnhealthy(ctx, id)
+// ListHealthEvents returns health events of backends, latest first
+func (c *Core) ListHealthEvents(ctx context.Context, params IHealthEventsListParams) ([]models.BackendHealthEvent, error) {
+	conditionStr := structs.New(HealthEventsFilters{BackendId: params.GetBackendId()})
+	// use the json tag name, so we can respect omitempty tags
+	conditionStr.TagName = "json"
+	conditions := conditionStr.Map()
+
+	fetchRequest := fetcherPkg.FetchMultipleRequest{
+		EntityName: healthEventEntityName,
+		Filter:     conditions,
+		Pagination: fetcherPkg.Pagination{
+			Skip:  int(params.GetSkip()),
+			Limit: int(params.GetCount()),
+		},
+		IsTrashed:    false,
+		HasCreatedAt: true,
+	}
+
+	resp, err := c.fetcher.FetchMultiple(ctx, fetchRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	events := (resp.GetEntities().(map[string]interface{})[healthEventEntityName]).(*[]models.BackendHealthEvent)
+
+	return *events, nil
 }
 
 type EvaluateClientParams struct {
@@ -44,6 +44,7 @@ func (s *Server) CreateOrUpdateBackend(ctx context.Context, req *gatewayv1.Backe
 		ClusterLoad:          req.GetClusterLoad(),
 		ThresholdClusterLoad: req.GetThresholdClusterLoad(),
 		StatsUpdatedAt:       req.GetStatsUpdatedAt(),
+		Tls:                  fromTlsProto(req.GetTls()),
 	}
 
 	err := s.core.CreateOrUpdateBackend(ctx, &createParams)
@@ -127,7 +128,7 @@ func (s *Server) MarkHealthyBackend(ctx context.Context, req *gatewayv1.BackendM
 	provider.Logger(ctx).Debugw("MarkHealthyBackend", map[string]interface{}{
 		"request": req.String(),
 	})
-	err := s.core.MarkHealthyBackend(ctx, req.GetId())
+	err := s.core.MarkHealthyBackend(ctx, req.GetId(), req.GetReason())
 	if err != nil {
 		return nil, err
 	}
@@ -139,7 +140,7 @@ func (s *Server) MarkUnhealthyBackend(ctx context.Context, req *gatewayv1.Backen
 	provider.Logger(ctx).Debugw("MarkUnhealthyBackend", map[string]interface{}{
 		"request": req.String(),
 	})
-	err := s.core.MarkUnhealthyBackend(ctx, req.GetId())
+	err := s.core.MarkUnhealthyBackend(ctx, req.GetId(), req.GetReason())
 	if err != nil {
 		return nil, err
 	}
@@ -158,8 +159,14 @@ func (s *Server) UpdateClusterLoadBackend(
 	if err != nil {
 		return nil, err
 	}
+	runningQueries, queuedQueries := req.GetRunningQueries(), req.GetQueuedQueries()
+	avgQueueTimeMs, activeNodes := req.GetAvgQueueTimeMs(), req.GetActiveNodes()
 	*b.ClusterLoad = req.GetClusterLoad()
 	*b.StatsUpdatedAt = time.Now().Unix()
+	b.RunningQueries = &runningQueries
+	b.QueuedQueries = &queuedQueries
+	b.AvgQueueTimeMs = &avgQueueTimeMs
+	b.ActiveNodes = &activeNodes
 
 	if err := s.core.UpdateBackend(ctx, b); err != nil {
 		return nil, err
@@ -168,6 +175,32 @@ func (s *Server) UpdateClusterLoadBackend(
 	return &gatewayv1.Empty{}, nil
 }
 
+// ListBackendHealthEvents lists health state changes of backends, latest first
+func (s *Server) ListBackendHealthEvents(
+	ctx context.Context,
+	req *gatewayv1.BackendHealthEventsListRequest,
+) (*gatewayv1.BackendHealthEventsListResponse, error) {
+	provider.Logger(ctx).Debugw("ListBackendHealthEvents", map[string]interface{}{
+		"request": req.String(),
+	})
+	events, err := s.core.ListHealthEvents(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*gatewayv1.BackendHealthEvent, len(events))
+	for i, e := range events {
+		items[i] = &gatewayv1.BackendHealthEvent{
+			BackendId: e.BackendId,
+			IsHealthy: e.IsHealthy,
+			Reason:    e.Reason,
+			CreatedAt: e.CreatedAt,
+		}
+	}
+
+	return &gatewayv1.BackendHealthEventsListResponse{Items: items}, nil
+}
+
 // Delete deletes a backend, soft-delete
 func (s *Server) DeleteBackend(ctx context.Context, req *gatewayv1.BackendDeleteRequest) (*gatewayv1.Empty, error) {
 	provider.Logger(ctx).Debugw("DeleteBackend", map[string]interface{}{
@@ -201,6 +234,23 @@ func toBackendResponseProto(backend *models.Backend) (*gatewayv1.Backend, error)
 		StatsUpdatedAt:       *backend.StatsUpdatedAt,
 		IsHealthy:            *backend.IsHealthy,
 	}
+	if backend.RunningQueries != nil {
+		response.RunningQueries = *backend.RunningQueries
+	}
+	if backend.QueuedQueries != nil {
+		response.QueuedQueries = *backend.QueuedQueries
+	}
+	if backend.AvgQueueTimeMs != nil {
+		response.AvgQueueTimeMs = *backend.AvgQueueTimeMs
+	}
+	if backend.ActiveNodes != nil {
+		response.ActiveNodes = *backend.ActiveNodes
+	}
+	tls, err := backendTls(backend)
+	if err != nil {
+		return nil, err
+	}
+	response.Tls = toTlsProto(tls)
 
 	return &response, nil
 }
@@ -0,0 +1,61 @@
+package backendapi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
+	"github.com/razorpay/trino-gateway/internal/tlsconfig"
+	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
+)
+
+// Tls are settings for connections of the router & monitor to the backend,
+// stored json encoded with the backend
+type Tls = tlsconfig.ClientOptions
+
+func encodeTls(t *Tls) (string, error) {
+	if t == nil || t.IsZero() {
+		return "", nil
+	}
+	if err := t.Validate(); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// backendTls returns the TLS settings of backend, nil if not set
+func backendTls(backend *models.Backend) (*Tls, error) {
+	if backend.Tls == nil || *backend.Tls == "" {
+		return nil, nil
+	}
+	var t Tls
+	if err := json.Unmarshal([]byte(*backend.Tls), &t); err != nil {
+		return nil, fmt.Errorf("invalid tls of backend %s: %w", backend.ID, err)
+	}
+	return &t, nil
+}
+
+func fromTlsProto(t *gatewayv1.BackendTls) *Tls {
+	if t == nil {
+		return nil
+	}
+	opts := tlsconfig.BackendClientOptions(t)
+	return &opts
+}
+
+func toTlsProto(t *Tls) *gatewayv1.BackendTls {
+	if t == nil {
+		return nil
+	}
+	return &gatewayv1.BackendTls{
+		CaFile:             t.CaFile,
+		CertFile:           t.CertFile,
+		KeyFile:            t.KeyFile,
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+}
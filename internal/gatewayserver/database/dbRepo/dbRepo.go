@@ -17,7 +17,9 @@ type IDbRepo interface {
 	FindWithConditionByIDs(ctx context.Context, receivers interface{}, condition map[string]interface{}, ids []string) error
 	FindMany(ctx context.Context, receivers interface{}, condition map[string]interface{}) error
 	Delete(ctx context.Context, receiver spine.IModel) error
+	DeleteWhere(ctx context.Context, receiver spine.IModel, query interface{}, args ...interface{}) error
 	Update(ctx context.Context, receiver spine.IModel, attrList ...string) error
+	UpdateColumns(ctx context.Context, receiver spine.IModel, columns map[string]interface{}, condition ...interface{}) error
 	Preload(ctx context.Context, query string, args ...interface{}) *spine.Repo
 	ClearAssociations(ctx context.Context, receiver spine.IModel, name string) error
 	ReplaceAssociations(ctx context.Context, receiver spine.IModel, name string, ass interface{}) error
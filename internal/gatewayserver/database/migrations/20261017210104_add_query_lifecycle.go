@@ -0,0 +1,47 @@
+package migration
+
+import (
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigration(Up20261017210104, Down20261017210104)
+}
+
+func Up20261017210104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec(`ALTER TABLE queries
+			ADD COLUMN state VARCHAR(32) NULL,
+			ADD COLUMN finished_at INT(11) NULL,
+			ADD COLUMN error_code INT DEFAULT 0,
+			ADD COLUMN error_name VARCHAR(255) DEFAULT '',
+			ADD COLUMN elapsed_time_ms BIGINT DEFAULT 0,
+			ADD COLUMN rows_returned BIGINT DEFAULT 0,
+			ADD COLUMN bytes_returned BIGINT DEFAULT 0,
+			ADD KEY queries_state_index (state);`)
+	if err != nil {
+		return err
+	}
+	return err
+}
+
+func Down20261017210104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec(`ALTER TABLE queries
+			DROP KEY queries_state_index,
+			DROP COLUMN state,
+			DROP COLUMN finished_at,
+			DROP COLUMN error_code,
+			DROP COLUMN error_name,
+			DROP COLUMN elapsed_time_ms,
+			DROP COLUMN rows_returned,
+			DROP COLUMN bytes_returned;`)
+	if err != nil {
+		return err
+	}
+	return err
+}
@@ -0,0 +1,43 @@
+package migration
+
+import (
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigration(Up20261017220104, Down20261017220104)
+}
+
+func Up20261017220104(tx *sql.Tx) error {
+	var err error
+
+	// policies with a condition don't have a rule of their own,
+	// enum is dropped as rule types are validated by the app
+	_, err = tx.Exec("ALTER TABLE `policies` MODIFY COLUMN `rule_type` VARCHAR(255) NULL;")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("ALTER TABLE `policies` ADD COLUMN `rule_condition` TEXT NULL, ADD COLUMN `priority` INT NOT NULL DEFAULT 0;")
+	if err != nil {
+		return err
+	}
+	return err
+}
+
+func Down20261017220104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec("ALTER TABLE `policies` DROP COLUMN `rule_condition`, DROP COLUMN `priority`;")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("ALTER TABLE `policies` MODIFY COLUMN `rule_type` ENUM ('header_client_tags', 'header_connection_properties', 'header_client_host', 'listening_port');")
+	if err != nil {
+		return err
+	}
+	return err
+}
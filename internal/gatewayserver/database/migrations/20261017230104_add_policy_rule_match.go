@@ -0,0 +1,31 @@
+package migration
+
+import (
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigration(Up20261017230104, Down20261017230104)
+}
+
+func Up20261017230104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec("ALTER TABLE `policies` ADD COLUMN `rule_match` VARCHAR(32) DEFAULT 'EXACT';")
+	if err != nil {
+		return err
+	}
+	return err
+}
+
+func Down20261017230104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec("ALTER TABLE `policies` DROP COLUMN `rule_match`;")
+	if err != nil {
+		return err
+	}
+	return err
+}
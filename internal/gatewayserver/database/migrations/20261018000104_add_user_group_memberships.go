@@ -0,0 +1,41 @@
+package migration
+
+import (
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigration(Up20261018000104, Down20261018000104)
+}
+
+func Up20261018000104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec(`CREATE TABLE user_group_memberships (
+			id int AUTO_INCREMENT,
+			username varchar(255) NOT NULL,
+			user_group varchar(255) NOT NULL,
+			created_at int(11),
+			updated_at int(11),
+			PRIMARY KEY (id),
+			UNIQUE KEY (username, user_group),
+			KEY users_created_at_index (created_at),
+			KEY users_updated_at_index (updated_at)
+		);`)
+	if err != nil {
+		return err
+	}
+	return err
+}
+
+func Down20261018000104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec("DROP TABLE `user_group_memberships`;")
+	if err != nil {
+		return err
+	}
+	return err
+}
@@ -0,0 +1,46 @@
+package migration
+
+import (
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigration(Up20261018010104, Down20261018010104)
+}
+
+func Up20261018010104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec("ALTER TABLE `groups_` MODIFY COLUMN `strategy` ENUM('random', 'round_robin', 'least_load', 'weighted') DEFAULT 'random';")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("ALTER TABLE `group_backends_mappings` ADD COLUMN `weight` INT NOT NULL DEFAULT 1;")
+	if err != nil {
+		return err
+	}
+	return err
+}
+
+func Down20261018010104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec("ALTER TABLE `group_backends_mappings` DROP COLUMN `weight`;")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE `groups_` SET `strategy` = 'random' WHERE `strategy` = 'weighted';")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("ALTER TABLE `groups_` MODIFY COLUMN `strategy` ENUM('random', 'round_robin', 'least_load') DEFAULT 'random';")
+	if err != nil {
+		return err
+	}
+	return err
+}
@@ -0,0 +1,41 @@
+package migration
+
+import (
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigration(Up20261018020104, Down20261018020104)
+}
+
+func Up20261018020104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec("ALTER TABLE `backends` ADD COLUMN `running_queries` INT NOT NULL DEFAULT 0, ADD COLUMN `queued_queries` INT NOT NULL DEFAULT 0, ADD COLUMN `avg_queue_time_ms` BIGINT NOT NULL DEFAULT 0, ADD COLUMN `active_nodes` INT NOT NULL DEFAULT 0;")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("ALTER TABLE `groups_` ADD COLUMN `load_formula` TEXT NULL;")
+	if err != nil {
+		return err
+	}
+	return err
+}
+
+func Down20261018020104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec("ALTER TABLE `groups_` DROP COLUMN `load_formula`;")
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("ALTER TABLE `backends` DROP COLUMN `running_queries`, DROP COLUMN `queued_queries`, DROP COLUMN `avg_queue_time_ms`, DROP COLUMN `active_nodes`;")
+	if err != nil {
+		return err
+	}
+	return err
+}
@@ -0,0 +1,41 @@
+package migration
+
+import (
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigration(Up20261018030104, Down20261018030104)
+}
+
+func Up20261018030104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec(`CREATE TABLE backend_health_events (
+			id int AUTO_INCREMENT,
+			backend_id varchar(50) NOT NULL,
+			is_healthy bool NOT NULL,
+			reason varchar(1024) NOT NULL DEFAULT '',
+			created_at int(11),
+			updated_at int(11),
+			PRIMARY KEY (id),
+			KEY backend_health_events_backend_id_index (backend_id),
+			KEY backend_health_events_created_at_index (created_at)
+		);`)
+	if err != nil {
+		return err
+	}
+	return err
+}
+
+func Down20261018030104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec("DROP TABLE `backend_health_events`;")
+	if err != nil {
+		return err
+	}
+	return err
+}
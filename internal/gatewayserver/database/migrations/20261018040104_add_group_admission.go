@@ -0,0 +1,31 @@
+package migration
+
+import (
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigration(Up20261018040104, Down20261018040104)
+}
+
+func Up20261018040104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec("ALTER TABLE `groups_` ADD COLUMN `admission` TEXT NULL;")
+	if err != nil {
+		return err
+	}
+	return err
+}
+
+func Down20261018040104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec("ALTER TABLE `groups_` DROP COLUMN `admission`;")
+	if err != nil {
+		return err
+	}
+	return err
+}
@@ -0,0 +1,43 @@
+package migration
+
+import (
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigration(Up20261018050104, Down20261018050104)
+}
+
+func Up20261018050104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec(`CREATE TABLE quotas (
+			id varchar(255),
+			key_type ENUM ('user', 'source', 'client_tag', 'listening_port') NOT NULL,
+			key_value varchar(255) NOT NULL,
+			queries_per_minute int NOT NULL DEFAULT 0,
+			max_concurrent_queries int NOT NULL DEFAULT 0,
+			is_enabled bool,
+			created_at int(11),
+			updated_at int(11),
+			PRIMARY KEY (id),
+			KEY quotas_created_at_index (created_at),
+			KEY quotas_updated_at_index (updated_at)
+		);`)
+	if err != nil {
+		return err
+	}
+	return err
+}
+
+func Down20261018050104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec("DROP TABLE `quotas`;")
+	if err != nil {
+		return err
+	}
+	return err
+}
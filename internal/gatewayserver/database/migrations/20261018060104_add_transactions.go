@@ -0,0 +1,40 @@
+package migration
+
+import (
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigration(Up20261018060104, Down20261018060104)
+}
+
+func Up20261018060104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec(`CREATE TABLE transactions (
+			id varchar(255),
+			backend_id varchar(255) NOT NULL,
+			group_id varchar(255) NULL,
+			username varchar(255) NULL,
+			created_at int(11),
+			updated_at int(11),
+			PRIMARY KEY (id),
+			KEY transactions_created_at_index (created_at)
+		);`)
+	if err != nil {
+		return err
+	}
+	return err
+}
+
+func Down20261018060104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec("DROP TABLE `transactions`;")
+	if err != nil {
+		return err
+	}
+	return err
+}
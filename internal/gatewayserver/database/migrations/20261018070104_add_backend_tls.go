@@ -0,0 +1,31 @@
+package migration
+
+import (
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigration(Up20261018070104, Down20261018070104)
+}
+
+func Up20261018070104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec("ALTER TABLE `backends` ADD COLUMN `tls` TEXT NULL;")
+	if err != nil {
+		return err
+	}
+	return err
+}
+
+func Down20261018070104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec("ALTER TABLE `backends` DROP COLUMN `tls`;")
+	if err != nil {
+		return err
+	}
+	return err
+}
@@ -0,0 +1,66 @@
+package migration
+
+import (
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigration(Up20261018080104, Down20261018080104)
+}
+
+// Service accounts exempted from authentication by the router before exemptions were managed via the api
+var seededAuthExemptionUsers = []string{
+	"capital-scorecard",
+	"care",
+	"cyber-helpdesk",
+	"datum",
+	"disputes",
+	"magic-checkout",
+	"partnerships",
+	"prod_api",
+	"api-service-payments.de-apps@razorpay.com",
+	"settlements",
+}
+
+func Up20261018080104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec(`CREATE TABLE auth_exemptions (
+			id varchar(255),
+			key_type ENUM ('user', 'listening_port', 'source_ip', 'source') NOT NULL,
+			key_value varchar(255) NOT NULL,
+			description varchar(1024) NOT NULL DEFAULT '',
+			is_enabled bool,
+			created_at int(11),
+			updated_at int(11),
+			PRIMARY KEY (id),
+			KEY auth_exemptions_created_at_index (created_at),
+			KEY auth_exemptions_updated_at_index (updated_at)
+		);`)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range seededAuthExemptionUsers {
+		_, err = tx.Exec(`INSERT INTO auth_exemptions
+			(id, key_type, key_value, description, is_enabled, created_at, updated_at)
+			VALUES (?, 'user', ?, 'service account exempted before exemptions were managed via the api', true, UNIX_TIMESTAMP(), UNIX_TIMESTAMP());`,
+			user, user)
+		if err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func Down20261018080104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec("DROP TABLE `auth_exemptions`;")
+	if err != nil {
+		return err
+	}
+	return err
+}
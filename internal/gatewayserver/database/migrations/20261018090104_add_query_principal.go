@@ -0,0 +1,32 @@
+package migration
+
+import (
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigration(Up20261018090104, Down20261018090104)
+}
+
+func Up20261018090104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec(`ALTER TABLE queries
+			ADD COLUMN principal VARCHAR(255) DEFAULT '';`)
+	if err != nil {
+		return err
+	}
+	return err
+}
+
+func Down20261018090104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec("ALTER TABLE `queries` DROP COLUMN `principal`;")
+	if err != nil {
+		return err
+	}
+	return err
+}
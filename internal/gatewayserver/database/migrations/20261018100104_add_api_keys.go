@@ -0,0 +1,45 @@
+package migration
+
+import (
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigration(Up20261018100104, Down20261018100104)
+}
+
+func Up20261018100104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec(`CREATE TABLE api_keys (
+			id varchar(255),
+			key_hash char(64) NOT NULL,
+			key_prefix varchar(16) NOT NULL DEFAULT '',
+			role ENUM ('viewer', 'operator', 'admin') NOT NULL,
+			permissions varchar(4096) NOT NULL DEFAULT '[]',
+			description varchar(1024) NOT NULL DEFAULT '',
+			is_enabled bool,
+			created_at int(11),
+			updated_at int(11),
+			PRIMARY KEY (id),
+			UNIQUE KEY api_keys_key_hash_unique (key_hash),
+			KEY api_keys_created_at_index (created_at),
+			KEY api_keys_updated_at_index (updated_at)
+		);`)
+	if err != nil {
+		return err
+	}
+	return err
+}
+
+func Down20261018100104(tx *sql.Tx) error {
+	var err error
+
+	_, err = tx.Exec("DROP TABLE `api_keys`;")
+	if err != nil {
+		return err
+	}
+	return err
+}
@@ -0,0 +1,39 @@
+package groupapi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
+	"github.com/razorpay/trino-gateway/internal/routing"
+)
+
+// Admission limits queries of the group, queries beyond them are queued by the router,
+// stored json encoded with the group
+type Admission = routing.Admission
+
+func encodeAdmission(a *Admission) (string, error) {
+	if a == nil {
+		return "", nil
+	}
+	if err := a.Validate(); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(a)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// groupAdmission returns the admission limits of group, nil if not set
+func groupAdmission(group *models.Group) (*Admission, error) {
+	if group.Admission == nil || *group.Admission == "" {
+		return nil, nil
+	}
+	var a Admission
+	if err := json.Unmarshal([]byte(*group.Admission), &a); err != nil {
+		return nil, fmt.Errorf("invalid admission of group %s: %w", group.ID, err)
+	}
+	return &a, nil
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"sort"
+	"math/rand"
 	"time"
 
 	"github.com/fatih/structs"
@@ -13,7 +13,7 @@ import (
 	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
 	"github.com/razorpay/trino-gateway/internal/gatewayserver/repo"
 	"github.com/razorpay/trino-gateway/internal/provider"
-	"github.com/razorpay/trino-gateway/internal/utils"
+	"github.com/razorpay/trino-gateway/internal/routing"
 )
 
 type Core struct {
@@ -44,14 +44,53 @@ type GroupCreateParams struct {
 	IsEnabled         bool
 	LastRoutedBackend string
 	Backends          []string
+	// defaults to 1 for backends not present
+	BackendWeights map[string]int32
+	// nil if the cluster load evaluated by monitor is to be used
+	LoadFormula *LoadFormula
+	// nil if queries of the group aren't to be queued at the gateway
+	Admission *Admission
 }
 
+// Weight of a backend in the group if not specified explicitly
+const defaultBackendWeight = routing.DefaultBackendWeight
+
 func (c *Core) CreateOrUpdateGroup(ctx context.Context, params *GroupCreateParams) error {
+	for backend, weight := range params.BackendWeights {
+		if weight < 0 {
+			return fmt.Errorf("invalid weight %d for backend %s, weight can't be negative", weight, backend)
+		}
+		found := false
+		for _, b := range params.Backends {
+			if b == backend {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("weight specified for backend %s which is not part of the group", backend)
+		}
+	}
+
+	loadFormula, err := encodeLoadFormula(params.LoadFormula)
+	if err != nil {
+		return err
+	}
+	admission, err := encodeAdmission(params.Admission)
+	if err != nil {
+		return err
+	}
+
 	var backendMappings []models.GroupBackendsMapping
 	for _, backend := range params.Backends {
+		weight := defaultBackendWeight
+		if w, ok := params.BackendWeights[backend]; ok {
+			weight = w
+		}
 		backendMappings = append(backendMappings, models.GroupBackendsMapping{
 			GroupId:   params.ID,
 			BackendId: backend,
+			Weight:    &weight,
 		})
 	}
 
@@ -59,9 +98,13 @@ func (c *Core) CreateOrUpdateGroup(ctx context.Context, params *GroupCreateParam
 		Strategy:          &params.Strategy,
 		IsEnabled:         &params.IsEnabled,
 		LastRoutedBackend: &params.LastRoutedBackend,
+		LoadFormula:       &loadFormula,
+		Admission:         &admission,
 	}
 	group.ID = params.ID
 	group.GroupBackendsMappings = backendMappings
+	defer routing.NotifyChanged()
+
 	_, notexists := c.groupRepo.Find(ctx, params.ID)
 	if notexists == nil { // update
 		return c.groupRepo.Update(ctx, &group)
@@ -120,14 +163,17 @@ func (c *Core) GetAllActiveGroups(ctx context.Context) ([]models.Group, error) {
 }
 
 func (c *Core) DeleteGroup(ctx context.Context, id string) error {
+	defer routing.NotifyChanged()
 	return c.groupRepo.Delete(ctx, id)
 }
 
 func (c *Core) EnableGroup(ctx context.Context, id string) error {
+	defer routing.NotifyChanged()
 	return c.groupRepo.Enable(ctx, id)
 }
 
 func (c *Core) DisableGroup(ctx context.Context, id string) error {
+	defer routing.NotifyChanged()
 	return c.groupRepo.Disable(ctx, id)
 }
 
@@ -141,16 +187,19 @@ func (c *Core) EvaluateBackendForGroups(ctx context.Context, groups []string) (s
 		return "", "", err
 	}
 
-	// Step 2: take intersections of all non nil grp sets; a nil set = any grp; all sets nil == route to fallbackGrp;
+	// Step 2: filter provided grp list to active grps, retaining the order of provided grp list
 	provider.Logger(ctx).Debug("Take intersection of active grps with provided grp list")
 	var eligibleGrps []*models.Group
-	for i, g := range activeGroups {
-		if utils.SliceContains(groups, g.ID) {
-			eligibleGrps = append(eligibleGrps, &activeGroups[i])
+	for _, gid := range groups {
+		for i, g := range activeGroups {
+			if g.ID == gid {
+				eligibleGrps = append(eligibleGrps, &activeGroups[i])
+				break
+			}
 		}
 	}
 
-	// Step 3: Evaluate Backends for each
+	// Step 3: Evaluate Backends for each grp in order, first grp having an eligible backend is chosen
 	evaluatedBackendId := make(map[*models.Group]string, len(eligibleGrps))
 	for _, g := range eligibleGrps {
 		backend_id, err := c.findBackend(ctx, *g)
@@ -159,13 +208,14 @@ func (c *Core) EvaluateBackendForGroups(ctx context.Context, groups []string) (s
 		}
 		if backend_id != nil {
 			evaluatedBackendId[g] = *backend_id
+			chosenGroup = g
+			break
 		}
 	}
 
 	// Step 4: Choose group & a backend
 	var chosenBackendId string
-	if len(evaluatedBackendId) > 0 {
-		chosenGroup = eligibleGrps[0]
+	if chosenGroup != nil {
 		chosenBackendId = evaluatedBackendId[chosenGroup]
 
 	} else {
@@ -221,64 +271,41 @@ func (c *Core) findBackend(ctx context.Context, group models.Group) (*string, er
 	}
 
 	// Step 2: Evaluate strategy
-	selectedBackendId := (activeBackends)[0].ID
+	formula, err := groupLoadFormula(&group)
+	if err != nil {
+		return nil, err
+	}
+	routingBackends := make([]routing.Backend, len(activeBackends))
+	for i := range activeBackends {
+		routingBackends[i] = toRoutingBackend(&activeBackends[i])
+	}
+	routingGroup := routing.Group{
+		ID:                group.ID,
+		Strategy:          *group.Strategy,
+		Backends:          backends,
+		BackendWeights:    make(map[string]int32, len(group.GroupBackendsMappings)),
+		LoadFormula:       formula,
+		LastRoutedBackend: *group.LastRoutedBackend,
+	}
+	for _, m := range group.GroupBackendsMappings {
+		routingGroup.BackendWeights[m.BackendId] = backendWeight(&m)
+	}
+
 	provider.Logger(ctx).Debugw("Evaluate strategy for the group", map[string]interface{}{"group": group.GetID(), "strategy": *group.Strategy})
-	switch strategy := *group.Strategy; strategy {
-	case "round_robin":
-		activeBackendIds := make([]string, len(activeBackends))
-		for i, b := range activeBackends {
-			activeBackendIds[i] = b.GetID()
-		}
-		lastRoutedBackendId := *group.LastRoutedBackend
-		activeBackendIds = append(activeBackendIds, lastRoutedBackendId)
-		sort.Strings(activeBackendIds)
-
-		index := 0
-		for i, b := range activeBackendIds {
-			if b == lastRoutedBackendId {
-				index = i
-			}
-		}
-		index = index + 1
-		if index >= len(activeBackendIds) {
-			index = 0
-		}
-		selectedBackendId = activeBackendIds[index]
-
-	case "least_load":
-		leastLoaded := (activeBackends)[0]
-		load := func(b *models.Backend) int {
-			curr := time.Now().Unix()
-			validityS := boot.Config.Monitor.StatsValiditySecs
-			if validityS == 0 || curr-*b.StatsUpdatedAt <= int64(validityS) {
-				provider.Logger(ctx).Debugw(
-					"ClusterLoad stats in valid time range",
-					map[string]interface{}{"backend": b.GetID(), "cluster_load": *b.ClusterLoad},
-				)
-				return int(*b.ClusterLoad)
-			}
-			provider.Logger(ctx).Infow(
-				"ClusterLoad stats too old to be valid, assuming load as 0",
-				map[string]interface{}{
-					"backend":          b.GetID(),
-					"validity_period":  validityS,
-					"stats_updated_at": *b.StatsUpdatedAt,
-				})
-			return 0
-		}
-		provider.Logger(ctx).Info("Selecting least loaded backend")
-		for _, b := range activeBackends {
-			if load(&b) < load(&leastLoaded) {
-				leastLoaded = b
-			}
-		}
-		selectedBackendId = leastLoaded.ID
-	default:
-		provider.Logger(ctx).Debugw("Falling back to `random` strategy for group", map[string]interface{}{"group": group.GetID(), "strategy": *group.Strategy})
+	selected := routing.SelectBackend(&routingGroup, routingBackends, routing.SelectOptions{
+		Now:               time.Now().Unix(),
+		StatsValiditySecs: boot.Config.Monitor.StatsValiditySecs,
+		RandInt63n:        rand.Int63n,
+	})
+	if selected == nil {
+		provider.Logger(ctx).Infow("All active backends of the group have zero weight", map[string]interface{}{"group": group.GetID()})
+		return nil, nil
 	}
+	selectedBackendId := *selected
+
 	// case RANDOM: return any
 	// case ROUND_ROBIN: order by ascending and take next bck_id after last_routed_backend
-	// case LOAD_BASED: get metrics of each backend and choose one with lowest running+queued_queries
+	// case LOAD_BASED: get metrics of each backend and choose one with lowest load as per the group's load formula
 	provider.Logger(ctx).Debugw("Backend evaluated for group", map[string]interface{}{"group": group.GetID(), "strategy": *group.Strategy, "backend": selectedBackendId})
 
 	updGrp := models.Group{LastRoutedBackend: &selectedBackendId}
@@ -287,3 +314,35 @@ func (c *Core) findBackend(ctx context.Context, group models.Group) (*string, er
 
 	return &selectedBackendId, nil
 }
+
+func backendWeight(m *models.GroupBackendsMapping) int32 {
+	if m.Weight == nil {
+		return defaultBackendWeight
+	}
+	return *m.Weight
+}
+
+func toRoutingBackend(b *models.Backend) routing.Backend {
+	deref32 := func(v *int32) int32 {
+		if v == nil {
+			return 0
+		}
+		return *v
+	}
+	res := routing.Backend{
+		ID:             b.ID,
+		IsEnabled:      b.IsEnabled != nil && *b.IsEnabled,
+		IsHealthy:      b.IsHealthy != nil && *b.IsHealthy,
+		ClusterLoad:    deref32(b.ClusterLoad),
+		RunningQueries: deref32(b.RunningQueries),
+		QueuedQueries:  deref32(b.QueuedQueries),
+		ActiveNodes:    deref32(b.ActiveNodes),
+	}
+	if b.AvgQueueTimeMs != nil {
+		res.AvgQueueTimeMs = *b.AvgQueueTimeMs
+	}
+	if b.StatsUpdatedAt != nil {
+		res.StatsUpdatedAt = *b.StatsUpdatedAt
+	}
+	return res
+}
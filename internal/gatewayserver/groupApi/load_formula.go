@@ -0,0 +1,39 @@
+package groupapi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
+	"github.com/razorpay/trino-gateway/internal/routing"
+)
+
+// LoadFormula evaluates load of a backend from its cluster stats for least_load strategy,
+// stored json encoded with the group
+type LoadFormula = routing.LoadFormula
+
+func encodeLoadFormula(f *LoadFormula) (string, error) {
+	if f == nil {
+		return "", nil
+	}
+	if err := f.Validate(); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(f)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// groupLoadFormula returns the load formula of group, nil if not set
+func groupLoadFormula(group *models.Group) (*LoadFormula, error) {
+	if group.LoadFormula == nil || *group.LoadFormula == "" {
+		return nil, nil
+	}
+	var f LoadFormula
+	if err := json.Unmarshal([]byte(*group.LoadFormula), &f); err != nil {
+		return nil, fmt.Errorf("invalid load formula of group %s: %w", group.ID, err)
+	}
+	return &f, nil
+}
@@ -38,6 +38,9 @@ func (s *Server) CreateOrUpdateGroup(ctx context.Context, req *gatewayv1.Group)
 		Backends:          req.GetBackends(),
 		IsEnabled:         req.GetIsEnabled(),
 		LastRoutedBackend: req.GetLastRoutedBackend(),
+		BackendWeights:    req.GetBackendWeights(),
+		LoadFormula:       fromLoadFormulaProto(req.GetLoadFormula()),
+		Admission:         fromAdmissionProto(req.GetAdmission()),
 	}
 
 	err := s.core.CreateOrUpdateGroup(ctx, &createParams)
@@ -139,8 +142,10 @@ func toGroupResponseProto(group *models.Group) (*gatewayv1.Group, error) {
 		return nil, errors.New(fmt.Sprint("error encoding response: invalid strategy ", *group.Strategy))
 	}
 	var backends []string
+	weights := make(map[string]int32, len(group.GroupBackendsMappings))
 	for _, backend := range group.GroupBackendsMappings {
 		backends = append(backends, backend.BackendId)
+		weights[backend.BackendId] = backendWeight(&backend)
 	}
 	response := gatewayv1.Group{
 		Id:                group.ID,
@@ -148,11 +153,68 @@ func toGroupResponseProto(group *models.Group) (*gatewayv1.Group, error) {
 		Backends:          backends,
 		IsEnabled:         *group.IsEnabled,
 		LastRoutedBackend: *group.LastRoutedBackend,
+		BackendWeights:    weights,
 	}
+	loadFormula, err := groupLoadFormula(group)
+	if err != nil {
+		return nil, err
+	}
+	response.LoadFormula = toLoadFormulaProto(loadFormula)
+	admission, err := groupAdmission(group)
+	if err != nil {
+		return nil, err
+	}
+	response.Admission = toAdmissionProto(admission)
 
 	return &response, nil
 }
 
+func fromLoadFormulaProto(f *gatewayv1.LoadFormula) *LoadFormula {
+	if f == nil {
+		return nil
+	}
+	return &LoadFormula{
+		RunningWeight:    f.GetRunningWeight(),
+		QueuedWeight:     f.GetQueuedWeight(),
+		QueueTimeWeight:  f.GetQueueTimeWeight(),
+		NormalizeByNodes: f.GetNormalizeByNodes(),
+	}
+}
+
+func toLoadFormulaProto(f *LoadFormula) *gatewayv1.LoadFormula {
+	if f == nil {
+		return nil
+	}
+	return &gatewayv1.LoadFormula{
+		RunningWeight:    f.RunningWeight,
+		QueuedWeight:     f.QueuedWeight,
+		QueueTimeWeight:  f.QueueTimeWeight,
+		NormalizeByNodes: f.NormalizeByNodes,
+	}
+}
+
+func fromAdmissionProto(a *gatewayv1.Admission) *Admission {
+	if a == nil {
+		return nil
+	}
+	return &Admission{
+		MaxConcurrency:   a.GetMaxConcurrency(),
+		MaxQueued:        a.GetMaxQueued(),
+		QueueTimeoutSecs: a.GetQueueTimeoutSecs(),
+	}
+}
+
+func toAdmissionProto(a *Admission) *gatewayv1.Admission {
+	if a == nil {
+		return nil
+	}
+	return &gatewayv1.Admission{
+		MaxConcurrency:   a.MaxConcurrency,
+		MaxQueued:        a.MaxQueued,
+		QueueTimeoutSecs: a.QueueTimeoutSecs,
+	}
+}
+
 func (s *Server) EvaluateBackendForGroups(ctx context.Context, req *gatewayv1.EvaluateBackendRequest) (*gatewayv1.EvaluateBackendResponse, error) {
 	provider.Logger(ctx).Debugw("EvaluateBackendForGroups", map[string]interface{}{
 		"request": req.String(),
@@ -2,34 +2,59 @@ package hooks
 
 import (
 	"context"
+	"crypto/subtle"
+	"errors"
 	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/twitchtv/twirp"
 
 	"github.com/razorpay/trino-gateway/internal/boot"
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/rbac"
+	"github.com/razorpay/trino-gateway/internal/provider"
 )
 
 type contextkey int
 
 const (
 	authTokenCtxKey contextkey = iota
-	authUrlPathCtxKey
+	authUserCtxKey
 )
 
-func Auth() *twirp.ServerHooks {
-	hooks := &twirp.ServerHooks{}
+// User of requests authenticated via the shared `auth.token`, e.g. by the router & the monitor
+const sharedTokenUser = "shared-token"
 
-	hooks.RequestReceived = func(ctx context.Context) (context.Context, error) {
-		m, _ := ctx.Value(authUrlPathCtxKey).(string)
-		if strings.Contains(m, "/Get") || strings.Contains(m, "/List") {
-			return ctx, nil
-		}
+var ErrInvalidApiKey = errors.New("invalid api key")
+
+// ApiKey is the identity of clients authenticated via an api key
+type ApiKey struct {
+	ID   string
+	Role string
+	// allowed in addition to the methods of the role
+	Permissions []string
+}
+
+type ApiKeyAuthenticator interface {
+	// AuthenticateApiKey returns the api key of the secret, ErrInvalidApiKey if it doesn't exist or is disabled
+	AuthenticateApiKey(ctx context.Context, key string) (*ApiKey, error)
+}
+
+// Auth authorizes requests as per the role & permissions of their api key, the shared `auth.token` has
+// the admin role. Requests without a key have the `auth.anonymousRole` if set, except for the
+// AuthExemptionApi & ApiKeyApi.
+func Auth(apiKeys ApiKeyAuthenticator) *twirp.ServerHooks {
+	hooks := &twirp.ServerHooks{}
 
+	// the method is known once the request is routed
+	hooks.RequestRouted = func(ctx context.Context) (context.Context, error) {
+		service, _ := twirp.ServiceName(ctx)
+		method, _ := twirp.MethodName(ctx)
 		token, _ := ctx.Value(authTokenCtxKey).(string)
 
 		if token == "" {
+			if rbac.AllowedAnonymous(boot.Config.Auth.AnonymousRole, service, method) {
+				return ctx, nil
+			}
 			return ctx, twirp.NewError(
 				twirp.Unauthenticated,
 				fmt.Sprint(
@@ -38,11 +63,27 @@ func Auth() *twirp.ServerHooks {
 			)
 		}
 
-		if boot.Config.Auth.Token == token {
-			return ctx, nil
+		if subtle.ConstantTimeCompare([]byte(boot.Config.Auth.Token), []byte(token)) == 1 {
+			return context.WithValue(ctx, authUserCtxKey, sharedTokenUser), nil
+		}
+
+		key, err := apiKeys.AuthenticateApiKey(ctx, token)
+		if errors.Is(err, ErrInvalidApiKey) {
+			return ctx, twirp.NewError(twirp.Unauthenticated, "invalid apiToken for authentication")
+		}
+		if err != nil {
+			provider.Logger(ctx).WithError(err).Error("Unable to authenticate api key")
+			return ctx, twirp.NewError(twirp.Unavailable, "unable to authenticate apiToken")
 		}
 
-		return ctx, twirp.NewError(twirp.Unauthenticated, "invalid apiToken for authentication")
+		ctx = context.WithValue(ctx, authUserCtxKey, key.ID)
+		if !rbac.Allowed(key.Role, key.Permissions, service, method) {
+			return ctx, twirp.NewError(
+				twirp.PermissionDenied,
+				fmt.Sprintf("api key %s with role %s is not allowed to call %s/%s", key.ID, key.Role, service, method),
+			)
+		}
+		return ctx, nil
 	}
 
 	return hooks
@@ -52,10 +93,8 @@ func WithAuth(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		token := r.Header.Get(boot.Config.Auth.TokenHeaderKey)
-		urlPath := r.URL.Path
 
 		ctx = context.WithValue(ctx, authTokenCtxKey, token)
-		ctx = context.WithValue(ctx, authUrlPathCtxKey, urlPath)
 
 		r = r.WithContext(ctx)
 
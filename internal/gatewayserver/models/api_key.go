@@ -0,0 +1,32 @@
+package models
+
+import "github.com/razorpay/trino-gateway/pkg/spine"
+
+// api key model struct definition
+type ApiKey struct {
+	spine.Model
+	// hex encoded sha256 of the secret
+	KeyHash   string `json:"key_hash"`
+	KeyPrefix string `json:"key_prefix"`
+	Role      string `json:"role"`
+	// json encoded list of permissions in addition to the ones of the role
+	Permissions string `json:"permissions"`
+	Description string `json:"description"`
+	IsEnabled   *bool  `json:"is_enabled" sql:"DEFAULT:true"`
+}
+
+func (u *ApiKey) TableName() string {
+	return "api_keys"
+}
+
+func (u *ApiKey) EntityName() string {
+	return "api_key"
+}
+
+func (u *ApiKey) SetDefaults() error {
+	return nil
+}
+
+func (u *ApiKey) Validate() error {
+	return nil
+}
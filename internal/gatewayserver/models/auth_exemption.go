@@ -0,0 +1,28 @@
+package models
+
+import "github.com/razorpay/trino-gateway/pkg/spine"
+
+// auth exemption model struct definition
+type AuthExemption struct {
+	spine.Model
+	KeyType     string `json:"key_type"`
+	KeyValue    string `json:"key_value"`
+	Description string `json:"description"`
+	IsEnabled   *bool  `json:"is_enabled" sql:"DEFAULT:true"`
+}
+
+func (u *AuthExemption) TableName() string {
+	return "auth_exemptions"
+}
+
+func (u *AuthExemption) EntityName() string {
+	return "auth_exemption"
+}
+
+func (u *AuthExemption) SetDefaults() error {
+	return nil
+}
+
+func (u *AuthExemption) Validate() error {
+	return nil
+}
@@ -17,6 +17,12 @@ type Backend struct {
 	ClusterLoad          *int32  `json:"cluster_load"`
 	ThresholdClusterLoad *int32  `json:"threshold_cluster_load"`
 	StatsUpdatedAt       *int64  `json:"stats_updated_at"`
+	RunningQueries       *int32  `json:"running_queries"`
+	QueuedQueries        *int32  `json:"queued_queries"`
+	AvgQueueTimeMs       *int64  `json:"avg_queue_time_ms"`
+	ActiveNodes          *int32  `json:"active_nodes"`
+	// json encoded TLS settings for connections to the backend, empty if defaults apply
+	Tls *string `json:"tls"`
 }
 
 func (u *Backend) TableName() string {
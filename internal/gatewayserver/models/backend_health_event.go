@@ -0,0 +1,28 @@
+package models
+
+import "github.com/razorpay/trino-gateway/pkg/spine"
+
+// backend health event model struct definition, recorded on change in health state of a backend
+type BackendHealthEvent struct {
+	spine.Model
+	ID        *int32 `json:"id" sql:"DEFAULT:NULL"`
+	BackendId string `json:"backend_id"`
+	IsHealthy bool   `json:"is_healthy"`
+	Reason    string `json:"reason"`
+}
+
+func (u *BackendHealthEvent) TableName() string {
+	return "backend_health_events"
+}
+
+func (u *BackendHealthEvent) EntityName() string {
+	return "backend_health_event"
+}
+
+func (u *BackendHealthEvent) SetDefaults() error {
+	return nil
+}
+
+func (u *BackendHealthEvent) Validate() error {
+	return nil
+}
@@ -5,9 +5,13 @@ import "github.com/razorpay/trino-gateway/pkg/spine"
 // group model struct definition
 type Group struct {
 	spine.Model
-	Strategy              *string                `json:"strategy"`
-	IsEnabled             *bool                  `json:"is_enabled" sql:"DEFAULT:true"`
-	LastRoutedBackend     *string                `json:"last_routed_backend"`
+	Strategy          *string `json:"strategy"`
+	IsEnabled         *bool   `json:"is_enabled" sql:"DEFAULT:true"`
+	LastRoutedBackend *string `json:"last_routed_backend"`
+	// json encoded load formula for least_load strategy
+	LoadFormula *string `json:"load_formula"`
+	// json encoded admission control limits, empty if queries of the group aren't queued at the gateway
+	Admission             *string                `json:"admission"`
 	GroupBackendsMappings []GroupBackendsMapping `gorm:"foreignKey:GroupId;references:ID"`
 }
 
@@ -32,6 +36,8 @@ type GroupBackendsMapping struct {
 	ID        *int32 `json:"id" sql:"DEFAULT:NULL"`
 	GroupId   string `json:"group_id" gorm:"primaryKey"`
 	BackendId string `json:"backend_id" gorm:"primaryKey"`
+	// relative share of traffic for weighted routing strategy
+	Weight *int32 `json:"weight" sql:"DEFAULT:1"`
 }
 
 func (u *GroupBackendsMapping) TableName() string {
@@ -7,11 +7,15 @@ type Policy struct {
 	spine.Model
 	RuleType         string  `json:"rule_type"`
 	RuleValue        string  `json:"rule_value"`
+	RuleMatch        string  `json:"rule_match"`
 	GroupId          string  `json:"group_id"`
 	FallbackGroupId  *string `json:"fallback_group_id"`
 	IsEnabled        *bool   `json:"is_enabled" sql:"DEFAULT:true"`
 	IsAuthDelegated  *bool   `json:"is_auth_delegated" sql:"DEFAULT:false"`
 	SetRequestSource *string `json:"set_request_source"`
+	// json encoded policyapi.Condition, empty if the policy only has a rule
+	RuleCondition *string `json:"rule_condition"`
+	Priority      *int32  `json:"priority" sql:"DEFAULT:0"`
 }
 
 func (u *Policy) TableName() string {
@@ -5,13 +5,21 @@ import "github.com/razorpay/trino-gateway/pkg/spine"
 // query model struct definition
 type Query struct {
 	spine.Model
-	Text        string `json:"text"`
-	ClientIp    string `json:"client_ip"`
-	GroupId     string `json:"group_id"`
-	BackendId   string `json:"backend_id"`
-	Username    string `json:"username"`
-	SubmittedAt int64  `json:"submitted_at"`
-	ServerHost  string `json:"server_host"`
+	Text          string `json:"text"`
+	ClientIp      string `json:"client_ip"`
+	GroupId       string `json:"group_id"`
+	BackendId     string `json:"backend_id"`
+	Username      string `json:"username"`
+	Principal     string `json:"principal"`
+	SubmittedAt   int64  `json:"submitted_at"`
+	ServerHost    string `json:"server_host"`
+	State         string `json:"state"`
+	FinishedAt    int64  `json:"finished_at"`
+	ErrorCode     int32  `json:"error_code"`
+	ErrorName     string `json:"error_name"`
+	ElapsedTimeMs int64  `json:"elapsed_time_ms"`
+	RowsReturned  int64  `json:"rows_returned"`
+	BytesReturned int64  `json:"bytes_returned"`
 }
 
 func (u *Query) TableName() string {
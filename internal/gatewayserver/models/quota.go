@@ -0,0 +1,29 @@
+package models
+
+import "github.com/razorpay/trino-gateway/pkg/spine"
+
+// quota model struct definition
+type Quota struct {
+	spine.Model
+	KeyType              string `json:"key_type"`
+	KeyValue             string `json:"key_value"`
+	QueriesPerMinute     *int32 `json:"queries_per_minute" sql:"DEFAULT:0"`
+	MaxConcurrentQueries *int32 `json:"max_concurrent_queries" sql:"DEFAULT:0"`
+	IsEnabled            *bool  `json:"is_enabled" sql:"DEFAULT:true"`
+}
+
+func (u *Quota) TableName() string {
+	return "quotas"
+}
+
+func (u *Quota) EntityName() string {
+	return "quota"
+}
+
+func (u *Quota) SetDefaults() error {
+	return nil
+}
+
+func (u *Quota) Validate() error {
+	return nil
+}
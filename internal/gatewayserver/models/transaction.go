@@ -0,0 +1,27 @@
+package models
+
+import "github.com/razorpay/trino-gateway/pkg/spine"
+
+// transaction model struct definition, binds a transaction to the backend it was started on
+type Transaction struct {
+	spine.Model
+	BackendId string `json:"backend_id"`
+	GroupId   string `json:"group_id"`
+	Username  string `json:"username"`
+}
+
+func (u *Transaction) TableName() string {
+	return "transactions"
+}
+
+func (u *Transaction) EntityName() string {
+	return "transaction"
+}
+
+func (u *Transaction) SetDefaults() error {
+	return nil
+}
+
+func (u *Transaction) Validate() error {
+	return nil
+}
@@ -0,0 +1,27 @@
+package models
+
+import "github.com/razorpay/trino-gateway/pkg/spine"
+
+// user group membership model struct definition
+type UserGroupMembership struct {
+	spine.Model
+	ID        *int32 `json:"id" sql:"DEFAULT:NULL"`
+	Username  string `json:"username"`
+	UserGroup string `json:"user_group"`
+}
+
+func (u *UserGroupMembership) TableName() string {
+	return "user_group_memberships"
+}
+
+func (u *UserGroupMembership) EntityName() string {
+	return "user_group_membership"
+}
+
+func (u *UserGroupMembership) SetDefaults() error {
+	return nil
+}
+
+func (u *UserGroupMembership) Validate() error {
+	return nil
+}
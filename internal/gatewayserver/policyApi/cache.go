@@ -0,0 +1,54 @@
+package policyapi
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
+)
+
+// activePoliciesCache holds active policies for evaluating client requests in-process.
+// Writes made via this instance invalidate it, ttl bounds the staleness
+// of writes made via other instances of the service.
+type activePoliciesCache struct {
+	mu        sync.RWMutex
+	ttl       time.Duration
+	policies  []models.Policy
+	expiresAt time.Time
+}
+
+func newActivePoliciesCache(ttl time.Duration) *activePoliciesCache {
+	return &activePoliciesCache{ttl: ttl}
+}
+
+// get returns cached policies, fetching them with load if expired
+func (c *activePoliciesCache) get(ctx context.Context, load func(ctx context.Context) ([]models.Policy, error)) ([]models.Policy, error) {
+	c.mu.RLock()
+	if time.Now().Before(c.expiresAt) {
+		defer c.mu.RUnlock()
+		return c.policies, nil
+	}
+	c.mu.RUnlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// might have been loaded while waiting for the lock
+	if time.Now().Before(c.expiresAt) {
+		return c.policies, nil
+	}
+	policies, err := load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.policies = policies
+	c.expiresAt = time.Now().Add(c.ttl)
+	return policies, nil
+}
+
+func (c *activePoliciesCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.policies = nil
+	c.expiresAt = time.Time{}
+}
@@ -2,6 +2,9 @@ package policyapi
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"strconv"
 
 	"github.com/fatih/structs"
@@ -9,10 +12,14 @@ import (
 	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
 	"github.com/razorpay/trino-gateway/internal/gatewayserver/repo"
 	"github.com/razorpay/trino-gateway/internal/provider"
+	"github.com/razorpay/trino-gateway/internal/routing"
+	"github.com/razorpay/trino-gateway/internal/utils"
 )
 
 type Core struct {
-	policyRepo repo.IPolicyRepo
+	policyRepo     repo.IPolicyRepo
+	activePolicies *activePoliciesCache
+	userGroups     IUserGroupProvider
 }
 
 type ICore interface {
@@ -24,15 +31,29 @@ type ICore interface {
 	EnablePolicy(ctx context.Context, id string) error
 	DisablePolicy(ctx context.Context, id string) error
 
-	EvaluateGroupsForClient(ctx context.Context, c *EvaluateClientParams) ([]string, error)
+	EvaluateGroupsForClient(ctx context.Context, c *routing.ClientParams) ([]string, error)
+	GetAllUserGroupMemberships(ctx context.Context) (map[string][]string, error)
 	EvaluateAuthDelegation(ctx context.Context, p int32) (bool, error)
 	EvaluateRequestSource(ctx context.Context, p int32) (string, error)
 	// EvaluatePolicy(ctx context.Context, group string) (string, error)
 	// FindPolicyForQuery(ctx context.Context, q string) (string, error)
 }
 
-func NewCore(policy repo.IPolicyRepo) *Core {
-	return &Core{policyRepo: policy}
+// NewCore returns a new instance of *Core, userGroups can be nil if
+// no source of user group memberships is configured.
+func NewCore(policy repo.IPolicyRepo, userGroups IUserGroupProvider) (*Core, error) {
+	cacheTTL, err := utils.ParseOptionalDuration(boot.Config.Gateway.PolicyCacheTTL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gateway.policyCacheTTL: %w", err)
+	}
+	if cacheTTL < 0 {
+		return nil, errors.New("gateway.policyCacheTTL must not be negative, 0 disables caching")
+	}
+	return &Core{
+		policyRepo:     policy,
+		activePolicies: newActivePoliciesCache(cacheTTL),
+		userGroups:     userGroups,
+	}, nil
 }
 
 // CreateParams has attributes that are required for policy.Create()
@@ -40,22 +61,48 @@ type PolicyCreateParams struct {
 	ID               string
 	RuleType         string
 	RuleValue        string
+	RuleMatch        string
 	Group            string
 	FallbackGroup    string
 	IsEnabled        bool
 	IsAuthDelegated  bool
 	SetRequestSource string
+	Condition        *routing.Condition
+	Priority         int32
 }
 
 func (c *Core) CreateOrUpdatePolicy(ctx context.Context, params *PolicyCreateParams) error {
+	var ruleCondition string
+	if params.Condition != nil {
+		if err := params.Condition.Validate(); err != nil {
+			return err
+		}
+		b, err := json.Marshal(params.Condition)
+		if err != nil {
+			return err
+		}
+		ruleCondition = string(b)
+	} else {
+		if params.RuleType == "" {
+			return errors.New("policy requires either a rule or a condition")
+		}
+		rule := routing.Rule{Type: params.RuleType, Value: params.RuleValue, MatchType: params.RuleMatch}
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+	}
+
 	policy := models.Policy{
 		RuleType:         params.RuleType,
 		RuleValue:        params.RuleValue,
+		RuleMatch:        params.RuleMatch,
 		GroupId:          params.Group,
 		FallbackGroupId:  &params.FallbackGroup,
 		IsEnabled:        &params.IsEnabled,
 		IsAuthDelegated:  &params.IsAuthDelegated,
 		SetRequestSource: &params.SetRequestSource,
+		RuleCondition:    &ruleCondition,
+		Priority:         &params.Priority,
 	}
 	policy.ID = params.ID
 
@@ -63,6 +110,8 @@ func (c *Core) CreateOrUpdatePolicy(ctx context.Context, params *PolicyCreatePar
 		policy.FallbackGroupId = &boot.Config.Gateway.DefaultRoutingGroup
 	}
 
+	defer c.invalidate()
+
 	_, exists := c.policyRepo.Find(ctx, params.ID)
 	if exists == nil { // update
 		return c.policyRepo.Update(ctx, &policy)
@@ -71,6 +120,12 @@ func (c *Core) CreateOrUpdatePolicy(ctx context.Context, params *PolicyCreatePar
 	}
 }
 
+// invalidate discards policies cached for evaluation, following a write
+func (c *Core) invalidate() {
+	c.activePolicies.invalidate()
+	routing.NotifyChanged()
+}
+
 func (c *Core) GetPolicy(ctx context.Context, id string) (*models.Policy, error) {
 	policy, err := c.policyRepo.Find(ctx, id)
 	return policy, err
@@ -134,93 +189,103 @@ func (c *Core) GetAllActivePolicies(ctx context.Context) ([]models.Policy, error
 }
 
 func (c *Core) DeletePolicy(ctx context.Context, id string) error {
+	defer c.invalidate()
 	return c.policyRepo.Delete(ctx, id)
 }
 
 func (c *Core) EnablePolicy(ctx context.Context, id string) error {
+	defer c.invalidate()
 	return c.policyRepo.Enable(ctx, id)
 }
 
 func (c *Core) DisablePolicy(ctx context.Context, id string) error {
+	defer c.invalidate()
 	return c.policyRepo.Disable(ctx, id)
 }
 
-type EvaluateClientParams struct {
-	ListeningPort              int32
-	Hostname                   string
-	HeaderConnectionProperties string
-	HeaderClientTags           string
+// Returns the condition of the policy, nil if the policy only has a rule
+func policyCondition(policy *models.Policy) (*routing.Condition, error) {
+	if policy.RuleCondition == nil || *policy.RuleCondition == "" {
+		return nil, nil
+	}
+	var cond routing.Condition
+	if err := json.Unmarshal([]byte(*policy.RuleCondition), &cond); err != nil {
+		return nil, err
+	}
+	return &cond, nil
 }
 
-func (c *Core) EvaluateGroupsForClient(ctx context.Context, params *EvaluateClientParams) ([]string, error) {
-	// policies, err := c.GetAllActivePolicies(ctx)
-	var err error
-
-	// Using a map instead of slice for returning groups, to simulate a 'set' data type
-	findGroupsForPolicyTypes := func(ruleType string, ruleValue string) (*map[string]struct{}, error) {
-		provider.Logger(ctx).Debugw("Fetching policies for rule", map[string]interface{}{
-			"ruleType":  ruleType,
-			"ruleValue": ruleValue,
-		})
-		activePolicies, err := c.FindMany(
-			ctx,
-			&FindManyParams{
-				IsEnabled: true,
-				RuleType:  ruleType,
-				RuleValue: ruleValue,
-			})
-		if err != nil {
-			return nil, err
-		}
-		gids := make(map[string]struct{})
-		for _, policy := range activePolicies {
-			gids[policy.GroupId] = struct{}{}
-		}
-		provider.Logger(ctx).Debugw("Groups matching rule", map[string]interface{}{
-			"ruleType":  ruleType,
-			"ruleValue": ruleValue,
-			"GIDs":      gids,
-		})
-		if len(gids) == 0 {
-			gids = map[string]struct{}(nil)
-		}
-		return &gids, nil
+func policyPriority(policy *models.Policy) int32 {
+	if policy.Priority == nil {
+		return 0
 	}
+	return *policy.Priority
+}
 
-	// Step 1: find all policies
-	listeningPortPolicies, err := findGroupsForPolicyTypes("listening_port", strconv.Itoa(int(params.ListeningPort)))
+// EvaluateGroupsForClient returns groups eligible for the client request,
+// see routing.EvaluateGroups for the semantics of policy evaluation.
+func (c *Core) EvaluateGroupsForClient(ctx context.Context, params *routing.ClientParams) ([]string, error) {
+	policies, err := c.activePolicies.get(ctx, c.GetAllActivePolicies)
 	if err != nil {
 		return nil, err
 	}
 
-	hostnamePolicies, err := findGroupsForPolicyTypes("header_host", params.Hostname)
-	if err != nil {
-		return nil, err
+	if params.UserGroups == nil && params.User != "" && c.userGroups != nil {
+		params.UserGroups, err = c.userGroups.GetGroupsForUser(ctx, params.User)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	clientTagsPolicies, err := findGroupsForPolicyTypes("header_client_tags", params.HeaderClientTags)
-	if err != nil {
-		return nil, err
+	routingPolicies := make([]routing.Policy, 0, len(policies))
+	for i := range policies {
+		p, err := toRoutingPolicy(&policies[i])
+		if err != nil {
+			provider.Logger(ctx).WithError(err).Errorw("Invalid condition of policy, skipping it", map[string]interface{}{
+				"policy_id": policies[i].ID,
+			})
+			continue
+		}
+		routingPolicies = append(routingPolicies, p)
 	}
 
-	clientConnPropsPolicies, err := findGroupsForPolicyTypes("header_connection_properties", params.HeaderConnectionProperties)
+	gids := routing.EvaluateGroups(routingPolicies, params)
+	provider.Logger(ctx).Debugw("Groups matching policies", map[string]interface{}{
+		"GIDs": gids,
+	})
+	return gids, nil
+}
+
+func toRoutingPolicy(policy *models.Policy) (routing.Policy, error) {
+	cond, err := policyCondition(policy)
 	if err != nil {
-		return nil, err
+		return routing.Policy{}, err
 	}
-
-	// Step 2: take intersections of all non nil grp sets; a nil set = any grp; all sets nil == route to fallbackGrp;
-	provider.Logger(ctx).Debug("Taking intersection of all eligible non-nil groups sets")
-	gids := setIntersection(setIntersection(setIntersection(*listeningPortPolicies, *hostnamePolicies), *clientTagsPolicies), *clientConnPropsPolicies)
-
-	res := make([]string, len(gids))
-	i := 0
-	for k := range gids {
-		res[i] = k
-		i++
+	res := routing.Policy{
+		ID:        policy.ID,
+		GroupId:   policy.GroupId,
+		Rule:      routing.Rule{Type: policy.RuleType, Value: policy.RuleValue, MatchType: policy.RuleMatch},
+		Condition: cond,
+		Priority:  policyPriority(policy),
+	}
+	if policy.IsAuthDelegated != nil {
+		res.IsAuthDelegated = *policy.IsAuthDelegated
+	}
+	if policy.SetRequestSource != nil {
+		res.SetRequestSource = *policy.SetRequestSource
 	}
 	return res, nil
 }
 
+// GetAllUserGroupMemberships returns groups of all the users, empty if
+// no source of user group memberships is configured.
+func (c *Core) GetAllUserGroupMemberships(ctx context.Context) (map[string][]string, error) {
+	if c.userGroups == nil {
+		return map[string][]string{}, nil
+	}
+	return c.userGroups.GetAllMemberships(ctx)
+}
+
 func (c *Core) EvaluateAuthDelegation(ctx context.Context, port int32) (bool, error) {
 	res, err := c.FindMany(
 		ctx,
@@ -263,20 +328,3 @@ func (c *Core) EvaluateRequestSource(ctx context.Context, port int32) (string, e
 	}
 	return "", nil
 }
-
-// Implementing "set" collection methods here, :)
-func setIntersection(s1 map[string]struct{}, s2 map[string]struct{}) map[string]struct{} {
-	s_intersection := map[string]struct{}{}
-	if len(s1) > len(s2) {
-		s1, s2 = s2, s1 // better to iterate over a shorter set
-	}
-	if s1 == nil {
-		return s2
-	}
-	for k, _ := range s1 {
-		if _, found := s2[k]; found {
-			s_intersection[k] = struct{}{}
-		}
-	}
-	return s_intersection
-}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
 	"github.com/razorpay/trino-gateway/internal/provider"
+	"github.com/razorpay/trino-gateway/internal/routing"
 	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
 	_ "github.com/twitchtv/twirp"
 )
@@ -33,13 +34,18 @@ func (s *Server) CreateOrUpdatePolicy(ctx context.Context, req *gatewayv1.Policy
 
 	createParams := PolicyCreateParams{
 		ID:               req.GetId(),
-		RuleType:         req.GetRule().GetType().Enum().String(),
 		RuleValue:        req.GetRule().GetValue(),
 		Group:            req.GetGroup(),
 		FallbackGroup:    req.GetFallbackGroup(),
 		IsEnabled:        req.GetIsEnabled(),
 		IsAuthDelegated:  req.GetIsAuthDelegated(),
 		SetRequestSource: req.GetSetRequestSource(),
+		Condition:        routing.ConditionFromProto(req.GetCondition()),
+		Priority:         req.GetPriority(),
+	}
+	if req.GetRule() != nil {
+		createParams.RuleType = req.GetRule().GetType().Enum().String()
+		createParams.RuleMatch = req.GetRule().GetMatch().Enum().String()
 	}
 
 	err := s.core.CreateOrUpdatePolicy(ctx, &createParams)
@@ -131,26 +137,79 @@ func (s *Server) DeletePolicy(ctx context.Context, req *gatewayv1.PolicyDeleteRe
 	return &gatewayv1.Empty{}, nil
 }
 
-func toPolicyResponseProto(policy *models.Policy) (*gatewayv1.Policy, error) {
-	if policy == nil {
-		return &gatewayv1.Policy{}, nil
+func toRuleProto(rule *routing.Rule) (*gatewayv1.Policy_Rule, error) {
+	rule_type, ok := gatewayv1.Policy_Rule_RuleType_value[rule.Type]
+	if !ok {
+		return nil, errors.New(fmt.Sprint("error encoding response: invalid rule_type ", rule.Type))
 	}
-	rule_type, ok := gatewayv1.Policy_Rule_RuleType_value[policy.RuleType]
+	match, ok := gatewayv1.Policy_Rule_MatchType_value[rule.EffectiveMatchType()]
 	if !ok {
-		return nil, errors.New(fmt.Sprint("error encoding response: invalid rule_type ", policy.RuleType))
+		return nil, errors.New(fmt.Sprint("error encoding response: invalid rule_match ", rule.MatchType))
 	}
-	rule := gatewayv1.Policy_Rule{
+	return &gatewayv1.Policy_Rule{
 		Type:  *gatewayv1.Policy_Rule_RuleType(rule_type).Enum(),
-		Value: policy.RuleValue,
+		Value: rule.Value,
+		Match: *gatewayv1.Policy_Rule_MatchType(match).Enum(),
+	}, nil
+}
+
+func toConditionProto(cond *routing.Condition) (*gatewayv1.Policy_Condition, error) {
+	operator, ok := gatewayv1.Policy_Condition_Operator_value[cond.Operator]
+	if !ok {
+		return nil, errors.New(fmt.Sprint("error encoding response: invalid condition operator ", cond.Operator))
+	}
+	res := gatewayv1.Policy_Condition{
+		Operator: *gatewayv1.Policy_Condition_Operator(operator).Enum(),
+	}
+	if cond.Rule != nil {
+		rule, err := toRuleProto(cond.Rule)
+		if err != nil {
+			return nil, err
+		}
+		res.Rule = rule
+	}
+	for i := range cond.Conditions {
+		c, err := toConditionProto(&cond.Conditions[i])
+		if err != nil {
+			return nil, err
+		}
+		res.Conditions = append(res.Conditions, c)
+	}
+	return &res, nil
+}
+
+func toPolicyResponseProto(policy *models.Policy) (*gatewayv1.Policy, error) {
+	if policy == nil {
+		return &gatewayv1.Policy{}, nil
 	}
 	response := gatewayv1.Policy{
 		Id:               policy.ID,
-		Rule:             &rule,
 		Group:            policy.GroupId,
 		FallbackGroup:    *policy.FallbackGroupId,
 		IsEnabled:        *policy.IsEnabled,
 		IsAuthDelegated:  *policy.IsAuthDelegated,
 		SetRequestSource: *policy.SetRequestSource,
+		Priority:         policyPriority(policy),
+	}
+
+	// policies having only a condition don't have a rule
+	if policy.RuleType != "" {
+		rule, err := toRuleProto(&routing.Rule{Type: policy.RuleType, Value: policy.RuleValue, MatchType: policy.RuleMatch})
+		if err != nil {
+			return nil, err
+		}
+		response.Rule = rule
+	}
+
+	cond, err := policyCondition(policy)
+	if err != nil {
+		return nil, errors.New(fmt.Sprint("error encoding response: invalid condition ", err.Error()))
+	}
+	if cond != nil {
+		response.Condition, err = toConditionProto(cond)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return &response, nil
@@ -163,11 +222,17 @@ func (s *Server) EvaluateGroupsForClient(ctx context.Context, req *gatewayv1.Eva
 
 	gids, err := s.core.EvaluateGroupsForClient(
 		ctx,
-		&EvaluateClientParams{
+		&routing.ClientParams{
 			ListeningPort:              req.GetIncomingPort(),
 			Hostname:                   req.GetHost(),
+			Sni:                        req.GetSni(),
 			HeaderConnectionProperties: req.GetHeaderConnectionProperties(),
 			HeaderClientTags:           req.GetHeaderClientTags(),
+			User:                       req.GetUser(),
+			StatementType:              req.GetStatementType(),
+			Catalogs:                   req.GetCatalogs(),
+			Schemas:                    req.GetSchemas(),
+			Tables:                     req.GetTables(),
 		})
 
 	if err != nil {
@@ -221,3 +286,17 @@ func (s *Server) EvaluateRequestSourceForClient(ctx context.Context, req *gatewa
 	}
 	return &gatewayv1.EvaluateRequestSourceResponse{SetRequestSource: result}, nil
 }
+
+func (s *Server) ListUserGroupMemberships(ctx context.Context, _ *gatewayv1.Empty) (*gatewayv1.UserGroupMembershipsListResponse, error) {
+	provider.Logger(ctx).Debug("ListUserGroupMemberships")
+
+	memberships, err := s.core.GetAllUserGroupMemberships(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := gatewayv1.UserGroupMembershipsListResponse{UserGroups: make(map[string]*gatewayv1.UserGroups, len(memberships))}
+	for user, groups := range memberships {
+		res.UserGroups[user] = &gatewayv1.UserGroups{Groups: groups}
+	}
+	return &res, nil
+}
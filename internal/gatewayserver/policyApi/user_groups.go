@@ -0,0 +1,137 @@
+package policyapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/razorpay/trino-gateway/internal/boot"
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/repo"
+	"github.com/razorpay/trino-gateway/internal/provider"
+)
+
+// Sources of user group memberships
+const (
+	UserGroupSourceFile = "file"
+	UserGroupSourceDb   = "db"
+)
+
+// IUserGroupProvider resolves the groups a user is a member of, for evaluating user_group rules
+type IUserGroupProvider interface {
+	GetGroupsForUser(ctx context.Context, user string) ([]string, error)
+	// GetAllMemberships returns groups of all the users, keyed by user
+	GetAllMemberships(ctx context.Context) (map[string][]string, error)
+}
+
+// userGroupProvider holds memberships of all users in memory, they are reloaded
+// from the source once refreshInterval elapses. Last loaded memberships are
+// retained if reloading fails.
+type userGroupProvider struct {
+	mu              sync.RWMutex
+	refreshInterval time.Duration
+	load            func(ctx context.Context) (map[string][]string, error)
+	memberships     map[string][]string
+	loadedAt        time.Time
+}
+
+// NewUserGroupProvider returns provider for the source configured in `gateway.userGroups`,
+// nil if no source is configured.
+func NewUserGroupProvider(ctx context.Context, membershipRepo repo.IUserGroupMembershipRepo) (IUserGroupProvider, error) {
+	cfg := boot.Config.Gateway.UserGroups
+	refreshInterval, _ := time.ParseDuration(cfg.RefreshInterval)
+
+	p := &userGroupProvider{refreshInterval: refreshInterval}
+	switch cfg.Source {
+	case "":
+		return nil, nil
+	case UserGroupSourceFile:
+		p.load = func(ctx context.Context) (map[string][]string, error) {
+			return loadUserGroupsFile(cfg.File)
+		}
+	case UserGroupSourceDb:
+		p.load = func(ctx context.Context) (map[string][]string, error) {
+			return loadUserGroupsDb(ctx, membershipRepo)
+		}
+	default:
+		return nil, fmt.Errorf("invalid user group source %q", cfg.Source)
+	}
+
+	// fail fast on misconfigured source
+	if _, err := p.GetAllMemberships(ctx); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *userGroupProvider) GetGroupsForUser(ctx context.Context, user string) ([]string, error) {
+	memberships, err := p.GetAllMemberships(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return memberships[user], nil
+}
+
+// GetAllMemberships returns the loaded memberships, callers must not modify them
+func (p *userGroupProvider) GetAllMemberships(ctx context.Context) (map[string][]string, error) {
+	p.mu.RLock()
+	if p.memberships != nil && time.Since(p.loadedAt) < p.refreshInterval {
+		defer p.mu.RUnlock()
+		return p.memberships, nil
+	}
+	p.mu.RUnlock()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.memberships != nil && time.Since(p.loadedAt) < p.refreshInterval {
+		return p.memberships, nil
+	}
+	memberships, err := p.load(ctx)
+	if err != nil {
+		if p.memberships == nil {
+			return nil, err
+		}
+		provider.Logger(ctx).WithError(err).Error("Unable to reload user group memberships, retaining the last loaded ones")
+		// retry on next refresh
+		p.loadedAt = time.Now()
+		return p.memberships, nil
+	}
+	p.memberships = memberships
+	p.loadedAt = time.Now()
+	return p.memberships, nil
+}
+
+// Reads memberships from a json file having list of users for each group, e.g.
+// {"etl": ["airflow", "spark"], "analysts": ["alice"]}
+func loadUserGroupsFile(path string) (map[string][]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var groupUsers map[string][]string
+	if err := json.Unmarshal(b, &groupUsers); err != nil {
+		return nil, fmt.Errorf("invalid user groups file %s: %w", path, err)
+	}
+
+	memberships := make(map[string][]string)
+	for group, users := range groupUsers {
+		for _, user := range users {
+			memberships[user] = append(memberships[user], group)
+		}
+	}
+	return memberships, nil
+}
+
+func loadUserGroupsDb(ctx context.Context, membershipRepo repo.IUserGroupMembershipRepo) (map[string][]string, error) {
+	rows, err := membershipRepo.FindMany(ctx, make(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+	memberships := make(map[string][]string)
+	for _, m := range rows {
+		memberships[m.Username] = append(memberships[m.Username], m.UserGroup)
+	}
+	return memberships, nil
+}
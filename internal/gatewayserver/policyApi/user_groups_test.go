@@ -0,0 +1,29 @@
+package policyapi
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_loadUserGroupsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "user_groups.json")
+	err := os.WriteFile(path, []byte(`{"etl": ["airflow", "spark"], "batch": ["airflow"]}`), 0o600)
+	assert.Nil(t, err)
+
+	memberships, err := loadUserGroupsFile(path)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"etl", "batch"}, memberships["airflow"])
+	assert.Equal(t, []string{"etl"}, memberships["spark"])
+	assert.Nil(t, memberships["alice"])
+
+	err = os.WriteFile(path, []byte(`["airflow"]`), 0o600)
+	assert.Nil(t, err)
+	_, err = loadUserGroupsFile(path)
+	assert.NotNil(t, err)
+
+	_, err = loadUserGroupsFile(filepath.Join(t.TempDir(), "missing.json"))
+	assert.NotNil(t, err)
+}
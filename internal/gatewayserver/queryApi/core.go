@@ -2,33 +2,46 @@ package queryapi
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/fatih/structs"
 	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
 	"github.com/razorpay/trino-gateway/internal/gatewayserver/repo"
 	fetcherPkg "github.com/razorpay/trino-gateway/pkg/fetcher"
+	"github.com/razorpay/trino-gateway/pkg/spine"
 )
 
 var entityName string = (&models.Query{}).EntityName()
 
 type Core struct {
-	queryRepo repo.IQueryRepo
-	fetcher   fetcherPkg.IClient
+	queryRepo       repo.IQueryRepo
+	transactionRepo repo.ITransactionRepo
+	fetcher         fetcherPkg.IClient
+	// bindings of transactions started longer ago are expired, 0 never expires them
+	transactionTtl time.Duration
 }
 
 type ICore interface {
 	CreateOrUpdateQuery(ctx context.Context, params *QueryCreateParams) error
 	GetQuery(ctx context.Context, id string) (*models.Query, error)
+	UpdateQueryState(ctx context.Context, params *QueryStateUpdateParams) error
 	FindMany(ctx context.Context, params IFindManyParams) ([]models.Query, error)
+
+	BindTransaction(ctx context.Context, params *TransactionBindParams) error
+	GetTransaction(ctx context.Context, id string) (*models.Transaction, error)
+	UnbindTransaction(ctx context.Context, id string) error
 }
 
-func NewCore(query repo.IQueryRepo, fetcher fetcherPkg.IClient) *Core {
+func NewCore(query repo.IQueryRepo, transaction repo.ITransactionRepo, fetcher fetcherPkg.IClient, transactionTtl time.Duration) *Core {
 	if !fetcher.IsEntityRegistered(entityName) {
 		fetcher.Register(entityName, &models.Query{}, &[]models.Query{})
 	}
 	return &Core{
-		queryRepo: query,
-		fetcher:   fetcher,
+		queryRepo:       query,
+		transactionRepo: transaction,
+		fetcher:         fetcher,
+		transactionTtl:  transactionTtl,
 	}
 }
 
@@ -39,9 +52,18 @@ type QueryCreateParams struct {
 	ClientIp    string
 	BackendId   string
 	Username    string
+	Principal   string
 	GroupId     string
 	ServerHost  string
 	SubmittedAt int64
+
+	// lifecycle, as observed in the response of query submission
+	State         string
+	ErrorCode     int32
+	ErrorName     string
+	ElapsedTimeMs int64
+	RowsReturned  int64
+	BytesReturned int64
 }
 
 func (c *Core) CreateOrUpdateQuery(ctx context.Context, params *QueryCreateParams) error {
@@ -50,9 +72,20 @@ func (c *Core) CreateOrUpdateQuery(ctx context.Context, params *QueryCreateParam
 		ClientIp:    params.ClientIp,
 		BackendId:   params.BackendId,
 		Username:    params.Username,
+		Principal:   params.Principal,
 		GroupId:     params.GroupId,
 		ServerHost:  params.ServerHost,
 		SubmittedAt: params.SubmittedAt,
+
+		State:         params.State,
+		ErrorCode:     params.ErrorCode,
+		ErrorName:     params.ErrorName,
+		ElapsedTimeMs: params.ElapsedTimeMs,
+		RowsReturned:  params.RowsReturned,
+		BytesReturned: params.BytesReturned,
+	}
+	if isTerminalState(query.State) {
+		query.FinishedAt = time.Now().Unix()
 	}
 	query.ID = params.ID
 	_, exists := c.queryRepo.Find(ctx, params.ID)
@@ -68,6 +101,56 @@ func (c *Core) GetQuery(ctx context.Context, id string) (*models.Query, error) {
 	return query, err
 }
 
+// QueryStateUpdateParams has attributes that are required for updating the lifecycle of a query
+type QueryStateUpdateParams struct {
+	ID            string
+	State         string
+	ErrorCode     int32
+	ErrorName     string
+	ElapsedTimeMs int64
+
+	// increments, added to the values already stored for the query
+	RowsReturned  int64
+	BytesReturned int64
+}
+
+// Query states in which the query is not expected to change anymore
+var terminalStates = []string{"FINISHED", "FAILED", "CANCELED"}
+
+func isTerminalState(state string) bool {
+	for _, s := range terminalStates {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Core) UpdateQueryState(ctx context.Context, params *QueryStateUpdateParams) error {
+	if _, err := c.queryRepo.Find(ctx, params.ID); err != nil {
+		return err
+	}
+
+	// Polls of the same query can be in flight concurrently, so the update is
+	// applied atomically by the repo: counts are added to the stored values and
+	// once a query has finished its state is final, a late response for an
+	// earlier poll must not move it back to an active state.
+	update := models.Query{
+		State:         params.State,
+		ErrorCode:     params.ErrorCode,
+		ErrorName:     params.ErrorName,
+		ElapsedTimeMs: params.ElapsedTimeMs,
+		RowsReturned:  params.RowsReturned,
+		BytesReturned: params.BytesReturned,
+	}
+	update.ID = params.ID
+	if isTerminalState(params.State) {
+		update.FinishedAt = time.Now().Unix()
+	}
+
+	return c.queryRepo.UpdateState(ctx, &update, terminalStates)
+}
+
 type IFindManyParams interface {
 	GetCount() int32
 	GetSkip() int32
@@ -79,6 +162,7 @@ type IFindManyParams interface {
 	GetUsername() string
 	GetBackendId() string
 	GetGroupId() string
+	GetState() string
 }
 
 type Filters struct {
@@ -86,6 +170,7 @@ type Filters struct {
 	Username  string `json:"username,omitempty"`
 	BackendId string `json:"backend_id,omitempty"`
 	GroupId   string `json:"group_id,omitempty"`
+	State     string `json:"state,omitempty"`
 }
 
 func (c *Core) FindMany(ctx context.Context, params IFindManyParams) ([]models.Query, error) {
@@ -93,6 +178,7 @@ func (c *Core) FindMany(ctx context.Context, params IFindManyParams) ([]models.Q
 		Username:  params.GetUsername(),
 		BackendId: params.GetBackendId(),
 		GroupId:   params.GetGroupId(),
+		State:     params.GetState(),
 	})
 	// use the json tag name, so we can respect omitempty tags
 	conditionStr.TagName = "json"
@@ -130,3 +216,57 @@ func (c *Core) FindMany(ctx context.Context, params IFindManyParams) ([]models.Q
 
 	return *queries, nil
 }
+
+// TransactionBindParams has attributes that are required for binding a transaction to a backend
+type TransactionBindParams struct {
+	ID        string
+	BackendId string
+	GroupId   string
+	Username  string
+}
+
+func (c *Core) BindTransaction(ctx context.Context, params *TransactionBindParams) error {
+	if params.ID == "" || params.BackendId == "" {
+		return errors.New("transaction id and backend id are required")
+	}
+	transaction := models.Transaction{
+		BackendId: params.BackendId,
+		GroupId:   params.GroupId,
+		Username:  params.Username,
+	}
+	transaction.ID = params.ID
+
+	// responses of further statements of the transaction may repeat the id of the started transaction
+	_, err := c.transactionRepo.Find(ctx, params.ID)
+	switch {
+	case err == nil: // update
+		err = c.transactionRepo.Update(ctx, &transaction)
+	case errors.Is(err, spine.RecordNotFound): // create
+		err = c.transactionRepo.Create(ctx, &transaction)
+	}
+	if err != nil {
+		return err
+	}
+
+	// bindings of transactions clients never committed or rolled back are purged as new ones are bound,
+	// failing to purge them doesn't affect the new binding
+	if c.transactionTtl > 0 {
+		_ = c.transactionRepo.DeleteCreatedBefore(ctx, time.Now().Add(-c.transactionTtl).Unix())
+	}
+	return nil
+}
+
+func (c *Core) GetTransaction(ctx context.Context, id string) (*models.Transaction, error) {
+	transaction, err := c.transactionRepo.Find(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if c.transactionTtl > 0 && time.Since(time.Unix(transaction.CreatedAt, 0)) > c.transactionTtl {
+		return nil, spine.RecordNotFound
+	}
+	return transaction, nil
+}
+
+func (c *Core) UnbindTransaction(ctx context.Context, id string) error {
+	return c.transactionRepo.Delete(ctx, id)
+}
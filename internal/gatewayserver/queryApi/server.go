@@ -33,8 +33,16 @@ func (s *Server) CreateOrUpdateQuery(ctx context.Context, req *gatewayv1.Query)
 		GroupId:     req.GetGroupId(),
 		BackendId:   req.GetBackendId(),
 		Username:    req.GetUsername(),
+		Principal:   req.GetPrincipal(),
 		ServerHost:  req.GetServerHost(),
 		SubmittedAt: req.GetSubmittedAt(),
+
+		State:         queryStateName(req.GetState()),
+		ErrorCode:     req.GetErrorCode(),
+		ErrorName:     req.GetErrorName(),
+		ElapsedTimeMs: req.GetElapsedTimeMs(),
+		RowsReturned:  req.GetRowsReturned(),
+		BytesReturned: req.GetBytesReturned(),
 	}
 
 	err := s.core.CreateOrUpdateQuery(ctx, &createParams)
@@ -45,6 +53,29 @@ func (s *Server) CreateOrUpdateQuery(ctx context.Context, req *gatewayv1.Query)
 	return &gatewayv1.Empty{}, nil
 }
 
+func (s *Server) UpdateQueryState(ctx context.Context, req *gatewayv1.QueryStateUpdateRequest) (*gatewayv1.Empty, error) {
+	provider.Logger(ctx).Debugw("UpdateQueryState", map[string]interface{}{
+		"request": req.String(),
+	})
+
+	updateParams := QueryStateUpdateParams{
+		ID:            req.GetId(),
+		State:         queryStateName(req.GetState()),
+		ErrorCode:     req.GetErrorCode(),
+		ErrorName:     req.GetErrorName(),
+		ElapsedTimeMs: req.GetElapsedTimeMs(),
+		RowsReturned:  req.GetRowsReturned(),
+		BytesReturned: req.GetBytesReturned(),
+	}
+
+	err := s.core.UpdateQueryState(ctx, &updateParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gatewayv1.Empty{}, nil
+}
+
 func (s *Server) GetQuery(ctx context.Context, req *gatewayv1.QueryGetRequest) (*gatewayv1.QueryGetResponse, error) {
 	provider.Logger(ctx).Debugw("GetQuery", map[string]interface{}{
 		"request": req.String(),
@@ -92,10 +123,23 @@ func (s *Server) ListQueries(ctx context.Context, req *gatewayv1.QueriesListRequ
 	return &response, nil
 }
 
+// Queries are stored without a state unless the client reported one
+func queryStateName(state gatewayv1.Query_State) string {
+	if state == gatewayv1.Query_STATE_UNSPECIFIED {
+		return ""
+	}
+	return state.String()
+}
+
 func toQueryResponseProto(query *models.Query) (*gatewayv1.Query, error) {
 	if query == nil {
 		return &gatewayv1.Query{}, nil
 	}
+	// Queries recorded before lifecycle tracking have no state, they are STATE_UNSPECIFIED
+	state, ok := gatewayv1.Query_State_value[query.State]
+	if !ok {
+		state = int32(gatewayv1.Query_STATE_UNSPECIFIED)
+	}
 	return &gatewayv1.Query{
 		Id:          query.ID,
 		Text:        query.Text,
@@ -104,7 +148,16 @@ func toQueryResponseProto(query *models.Query) (*gatewayv1.Query, error) {
 		GroupId:     query.GroupId,
 		BackendId:   query.BackendId,
 		Username:    query.Username,
+		Principal:   query.Principal,
 		SubmittedAt: query.SubmittedAt,
+
+		State:         gatewayv1.Query_State(state),
+		FinishedAt:    query.FinishedAt,
+		ErrorCode:     query.ErrorCode,
+		ErrorName:     query.ErrorName,
+		ElapsedTimeMs: query.ElapsedTimeMs,
+		RowsReturned:  query.RowsReturned,
+		BytesReturned: query.BytesReturned,
 	}, nil
 }
 
@@ -122,3 +175,47 @@ func (s *Server) FindBackendForQuery(ctx context.Context, req *gatewayv1.FindBac
 		GroupId:   query.GroupId,
 	}, nil
 }
+
+func (s *Server) BindTransaction(ctx context.Context, req *gatewayv1.Transaction) (*gatewayv1.Empty, error) {
+	provider.Logger(ctx).Debugw("BindTransaction", map[string]interface{}{
+		"request": req.String(),
+	})
+
+	err := s.core.BindTransaction(ctx, &TransactionBindParams{
+		ID:        req.GetId(),
+		BackendId: req.GetBackendId(),
+		GroupId:   req.GetGroupId(),
+		Username:  req.GetUsername(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &gatewayv1.Empty{}, nil
+}
+
+func (s *Server) FindBackendForTransaction(ctx context.Context, req *gatewayv1.FindBackendForTransactionRequest) (*gatewayv1.FindBackendForTransactionResponse, error) {
+	provider.Logger(ctx).Debugw("FindBackendForTransaction", map[string]interface{}{
+		"request": req.String(),
+	})
+
+	transaction, err := s.core.GetTransaction(ctx, req.GetTransactionId())
+	if err != nil {
+		return nil, err
+	}
+	return &gatewayv1.FindBackendForTransactionResponse{
+		BackendId: transaction.BackendId,
+		GroupId:   transaction.GroupId,
+		Username:  transaction.Username,
+	}, nil
+}
+
+func (s *Server) UnbindTransaction(ctx context.Context, req *gatewayv1.TransactionUnbindRequest) (*gatewayv1.Empty, error) {
+	provider.Logger(ctx).Debugw("UnbindTransaction", map[string]interface{}{
+		"request": req.String(),
+	})
+
+	if err := s.core.UnbindTransaction(ctx, req.GetId()); err != nil {
+		return nil, err
+	}
+	return &gatewayv1.Empty{}, nil
+}
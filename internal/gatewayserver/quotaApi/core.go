@@ -0,0 +1,87 @@
+package quotaapi
+
+import (
+	"context"
+
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/repo"
+	"github.com/razorpay/trino-gateway/internal/routing"
+)
+
+type Core struct {
+	quotaRepo repo.IQuotaRepo
+}
+
+type ICore interface {
+	CreateOrUpdateQuota(ctx context.Context, params *QuotaCreateParams) error
+	GetQuota(ctx context.Context, id string) (*models.Quota, error)
+	GetAllQuotas(ctx context.Context) ([]models.Quota, error)
+	DeleteQuota(ctx context.Context, id string) error
+	EnableQuota(ctx context.Context, id string) error
+	DisableQuota(ctx context.Context, id string) error
+}
+
+// NewCore returns a new instance of *Core
+func NewCore(quota repo.IQuotaRepo) *Core {
+	return &Core{quotaRepo: quota}
+}
+
+// QuotaCreateParams has attributes that are required for quota.Create()
+type QuotaCreateParams struct {
+	ID                   string
+	KeyType              string
+	KeyValue             string
+	QueriesPerMinute     int32
+	MaxConcurrentQueries int32
+	IsEnabled            bool
+}
+
+func (c *Core) CreateOrUpdateQuota(ctx context.Context, params *QuotaCreateParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+
+	quota := models.Quota{
+		KeyType:              params.KeyType,
+		KeyValue:             params.KeyValue,
+		QueriesPerMinute:     &params.QueriesPerMinute,
+		MaxConcurrentQueries: &params.MaxConcurrentQueries,
+		IsEnabled:            &params.IsEnabled,
+	}
+	quota.ID = params.ID
+
+	// quotas are enforced by the router from its routing snapshot
+	defer routing.NotifyChanged()
+
+	_, exists := c.quotaRepo.Find(ctx, params.ID)
+	if exists == nil { // update
+		return c.quotaRepo.Update(ctx, &quota)
+	} else { // create
+		return c.quotaRepo.Create(ctx, &quota)
+	}
+}
+
+func (c *Core) GetQuota(ctx context.Context, id string) (*models.Quota, error) {
+	quota, err := c.quotaRepo.Find(ctx, id)
+	return quota, err
+}
+
+func (c *Core) GetAllQuotas(ctx context.Context) ([]models.Quota, error) {
+	quotas, err := c.quotaRepo.FindMany(ctx, make(map[string]interface{}))
+	return quotas, err
+}
+
+func (c *Core) DeleteQuota(ctx context.Context, id string) error {
+	defer routing.NotifyChanged()
+	return c.quotaRepo.Delete(ctx, id)
+}
+
+func (c *Core) EnableQuota(ctx context.Context, id string) error {
+	defer routing.NotifyChanged()
+	return c.quotaRepo.Enable(ctx, id)
+}
+
+func (c *Core) DisableQuota(ctx context.Context, id string) error {
+	defer routing.NotifyChanged()
+	return c.quotaRepo.Disable(ctx, id)
+}
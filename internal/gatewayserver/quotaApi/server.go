@@ -0,0 +1,147 @@
+package quotaapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
+	"github.com/razorpay/trino-gateway/internal/provider"
+	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
+)
+
+// Server has methods implementing of server rpc.
+type Server struct {
+	core ICore
+}
+
+// NewServer returns a server.
+func NewServer(core ICore) *Server {
+	return &Server{
+		core: core,
+	}
+}
+
+// CreateOrUpdateQuota creates a new quota or updates the existing one
+func (s *Server) CreateOrUpdateQuota(ctx context.Context, req *gatewayv1.Quota) (*gatewayv1.Empty, error) {
+	provider.Logger(ctx).Debugw("CreateOrUpdateQuota", map[string]interface{}{
+		"request": req.String(),
+	})
+
+	createParams := QuotaCreateParams{
+		ID:                   req.GetId(),
+		KeyType:              req.GetKeyType().Enum().String(),
+		KeyValue:             req.GetKeyValue(),
+		QueriesPerMinute:     req.GetQueriesPerMinute(),
+		MaxConcurrentQueries: req.GetMaxConcurrentQueries(),
+		IsEnabled:            req.GetIsEnabled(),
+	}
+
+	err := s.core.CreateOrUpdateQuota(ctx, &createParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gatewayv1.Empty{}, nil
+}
+
+// GetQuota retrieves a single quota record
+func (s *Server) GetQuota(ctx context.Context, req *gatewayv1.QuotaGetRequest) (*gatewayv1.QuotaGetResponse, error) {
+	provider.Logger(ctx).Debugw("GetQuota", map[string]interface{}{
+		"request": req.String(),
+	})
+	quota, err := s.core.GetQuota(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	quotaProto, err := toQuotaResponseProto(quota)
+	if err != nil {
+		return nil, err
+	}
+	return &gatewayv1.QuotaGetResponse{Quota: quotaProto}, nil
+}
+
+// ListAllQuotas fetches all quota records
+func (s *Server) ListAllQuotas(ctx context.Context, req *gatewayv1.Empty) (*gatewayv1.QuotaListAllResponse, error) {
+	provider.Logger(ctx).Debugw("ListAllQuotas", map[string]interface{}{
+		"request": req.String(),
+	})
+	quotas, err := s.core.GetAllQuotas(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	quotasProto := make([]*gatewayv1.Quota, len(quotas))
+	for i := range quotas {
+		quota, err := toQuotaResponseProto(&quotas[i])
+		if err != nil {
+			return nil, err
+		}
+		quotasProto[i] = quota
+	}
+
+	return &gatewayv1.QuotaListAllResponse{Items: quotasProto}, nil
+}
+
+func (s *Server) EnableQuota(ctx context.Context, req *gatewayv1.QuotaEnableRequest) (*gatewayv1.Empty, error) {
+	provider.Logger(ctx).Debugw("EnableQuota", map[string]interface{}{
+		"request": req.String(),
+	})
+	err := s.core.EnableQuota(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &gatewayv1.Empty{}, nil
+}
+
+func (s *Server) DisableQuota(ctx context.Context, req *gatewayv1.QuotaDisableRequest) (*gatewayv1.Empty, error) {
+	provider.Logger(ctx).Debugw("DisableQuota", map[string]interface{}{
+		"request": req.String(),
+	})
+	err := s.core.DisableQuota(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &gatewayv1.Empty{}, nil
+}
+
+// DeleteQuota deletes a quota
+func (s *Server) DeleteQuota(ctx context.Context, req *gatewayv1.QuotaDeleteRequest) (*gatewayv1.Empty, error) {
+	provider.Logger(ctx).Debugw("DeleteQuota", map[string]interface{}{
+		"request": req.String(),
+	})
+	err := s.core.DeleteQuota(ctx, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &gatewayv1.Empty{}, nil
+}
+
+func toQuotaResponseProto(quota *models.Quota) (*gatewayv1.Quota, error) {
+	if quota == nil {
+		return &gatewayv1.Quota{}, nil
+	}
+	keyType, ok := gatewayv1.Quota_KeyType_value[quota.KeyType]
+	if !ok {
+		return nil, errors.New(fmt.Sprint("error encoding response: invalid key_type ", quota.KeyType))
+	}
+	response := gatewayv1.Quota{
+		Id:       quota.ID,
+		KeyType:  *gatewayv1.Quota_KeyType(keyType).Enum(),
+		KeyValue: quota.KeyValue,
+	}
+	if quota.QueriesPerMinute != nil {
+		response.QueriesPerMinute = *quota.QueriesPerMinute
+	}
+	if quota.MaxConcurrentQueries != nil {
+		response.MaxConcurrentQueries = *quota.MaxConcurrentQueries
+	}
+	if quota.IsEnabled != nil {
+		response.IsEnabled = *quota.IsEnabled
+	}
+
+	return &response, nil
+}
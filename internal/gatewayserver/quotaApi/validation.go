@@ -0,0 +1,19 @@
+package quotaapi
+
+import "errors"
+
+func (p *QuotaCreateParams) Validate() error {
+	if p.ID == "" {
+		return errors.New("id of quota is required")
+	}
+	if p.KeyValue == "" {
+		return errors.New("key_value of quota is required, use * for applying it to each value of the key")
+	}
+	if p.QueriesPerMinute < 0 || p.MaxConcurrentQueries < 0 {
+		return errors.New("limits of quota can't be negative")
+	}
+	if p.QueriesPerMinute == 0 && p.MaxConcurrentQueries == 0 {
+		return errors.New("quota requires queries_per_minute or max_concurrent_queries")
+	}
+	return nil
+}
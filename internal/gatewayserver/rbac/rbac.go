@@ -0,0 +1,99 @@
+// Package rbac decides which methods of the gateway apis clients may call as per their role & permissions
+package rbac
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Roles of api keys
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+// Methods of the service managing api keys require the admin role, regardless of permissions of keys
+const apiKeyService = "ApiKeyApi"
+
+// Services never allowed to anonymous requests regardless of their role, as they expose which clients
+// can bypass authentication & call the apis
+var anonymousDeniedServices = map[string]bool{
+	apiKeyService:      true,
+	"AuthExemptionApi": true,
+}
+
+// Methods of each service not changing any state. Methods are listed explicitly as verbs don't tell,
+// e.g. GroupApi/EvaluateBackendForGroups records the backend routed to.
+var viewerMethods = map[string][]string{
+	"BackendApi":       {"GetBackend", "ListAllBackends", "ListBackendHealthEvents"},
+	"GroupApi":         {"GetGroup", "ListAllGroups"},
+	"PolicyApi":        {"GetPolicy", "ListAllPolicies", "EvaluateGroupsForClient", "EvaluateAuthDelegationForClient", "EvaluateRequestSourceForClient", "ListUserGroupMemberships"},
+	"QueryApi":         {"GetQuery", "ListQueries", "FindBackendForQuery", "FindBackendForTransaction"},
+	"QuotaApi":         {"GetQuota", "ListAllQuotas"},
+	"AuthExemptionApi": {"GetAuthExemption", "ListAllAuthExemptions"},
+}
+
+// Methods of each service for taking backends, groups & quotas out of rotation, e.g. by on-call engineers.
+// Policies & auth exemptions decide routing & authentication of clients, so changing them requires admin.
+var operatorMethods = map[string][]string{
+	"BackendApi": {"EnableBackend", "DisableBackend", "MarkHealthyBackend", "MarkUnhealthyBackend"},
+	"GroupApi":   {"EnableGroup", "DisableGroup"},
+	"QuotaApi":   {"EnableQuota", "DisableQuota"},
+}
+
+// Methods allowed per role by service, the admin role is allowed all methods
+var roleMethods = map[string][]map[string][]string{
+	RoleViewer:   {viewerMethods},
+	RoleOperator: {viewerMethods, operatorMethods},
+	RoleAdmin:    nil,
+}
+
+func IsRole(role string) bool {
+	_, ok := roleMethods[role]
+	return ok
+}
+
+// ValidatePermission checks the permission is a Service/Method pattern, e.g. BackendApi/* or */ListAll*
+func ValidatePermission(permission string) error {
+	service, method, ok := strings.Cut(permission, "/")
+	if !ok || service == "" || method == "" || strings.Contains(method, "/") {
+		return fmt.Errorf("permission %s is not of the form Service/Method", permission)
+	}
+	if _, err := path.Match(permission, ""); err != nil {
+		return fmt.Errorf("invalid pattern of permission %s: %w", permission, err)
+	}
+	return nil
+}
+
+// Allowed returns whether the role or the additional permissions allow calling the method of the service
+func Allowed(role string, permissions []string, service string, method string) bool {
+	if service == apiKeyService {
+		return role == RoleAdmin
+	}
+	if role == RoleAdmin {
+		return true
+	}
+	for _, methods := range roleMethods[role] {
+		if slices.Contains(methods[service], method) {
+			return true
+		}
+	}
+	target := service + "/" + method
+	for _, p := range permissions {
+		if ok, _ := path.Match(p, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedAnonymous returns whether requests without an api key having the role may call the method of the service
+func AllowedAnonymous(role string, service string, method string) bool {
+	if role == "" || anonymousDeniedServices[service] {
+		return false
+	}
+	return Allowed(role, nil, service, method)
+}
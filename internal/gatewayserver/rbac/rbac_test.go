@@ -0,0 +1,60 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowed(t *testing.T) {
+	tests := []struct {
+		name        string
+		role        string
+		permissions []string
+		service     string
+		method      string
+		allowed     bool
+	}{
+		{"viewer reads", RoleViewer, nil, "BackendApi", "ListAllBackends", true},
+		{"viewer evaluates", RoleViewer, nil, "PolicyApi", "EvaluateGroupsForClient", true},
+		{"viewer can't disable", RoleViewer, nil, "BackendApi", "DisableBackend", false},
+		{"viewer can't evaluate backends of groups", RoleViewer, nil, "GroupApi", "EvaluateBackendForGroups", false},
+		{"viewer can't bind transactions", RoleViewer, nil, "QueryApi", "BindTransaction", false},
+		{"operator disables", RoleOperator, nil, "BackendApi", "DisableBackend", true},
+		{"operator marks unhealthy", RoleOperator, nil, "BackendApi", "MarkUnhealthyBackend", true},
+		{"operator can't delete", RoleOperator, nil, "PolicyApi", "DeletePolicy", false},
+		{"operator can't disable policies", RoleOperator, nil, "PolicyApi", "DisablePolicy", false},
+		{"operator can't enable auth exemptions", RoleOperator, nil, "AuthExemptionApi", "EnableAuthExemption", false},
+		{"operator enables quotas", RoleOperator, nil, "QuotaApi", "EnableQuota", true},
+		{"operator can't update", RoleOperator, nil, "GroupApi", "CreateOrUpdateGroup", false},
+		{"admin deletes", RoleAdmin, nil, "PolicyApi", "DeletePolicy", true},
+		{"permission of method", RoleViewer, []string{"PolicyApi/CreateOrUpdatePolicy"}, "PolicyApi", "CreateOrUpdatePolicy", true},
+		{"permission of service", RoleViewer, []string{"QuotaApi/*"}, "QuotaApi", "DeleteQuota", true},
+		{"permission of other service", RoleViewer, []string{"QuotaApi/*"}, "PolicyApi", "DeletePolicy", false},
+		{"unknown role", "superuser", nil, "BackendApi", "GetBackend", false},
+		{"api keys require admin", RoleOperator, []string{"*/*", "ApiKeyApi/*"}, "ApiKeyApi", "ListAllApiKeys", false},
+		{"admin manages api keys", RoleAdmin, nil, "ApiKeyApi", "CreateOrUpdateApiKey", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.allowed, Allowed(tt.role, tt.permissions, tt.service, tt.method))
+		})
+	}
+}
+
+func TestValidatePermission(t *testing.T) {
+	assert.NoError(t, ValidatePermission("BackendApi/EnableBackend"))
+	assert.NoError(t, ValidatePermission("*/ListAll*"))
+	assert.Error(t, ValidatePermission("BackendApi"))
+	assert.Error(t, ValidatePermission("BackendApi/"))
+	assert.Error(t, ValidatePermission("BackendApi/Enable/Backend"))
+	assert.Error(t, ValidatePermission("BackendApi/[Enable"))
+}
+
+func TestAllowedAnonymous(t *testing.T) {
+	assert.True(t, AllowedAnonymous(RoleViewer, "BackendApi", "ListAllBackends"))
+	assert.False(t, AllowedAnonymous(RoleViewer, "BackendApi", "DisableBackend"))
+	assert.False(t, AllowedAnonymous("", "BackendApi", "ListAllBackends"))
+	assert.False(t, AllowedAnonymous(RoleAdmin, "AuthExemptionApi", "ListAllAuthExemptions"))
+	assert.False(t, AllowedAnonymous(RoleAdmin, "ApiKeyApi", "ListAllApiKeys"))
+}
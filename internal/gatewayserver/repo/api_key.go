@@ -0,0 +1,160 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/database/dbRepo"
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
+	"github.com/razorpay/trino-gateway/internal/provider"
+	"github.com/razorpay/trino-gateway/pkg/spine"
+)
+
+type IApiKeyRepo interface {
+	Create(ctx context.Context, apiKey *models.ApiKey) error
+	Update(ctx context.Context, apiKey *models.ApiKey) error
+	Find(ctx context.Context, id string) (*models.ApiKey, error)
+	FindMany(ctx context.Context, conditions map[string]interface{}) ([]models.ApiKey, error)
+	FindByKeyHash(ctx context.Context, keyHash string) (*models.ApiKey, error)
+	Delete(ctx context.Context, id string) error
+	Enable(ctx context.Context, id string) error
+	Disable(ctx context.Context, id string) error
+}
+
+type ApiKeyRepo struct {
+	repo dbRepo.IDbRepo
+}
+
+func NewApiKeyRepo(repo dbRepo.IDbRepo) *ApiKeyRepo {
+	return &ApiKeyRepo{repo: repo}
+}
+
+func (r *ApiKeyRepo) Create(ctx context.Context, apiKey *models.ApiKey) error {
+	err := r.repo.Create(ctx, apiKey)
+	if err != nil {
+		provider.Logger(ctx).WithError(err).Errorw("api key create failed", map[string]interface{}{"id": apiKey.ID})
+		return err
+	}
+
+	provider.Logger(ctx).Infow("api key created", map[string]interface{}{"id": apiKey.ID})
+
+	return nil
+}
+
+func (r *ApiKeyRepo) Update(ctx context.Context, apiKey *models.ApiKey) error {
+	err := r.repo.Update(ctx, apiKey)
+	if err != nil {
+		if err == spine.NoRowAffected {
+			provider.Logger(ctx).Debugw(
+				"no row affected by api key update",
+				map[string]interface{}{"api_key_id": apiKey.ID},
+			)
+			return nil
+		}
+		provider.Logger(ctx).WithError(err).Errorw(
+			"api key update failed",
+			map[string]interface{}{"api_key_id": apiKey.ID})
+		return err
+	}
+
+	provider.Logger(ctx).Infow("api key updated", map[string]interface{}{"id": apiKey.ID})
+
+	return nil
+}
+
+func (r *ApiKeyRepo) Find(ctx context.Context, id string) (*models.ApiKey, error) {
+	apiKey := models.ApiKey{}
+
+	err := r.repo.FindByID(ctx, &apiKey, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiKey, nil
+}
+
+func (r *ApiKeyRepo) FindMany(ctx context.Context, conditions map[string]interface{}) ([]models.ApiKey, error) {
+	var apiKeys []models.ApiKey
+
+	err := r.repo.FindMany(ctx, &apiKeys, conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	return apiKeys, nil
+}
+
+func (r *ApiKeyRepo) FindByKeyHash(ctx context.Context, keyHash string) (*models.ApiKey, error) {
+	apiKeys, err := r.FindMany(ctx, map[string]interface{}{"key_hash": keyHash})
+	if err != nil {
+		return nil, err
+	}
+	if len(apiKeys) == 0 {
+		return nil, spine.RecordNotFound
+	}
+
+	return &apiKeys[0], nil
+}
+
+func (r *ApiKeyRepo) Enable(ctx context.Context, id string) error {
+	provider.Logger(ctx).Infow("api key activation triggered", map[string]interface{}{"api_key_id": id})
+
+	apiKey, err := r.Find(ctx, id)
+	if err != nil {
+		provider.Logger(ctx).Error("api key activation failed: " + err.Error())
+		return err
+	}
+
+	if *apiKey.IsEnabled {
+		provider.Logger(ctx).Error("api key activation failed. Already active")
+		return errors.New("Already active")
+	}
+
+	*apiKey.IsEnabled = true
+
+	if err := r.repo.Update(ctx, apiKey); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *ApiKeyRepo) Disable(ctx context.Context, id string) error {
+	provider.Logger(ctx).Infow("api key deactivation triggered", map[string]interface{}{"api_key_id": id})
+
+	apiKey, err := r.Find(ctx, id)
+	if err != nil {
+		provider.Logger(ctx).Error("api key deactivation failed: " + err.Error())
+		return err
+	}
+
+	if !*apiKey.IsEnabled {
+		provider.Logger(ctx).Error("api key deactivation failed. Already inactive")
+		return errors.New("Already inactive")
+	}
+
+	*apiKey.IsEnabled = false
+
+	if err := r.repo.Update(ctx, apiKey); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *ApiKeyRepo) Delete(ctx context.Context, id string) error {
+	provider.Logger(ctx).Infow("api key delete request", map[string]interface{}{"api_key_id": id})
+
+	apiKey, err := r.Find(ctx, id)
+	if err != nil {
+		provider.Logger(ctx).Error("api key delete failed: " + err.Error())
+		return err
+	}
+
+	err = r.repo.Delete(ctx, apiKey)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
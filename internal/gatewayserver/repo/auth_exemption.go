@@ -0,0 +1,147 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/database/dbRepo"
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
+	"github.com/razorpay/trino-gateway/internal/provider"
+	"github.com/razorpay/trino-gateway/pkg/spine"
+)
+
+type IAuthExemptionRepo interface {
+	Create(ctx context.Context, exemption *models.AuthExemption) error
+	Update(ctx context.Context, exemption *models.AuthExemption) error
+	Find(ctx context.Context, id string) (*models.AuthExemption, error)
+	FindMany(ctx context.Context, conditions map[string]interface{}) ([]models.AuthExemption, error)
+	Delete(ctx context.Context, id string) error
+	Enable(ctx context.Context, id string) error
+	Disable(ctx context.Context, id string) error
+}
+
+type AuthExemptionRepo struct {
+	repo dbRepo.IDbRepo
+}
+
+func NewAuthExemptionRepo(repo dbRepo.IDbRepo) *AuthExemptionRepo {
+	return &AuthExemptionRepo{repo: repo}
+}
+
+func (r *AuthExemptionRepo) Create(ctx context.Context, exemption *models.AuthExemption) error {
+	err := r.repo.Create(ctx, exemption)
+	if err != nil {
+		provider.Logger(ctx).WithError(err).Errorw("auth exemption create failed", map[string]interface{}{"id": exemption.ID})
+		return err
+	}
+
+	provider.Logger(ctx).Infow("auth exemption created", map[string]interface{}{"id": exemption.ID})
+
+	return nil
+}
+
+func (r *AuthExemptionRepo) Update(ctx context.Context, exemption *models.AuthExemption) error {
+	err := r.repo.Update(ctx, exemption)
+	if err != nil {
+		if err == spine.NoRowAffected {
+			provider.Logger(ctx).Debugw(
+				"no row affected by auth exemption update",
+				map[string]interface{}{"auth_exemption_id": exemption.ID},
+			)
+			return nil
+		}
+		provider.Logger(ctx).WithError(err).Errorw(
+			"auth exemption update failed",
+			map[string]interface{}{"auth_exemption_id": exemption.ID})
+		return err
+	}
+
+	provider.Logger(ctx).Infow("auth exemption updated", map[string]interface{}{"id": exemption.ID})
+
+	return nil
+}
+
+func (r *AuthExemptionRepo) Find(ctx context.Context, id string) (*models.AuthExemption, error) {
+	exemption := models.AuthExemption{}
+
+	err := r.repo.FindByID(ctx, &exemption, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &exemption, nil
+}
+
+func (r *AuthExemptionRepo) FindMany(ctx context.Context, conditions map[string]interface{}) ([]models.AuthExemption, error) {
+	var exemptions []models.AuthExemption
+
+	err := r.repo.FindMany(ctx, &exemptions, conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	return exemptions, nil
+}
+
+func (r *AuthExemptionRepo) Enable(ctx context.Context, id string) error {
+	provider.Logger(ctx).Infow("auth exemption activation triggered", map[string]interface{}{"auth_exemption_id": id})
+
+	exemption, err := r.Find(ctx, id)
+	if err != nil {
+		provider.Logger(ctx).Error("auth exemption activation failed: " + err.Error())
+		return err
+	}
+
+	if *exemption.IsEnabled {
+		provider.Logger(ctx).Error("auth exemption activation failed. Already active")
+		return errors.New("Already active")
+	}
+
+	*exemption.IsEnabled = true
+
+	if err := r.repo.Update(ctx, exemption); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *AuthExemptionRepo) Disable(ctx context.Context, id string) error {
+	provider.Logger(ctx).Infow("auth exemption deactivation triggered", map[string]interface{}{"auth_exemption_id": id})
+
+	exemption, err := r.Find(ctx, id)
+	if err != nil {
+		provider.Logger(ctx).Error("auth exemption deactivation failed: " + err.Error())
+		return err
+	}
+
+	if !*exemption.IsEnabled {
+		provider.Logger(ctx).Error("auth exemption deactivation failed. Already inactive")
+		return errors.New("Already inactive")
+	}
+
+	*exemption.IsEnabled = false
+
+	if err := r.repo.Update(ctx, exemption); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *AuthExemptionRepo) Delete(ctx context.Context, id string) error {
+	provider.Logger(ctx).Infow("auth exemption delete request", map[string]interface{}{"auth_exemption_id": id})
+
+	exemption, err := r.Find(ctx, id)
+	if err != nil {
+		provider.Logger(ctx).Error("auth exemption delete failed: " + err.Error())
+		return err
+	}
+
+	err = r.repo.Delete(ctx, exemption)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/database/dbRepo"
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
+	"github.com/razorpay/trino-gateway/internal/provider"
+)
+
+type IBackendHealthEventRepo interface {
+	Create(ctx context.Context, event *models.BackendHealthEvent) error
+}
+
+type BackendHealthEventRepo struct {
+	repo dbRepo.IDbRepo
+}
+
+// NewBackendHealthEventRepo returns a new instance of *BackendHealthEventRepo
+func NewBackendHealthEventRepo(repo dbRepo.IDbRepo) *BackendHealthEventRepo {
+	return &BackendHealthEventRepo{repo: repo}
+}
+
+func (r *BackendHealthEventRepo) Create(ctx context.Context, event *models.BackendHealthEvent) error {
+	err := r.repo.Create(ctx, event)
+	if err != nil {
+		provider.Logger(ctx).WithError(err).Errorw(
+			"backend health event create failed",
+			map[string]interface{}{"backend_id": event.BackendId})
+		return err
+	}
+
+	provider.Logger(ctx).Infow(
+		"backend health event created",
+		map[string]interface{}{"backend_id": event.BackendId, "is_healthy": event.IsHealthy, "reason": event.Reason})
+
+	return nil
+}
@@ -7,11 +7,13 @@ import (
 	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
 	"github.com/razorpay/trino-gateway/internal/provider"
 	"github.com/razorpay/trino-gateway/pkg/spine"
+	"gorm.io/gorm"
 )
 
 type IQueryRepo interface {
 	Create(ctx context.Context, query *models.Query) error
 	Update(ctx context.Context, query *models.Query) error
+	UpdateState(ctx context.Context, update *models.Query, terminalStates []string) error
 	Find(ctx context.Context, id string) (*models.Query, error)
 	FindMany(ctx context.Context, conditions map[string]interface{}) ([]models.Query, error)
 	// Find(ctx context.Context, id string) (*Query, error)
@@ -60,6 +62,51 @@ func (r *QueryRepo) Update(ctx context.Context, query *models.Query) error {
 	return nil
 }
 
+// UpdateState applies a lifecycle update of a query in the database itself, so concurrent updates
+// for polls of the same query don't lose each other's row & byte counts. RowsReturned & BytesReturned
+// of the update are increments, ElapsedTimeMs only ever grows, and State & FinishedAt are only
+// applied when set and while the stored state isn't one of terminalStates.
+func (r *QueryRepo) UpdateState(ctx context.Context, update *models.Query, terminalStates []string) error {
+	query := models.Query{}
+	query.ID = update.ID
+
+	columns := map[string]interface{}{
+		"rows_returned":   gorm.Expr("rows_returned + ?", update.RowsReturned),
+		"bytes_returned":  gorm.Expr("bytes_returned + ?", update.BytesReturned),
+		"elapsed_time_ms": gorm.Expr("GREATEST(elapsed_time_ms, ?)", update.ElapsedTimeMs),
+	}
+	if update.ErrorName != "" {
+		columns["error_code"] = update.ErrorCode
+		columns["error_name"] = update.ErrorName
+	}
+	err := r.repo.UpdateColumns(ctx, &query, columns)
+	if err != nil && err != spine.NoRowAffected {
+		provider.Logger(ctx).WithError(err).Errorw(
+			"query state update failed",
+			map[string]interface{}{"query_id": update.ID})
+		return err
+	}
+
+	if update.State != "" {
+		err = r.repo.UpdateColumns(
+			ctx,
+			&query,
+			map[string]interface{}{"state": update.State, "finished_at": update.FinishedAt},
+			"(state IS NULL OR state NOT IN ?)", terminalStates,
+		)
+		if err != nil && err != spine.NoRowAffected {
+			provider.Logger(ctx).WithError(err).Errorw(
+				"query state update failed",
+				map[string]interface{}{"query_id": update.ID})
+			return err
+		}
+	}
+
+	provider.Logger(ctx).Infow("query state updated", map[string]interface{}{"query_id": update.ID})
+
+	return nil
+}
+
 func (r *QueryRepo) Find(ctx context.Context, id string) (*models.Query, error) {
 	query := models.Query{}
 
@@ -0,0 +1,147 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/database/dbRepo"
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
+	"github.com/razorpay/trino-gateway/internal/provider"
+	"github.com/razorpay/trino-gateway/pkg/spine"
+)
+
+type IQuotaRepo interface {
+	Create(ctx context.Context, quota *models.Quota) error
+	Update(ctx context.Context, quota *models.Quota) error
+	Find(ctx context.Context, id string) (*models.Quota, error)
+	FindMany(ctx context.Context, conditions map[string]interface{}) ([]models.Quota, error)
+	Delete(ctx context.Context, id string) error
+	Enable(ctx context.Context, id string) error
+	Disable(ctx context.Context, id string) error
+}
+
+type QuotaRepo struct {
+	repo dbRepo.IDbRepo
+}
+
+func NewQuotaRepo(repo dbRepo.IDbRepo) *QuotaRepo {
+	return &QuotaRepo{repo: repo}
+}
+
+func (r *QuotaRepo) Create(ctx context.Context, quota *models.Quota) error {
+	err := r.repo.Create(ctx, quota)
+	if err != nil {
+		provider.Logger(ctx).WithError(err).Errorw("quota create failed", map[string]interface{}{"id": quota.ID})
+		return err
+	}
+
+	provider.Logger(ctx).Infow("quota created", map[string]interface{}{"id": quota.ID})
+
+	return nil
+}
+
+func (r *QuotaRepo) Update(ctx context.Context, quota *models.Quota) error {
+	err := r.repo.Update(ctx, quota)
+	if err != nil {
+		if err == spine.NoRowAffected {
+			provider.Logger(ctx).Debugw(
+				"no row affected by quota update",
+				map[string]interface{}{"quota_id": quota.ID},
+			)
+			return nil
+		}
+		provider.Logger(ctx).WithError(err).Errorw(
+			"quota update failed",
+			map[string]interface{}{"quota_id": quota.ID})
+		return err
+	}
+
+	provider.Logger(ctx).Infow("quota updated", map[string]interface{}{"id": quota.ID})
+
+	return nil
+}
+
+func (r *QuotaRepo) Find(ctx context.Context, id string) (*models.Quota, error) {
+	quota := models.Quota{}
+
+	err := r.repo.FindByID(ctx, &quota, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &quota, nil
+}
+
+func (r *QuotaRepo) FindMany(ctx context.Context, conditions map[string]interface{}) ([]models.Quota, error) {
+	var quotas []models.Quota
+
+	err := r.repo.FindMany(ctx, &quotas, conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	return quotas, nil
+}
+
+func (r *QuotaRepo) Enable(ctx context.Context, id string) error {
+	provider.Logger(ctx).Infow("quota activation triggered", map[string]interface{}{"quota_id": id})
+
+	quota, err := r.Find(ctx, id)
+	if err != nil {
+		provider.Logger(ctx).Error("quota activation failed: " + err.Error())
+		return err
+	}
+
+	if *quota.IsEnabled {
+		provider.Logger(ctx).Error("quota activation failed. Already active")
+		return errors.New("Already active")
+	}
+
+	*quota.IsEnabled = true
+
+	if err := r.repo.Update(ctx, quota); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *QuotaRepo) Disable(ctx context.Context, id string) error {
+	provider.Logger(ctx).Infow("quota deactivation triggered", map[string]interface{}{"quota_id": id})
+
+	quota, err := r.Find(ctx, id)
+	if err != nil {
+		provider.Logger(ctx).Error("quota deactivation failed: " + err.Error())
+		return err
+	}
+
+	if !*quota.IsEnabled {
+		provider.Logger(ctx).Error("quota deactivation failed. Already inactive")
+		return errors.New("Already inactive")
+	}
+
+	*quota.IsEnabled = false
+
+	if err := r.repo.Update(ctx, quota); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *QuotaRepo) Delete(ctx context.Context, id string) error {
+	provider.Logger(ctx).Infow("quota delete request", map[string]interface{}{"quota_id": id})
+
+	quota, err := r.Find(ctx, id)
+	if err != nil {
+		provider.Logger(ctx).Error("quota delete failed: " + err.Error())
+		return err
+	}
+
+	err = r.repo.Delete(ctx, quota)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,109 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/database/dbRepo"
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
+	"github.com/razorpay/trino-gateway/internal/provider"
+	"github.com/razorpay/trino-gateway/pkg/spine"
+)
+
+type ITransactionRepo interface {
+	Create(ctx context.Context, transaction *models.Transaction) error
+	Find(ctx context.Context, id string) (*models.Transaction, error)
+	Update(ctx context.Context, transaction *models.Transaction) error
+	Delete(ctx context.Context, id string) error
+	DeleteCreatedBefore(ctx context.Context, createdAt int64) error
+}
+
+type TransactionRepo struct {
+	repo dbRepo.IDbRepo
+}
+
+// NewTransactionRepo returns a new instance of *TransactionRepo
+func NewTransactionRepo(repo dbRepo.IDbRepo) *TransactionRepo {
+	return &TransactionRepo{repo: repo}
+}
+
+func (r *TransactionRepo) Create(ctx context.Context, transaction *models.Transaction) error {
+	err := r.repo.Create(ctx, transaction)
+	if err != nil {
+		provider.Logger(ctx).WithError(err).Errorw(
+			"transaction create failed",
+			map[string]interface{}{"transaction_id": transaction.ID})
+		return err
+	}
+
+	provider.Logger(ctx).Infow(
+		"transaction bound to backend",
+		map[string]interface{}{"transaction_id": transaction.ID, "backend_id": transaction.BackendId})
+
+	return nil
+}
+
+func (r *TransactionRepo) Find(ctx context.Context, id string) (*models.Transaction, error) {
+	transaction := models.Transaction{}
+
+	err := r.repo.FindByID(ctx, &transaction, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &transaction, nil
+}
+
+func (r *TransactionRepo) Update(ctx context.Context, transaction *models.Transaction) error {
+	err := r.repo.Update(ctx, transaction)
+	if err != nil {
+		if err == spine.NoRowAffected {
+			provider.Logger(ctx).Debugw(
+				"no row affected by transaction update",
+				map[string]interface{}{"transaction_id": transaction.ID},
+			)
+			return nil
+		}
+		provider.Logger(ctx).WithError(err).Errorw(
+			"transaction update failed",
+			map[string]interface{}{"transaction_id": transaction.ID})
+		return err
+	}
+
+	provider.Logger(ctx).Infow(
+		"transaction rebound to backend",
+		map[string]interface{}{"transaction_id": transaction.ID, "backend_id": transaction.BackendId})
+
+	return nil
+}
+
+func (r *TransactionRepo) Delete(ctx context.Context, id string) error {
+	transaction, err := r.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = r.repo.Delete(ctx, transaction)
+	if err != nil {
+		provider.Logger(ctx).WithError(err).Errorw(
+			"transaction delete failed",
+			map[string]interface{}{"transaction_id": id})
+		return err
+	}
+
+	provider.Logger(ctx).Infow("transaction unbound", map[string]interface{}{"transaction_id": id})
+
+	return nil
+}
+
+// DeleteCreatedBefore removes bindings of transactions started before the given unix time
+func (r *TransactionRepo) DeleteCreatedBefore(ctx context.Context, createdAt int64) error {
+	err := r.repo.DeleteWhere(ctx, &models.Transaction{}, spine.AttributeCreatedAt+" < ?", createdAt)
+	if err != nil {
+		provider.Logger(ctx).WithError(err).Errorw(
+			"expired transactions delete failed",
+			map[string]interface{}{"created_before": createdAt})
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/database/dbRepo"
+	"github.com/razorpay/trino-gateway/internal/gatewayserver/models"
+)
+
+type IUserGroupMembershipRepo interface {
+	FindMany(ctx context.Context, conditions map[string]interface{}) ([]models.UserGroupMembership, error)
+}
+
+type UserGroupMembershipRepo struct {
+	repo dbRepo.IDbRepo
+}
+
+// NewUserGroupMembershipRepo returns a new instance of *UserGroupMembershipRepo
+func NewUserGroupMembershipRepo(repo dbRepo.IDbRepo) *UserGroupMembershipRepo {
+	return &UserGroupMembershipRepo{repo: repo}
+}
+
+func (r *UserGroupMembershipRepo) FindMany(ctx context.Context, conditions map[string]interface{}) ([]models.UserGroupMembership, error) {
+	var memberships []models.UserGroupMembership
+
+	err := r.repo.FindMany(ctx, &memberships, conditions)
+	if err != nil {
+		return nil, err
+	}
+
+	return memberships, nil
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/razorpay/trino-gateway/internal/boot"
 	"github.com/razorpay/trino-gateway/internal/provider"
+	"github.com/razorpay/trino-gateway/internal/tlsconfig"
 	"github.com/razorpay/trino-gateway/internal/utils"
 	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
 )
@@ -18,62 +19,50 @@ type Core struct {
 }
 
 type ICore interface {
-	EvaluateBackendNewState(ctx *context.Context) (*BackendsNewState, error)
-	MarkHealthyBackend(ctx *context.Context, b *gatewayv1.Backend) error
-	MarkUnhealthyBackend(ctx *context.Context, b *gatewayv1.Backend) error
+	GetAllBackends(ctx *context.Context) ([]*gatewayv1.Backend, error)
+	ProbeBackend(ctx *context.Context, b *gatewayv1.Backend) *ProbeResult
+	MarkHealthyBackend(ctx *context.Context, b *gatewayv1.Backend, reason string) error
+	MarkUnhealthyBackend(ctx *context.Context, b *gatewayv1.Backend, reason string) error
 }
 
 func NewCore(b gatewayv1.BackendApi) *Core {
 	return &Core{gatewayBackendClient: b}
 }
 
-type BackendsNewState struct {
-	Healthy   []*gatewayv1.Backend
-	Unhealthy []*gatewayv1.Backend
+// ProbeResult is the outcome of a single health check of a backend
+type ProbeResult struct {
+	Healthy bool
+	// why the backend is unhealthy
+	Reason string
+	// state is to be applied without waiting for consecutive probes, e.g. outside uptime schedule
+	Immediate bool
 }
 
-func (c *Core) EvaluateBackendNewState(ctx *context.Context) (*BackendsNewState, error) {
-	provider.Logger(*ctx).Debug("Evaluating new state for backends based on uptime schedules")
-	provider.Logger(*ctx).Debug("Fetching all backends")
-	backends, err := c.getAllBackends(ctx)
+// ProbeBackend checks backend's uptime schedule, availability and cluster load
+func (c *Core) ProbeBackend(ctx *context.Context, b *gatewayv1.Backend) *ProbeResult {
+	isEligible, err := c.isCurrentTimeInCron(ctx, b.UptimeSchedule)
 	if err != nil {
-		return nil, err
+		provider.Logger(*ctx).WithError(err).Errorw(
+			"Unable to parse cron expression in uptime schedule, marking as unhealthy",
+			map[string]interface{}{"backend": b},
+		)
+		return &ProbeResult{Reason: fmt.Sprint("invalid uptime schedule: ", err), Immediate: true}
 	}
-	var markHealthy []*gatewayv1.Backend
-	var markUnhealthy []*gatewayv1.Backend
-	for _, b := range backends {
-		if b == nil {
-			return nil, errors.New("nil pointer reference in twirp response")
-		}
-		isEligible, err := c.isCurrentTimeInCron(ctx, b.UptimeSchedule)
-		if err != nil {
-			provider.Logger(*ctx).WithError(err).Errorw(
-				"Unable to parse cron expression in uptime schedule, marking as unhealthy",
-				map[string]interface{}{"backend": b},
-			)
-		} else if isEligible {
-			provider.Logger(*ctx).Debugw(
-				"Evaluating health of backend based on availability and cluster load",
-				map[string]interface{}{"backend": b})
-
-			isHealthy, err := c.isBackendHealthy(ctx, b)
-			if err != nil {
-				provider.Logger(*ctx).WithError(err).Errorw(
-					"Failure checking backend health",
-					map[string]interface{}{"backend": b})
-			} else if isHealthy {
-				markHealthy = append(markHealthy, b)
-				continue
-			}
-		}
-
-		markUnhealthy = append(markUnhealthy, b)
+	if !isEligible {
+		return &ProbeResult{Reason: "outside uptime schedule", Immediate: true}
 	}
 
-	return &BackendsNewState{
-		Healthy:   markHealthy,
-		Unhealthy: markUnhealthy,
-	}, nil
+	provider.Logger(*ctx).Debugw(
+		"Evaluating health of backend based on availability and cluster load",
+		map[string]interface{}{"backend": b})
+	isHealthy, reason, err := c.isBackendHealthy(ctx, b)
+	if err != nil {
+		provider.Logger(*ctx).WithError(err).Errorw(
+			"Failure checking backend health",
+			map[string]interface{}{"backend": b})
+		return &ProbeResult{Reason: fmt.Sprint(reason, ": ", err)}
+	}
+	return &ProbeResult{Healthy: isHealthy, Reason: reason}
 }
 
 // checks whether current time is int the specified cron schedule pattern
@@ -82,7 +71,7 @@ func (c *Core) isCurrentTimeInCron(ctx *context.Context, sched string) (bool, er
 	return utils.IsTimeInCron(ctx, time.Now(), sched)
 }
 
-func (c *Core) getAllBackends(ctx *context.Context) ([]*gatewayv1.Backend, error) {
+func (c *Core) GetAllBackends(ctx *context.Context) ([]*gatewayv1.Backend, error) {
 	provider.Logger(*ctx).Debug("fetching all backends")
 	resp, err := c.gatewayBackendClient.ListAllBackends(*ctx, &gatewayv1.Empty{})
 	if err != nil {
@@ -99,74 +88,88 @@ func (c *Core) getAllBackends(ctx *context.Context) ([]*gatewayv1.Backend, error
 	return resp.GetItems(), nil
 }
 
-func (c *Core) MarkHealthyBackend(ctx *context.Context, b *gatewayv1.Backend) error {
+func (c *Core) MarkHealthyBackend(ctx *context.Context, b *gatewayv1.Backend, reason string) error {
 	_, err := c.gatewayBackendClient.
 		MarkHealthyBackend(*ctx, &gatewayv1.BackendMarkHealthyRequest{
-			Id: b.GetId(),
+			Id:     b.GetId(),
+			Reason: reason,
 		})
 	return err
 }
 
-func (c *Core) MarkUnhealthyBackend(ctx *context.Context, b *gatewayv1.Backend) error {
+func (c *Core) MarkUnhealthyBackend(ctx *context.Context, b *gatewayv1.Backend, reason string) error {
 	_, err := c.gatewayBackendClient.
 		MarkUnhealthyBackend(*ctx, &gatewayv1.BackendMarkUnhealthyRequest{
-			Id: b.GetId(),
+			Id:     b.GetId(),
+			Reason: reason,
 		})
 	return err
 }
 
-func (c *Core) isBackendHealthy(ctx *context.Context, b *gatewayv1.Backend) (bool, error) {
+// isBackendHealthy returns whether backend is healthy along with the reason if it isn't
+func (c *Core) isBackendHealthy(ctx *context.Context, b *gatewayv1.Backend) (bool, string, error) {
 	isUp, err := c.isBackendUp(ctx, b)
 	if err != nil {
 		provider.Logger(*ctx).WithError(err).Errorw(
 			"Failure fetching cluster ready state, assuming unhealthy",
 			map[string]interface{}{"backend": b})
-		return false, err
-	} else if isUp {
-		provider.Logger(*ctx).Debugw(
-			"Cluster is up",
+		return false, "failure fetching cluster ready state", err
+	}
+	if !isUp {
+		provider.Logger(*ctx).Infow(
+			"Cluster not ready",
 			map[string]interface{}{"backend": b})
-		load, err := c.getBackendLoad(ctx, b)
-		if err != nil {
-			provider.Logger(*ctx).WithError(err).Errorw(
-				"Failure evaluating current backend load, assuming unhealthy",
-				map[string]interface{}{"backend": b})
-			return false, err
-		}
+		return false, "cluster not ready", nil
+	}
 
-		if err = c.updateBackendClusterLoad(ctx, b.GetId(), load); err != nil {
-			provider.Logger(*ctx).WithError(err).Errorw(
-				"Error updating cluster load stats for backend",
-				map[string]interface{}{"backend_id": b.GetId(), "load": load})
-		}
+	provider.Logger(*ctx).Debugw(
+		"Cluster is up",
+		map[string]interface{}{"backend": b})
+	stats, err := c.getBackendLoad(ctx, b)
+	if err != nil {
+		provider.Logger(*ctx).WithError(err).Errorw(
+			"Failure evaluating current backend load, assuming unhealthy",
+			map[string]interface{}{"backend": b})
+		return false, "failure evaluating cluster load", err
+	}
+	load := c.computeClusterLoad(ctx, stats)
 
-		threshold := b.ThresholdClusterLoad
-
-		if threshold == 0 || load <= threshold {
-			provider.Logger(*ctx).Debugw(
-				"Cluster load below threshold, assuming healthy",
-				map[string]interface{}{"backend": b})
-			return true, nil
-		} else {
-			provider.Logger(*ctx).Infow(
-				"Cluster load above threshold",
-				map[string]interface{}{"backend": b, "load": load, "threshold": threshold})
-		}
+	if err = c.updateBackendClusterLoad(ctx, b.GetId(), load, stats); err != nil {
+		provider.Logger(*ctx).WithError(err).Errorw(
+			"Error updating cluster load stats for backend",
+			map[string]interface{}{"backend_id": b.GetId(), "load": load})
 	}
-	provider.Logger(*ctx).Infow(
-		"Cluster unhealthy, marked as inactive",
-		map[string]interface{}{"backend": b})
 
-	return false, nil
+	threshold := b.ThresholdClusterLoad
+
+	if threshold != 0 && load > threshold {
+		provider.Logger(*ctx).Infow(
+			"Cluster load above threshold",
+			map[string]interface{}{"backend": b, "load": load, "threshold": threshold})
+		return false, fmt.Sprintf("cluster load %d above threshold %d", load, threshold), nil
+	}
+
+	provider.Logger(*ctx).Debugw(
+		"Cluster load below threshold, assuming healthy",
+		map[string]interface{}{"backend": b})
+	return true, "", nil
 }
 
 func (c *Core) isBackendUp(ctx *context.Context, b *gatewayv1.Backend) (bool, error) {
 	provider.Logger(*ctx).Debugw(
 		"Checking if backend is healthy",
 		map[string]interface{}{"backend": b})
+	tlsConfig, err := tlsconfig.ClientConfig(tlsconfig.BackendClientOptions(b.GetTls()))
+	if err != nil {
+		provider.Logger(*ctx).WithError(err).Errorw(
+			"invalid tls settings of backend",
+			map[string]interface{}{"backend_id": b.GetId()})
+		return false, err
+	}
 	trinoClient := &TrinoClient{
-		user: boot.Config.Monitor.Trino.User,
-		url:  url.URL{Scheme: b.GetScheme().Enum().String(), Host: b.GetHostname()},
+		user:      boot.Config.Monitor.Trino.User,
+		url:       url.URL{Scheme: b.GetScheme().Enum().String(), Host: b.GetHostname()},
+		tlsConfig: tlsConfig,
 	}
 	defer trinoClient.Teardown(ctx)
 	isUp, err := trinoClient.IsClusterUp(ctx)
@@ -189,15 +192,25 @@ func (c *Core) isBackendUp(ctx *context.Context, b *gatewayv1.Backend) (bool, er
 	return h, nil
 }
 
-func (c *Core) updateBackendClusterLoad(ctx *context.Context, b_id string, load int32) error {
+func (c *Core) updateBackendClusterLoad(ctx *context.Context, b_id string, load int32, stats *clusterLoadStats) error {
 	defer func() {
 		metrics.backendLoad.WithLabelValues(b_id).
 			Set(float64(load))
+		metrics.backendQueuedQueries.WithLabelValues(b_id).
+			Set(float64(stats.queuedQueries()))
+		metrics.backendAvgQueueTime.WithLabelValues(b_id).
+			Set(float64(stats.AvgQueueTimeMs) / 1000)
+		metrics.backendActiveNodes.WithLabelValues(b_id).
+			Set(float64(stats.ActiveNodes))
 	}()
 	_, err := c.gatewayBackendClient.
 		UpdateClusterLoadBackend(*ctx, &gatewayv1.BackendUpdateClusterLoadRequest{
-			Id:          b_id,
-			ClusterLoad: load,
+			Id:             b_id,
+			ClusterLoad:    load,
+			RunningQueries: stats.runningQueries(),
+			QueuedQueries:  stats.queuedQueries(),
+			AvgQueueTimeMs: stats.AvgQueueTimeMs,
+			ActiveNodes:    stats.ActiveNodes,
 		})
 	return err
 }
@@ -215,11 +228,24 @@ type clusterLoadStats struct {
 	ActiveNodes         int32
 }
 
-func (c *Core) getBackendLoad(ctx *context.Context, b *gatewayv1.Backend) (int32, error) {
+func (s *clusterLoadStats) runningQueries() int32 {
+	return s.Running + s.Planning + s.Finishing + s.Dispatching
+}
+
+func (s *clusterLoadStats) queuedQueries() int32 {
+	return s.Queued + s.WaitingForResources + s.Starting
+}
+
+func (c *Core) getBackendLoad(ctx *context.Context, b *gatewayv1.Backend) (*clusterLoadStats, error) {
+	tlsConfig, err := tlsconfig.ClientConfig(tlsconfig.BackendClientOptions(b.GetTls()))
+	if err != nil {
+		return nil, err
+	}
 	trinoClient := &TrinoClient{
-		user: boot.Config.Monitor.Trino.User,
-		url:  url.URL{Scheme: b.GetScheme().Enum().String(), Host: b.GetHostname()},
-		pass: boot.Config.Monitor.Trino.Password,
+		user:      boot.Config.Monitor.Trino.User,
+		url:       url.URL{Scheme: b.GetScheme().Enum().String(), Host: b.GetHostname()},
+		pass:      boot.Config.Monitor.Trino.Password,
+		tlsConfig: tlsConfig,
 	}
 	defer trinoClient.Teardown(ctx)
 
@@ -244,7 +270,7 @@ func (c *Core) getBackendLoad(ctx *context.Context, b *gatewayv1.Backend) (int32
 		provider.Logger(*ctx).WithError(err).Errorw(
 			"error executing trino query",
 			map[string]interface{}{"query": q, "backend_id": b.GetId()})
-		return 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -255,7 +281,7 @@ func (c *Core) getBackendLoad(ctx *context.Context, b *gatewayv1.Backend) (int32
 			provider.Logger(*ctx).WithError(err).Errorw(
 				"error parsing trino query results",
 				map[string]interface{}{"query": q, "backend_id": b.GetId()})
-			return 0, err
+			return nil, err
 		}
 		stateStats = append(stateStats, res)
 	}
@@ -263,7 +289,7 @@ func (c *Core) getBackendLoad(ctx *context.Context, b *gatewayv1.Backend) (int32
 		provider.Logger(*ctx).WithError(err).Errorw(
 			"error parsing trino query results",
 			map[string]interface{}{"query": q, "backend_id": b.GetId()})
-		return 0, err
+		return nil, err
 	}
 
 	res := &clusterLoadStats{}
@@ -293,19 +319,71 @@ func (c *Core) getBackendLoad(ctx *context.Context, b *gatewayv1.Backend) (int32
 		}
 	}
 
-	res.AvgQueueTimeMs = 0 // TODO - via system.runtime.queries
-	res.AvgCpuLoad = 0     // TODO - ideally via Prom/VictoriaDb Trino connector
-	res.ActiveNodes = 0    // TODO - via system.runtime.nodes
+	// avg time spent in queue by queries submitted recently, including the ones still queued
+	q = fmt.Sprint(
+		"SELECT coalesce(avg(queued_time_ms), 0)",
+		" FROM system.runtime.queries",
+		fmt.Sprintf(
+			" WHERE user != '%s' AND created > current_timestamp - INTERVAL '%d' SECOND",
+			boot.Config.Monitor.Trino.User, queueTimeWindowSecs),
+	)
+	var avgQueueTimeMs float64
+	if err := c.runScalarQuery(ctx, trinoClient, b, q, &avgQueueTimeMs); err != nil {
+		return nil, err
+	}
+	res.AvgQueueTimeMs = int64(avgQueueTimeMs)
+
+	q = "SELECT count(*) FROM system.runtime.nodes WHERE state = 'active'"
+	if err := c.runScalarQuery(ctx, trinoClient, b, q, &res.ActiveNodes); err != nil {
+		return nil, err
+	}
+
+	res.AvgCpuLoad = 0 // TODO - ideally via Prom/VictoriaDb Trino connector
+
+	return res, nil
+}
+
+// Window of query submission time over which average queue time is evaluated
+const queueTimeWindowSecs = 300
 
-	load := c.computeClusterLoad(ctx, res)
-	return load, nil
+// runScalarQuery runs a query returning a single row with single column and scans it into dest
+func (c *Core) runScalarQuery(ctx *context.Context, trinoClient *TrinoClient, b *gatewayv1.Backend, q string, dest interface{}) error {
+	provider.Logger(*ctx).Debugw(
+		"Fetching Load info from trino cluster",
+		map[string]interface{}{"backend_id": b.GetId(), "query": q})
+	rows, err := trinoClient.RunQuery(ctx, q)
+	if err != nil {
+		provider.Logger(*ctx).WithError(err).Errorw(
+			"error executing trino query",
+			map[string]interface{}{"query": q, "backend_id": b.GetId()})
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err = rows.Err(); err == nil {
+			err = errors.New("no rows in trino query results")
+		}
+		provider.Logger(*ctx).WithError(err).Errorw(
+			"error parsing trino query results",
+			map[string]interface{}{"query": q, "backend_id": b.GetId()})
+		return err
+	}
+	if err := rows.Scan(dest); err != nil {
+		provider.Logger(*ctx).WithError(err).Errorw(
+			"error parsing trino query results",
+			map[string]interface{}{"query": q, "backend_id": b.GetId()})
+		return err
+	}
+	return nil
 }
 
 func (c *Core) computeClusterLoad(ctx *context.Context, stats *clusterLoadStats) int32 {
-	running := stats.Running + stats.Planning + stats.Finishing + stats.Dispatching
+	running := stats.runningQueries()
 	queued := stats.Queued + stats.Starting
 
-	// TODO - factor in more metrics
+	// Groups can factor in queue time & cluster size via their load formula,
+	// this is retained for evaluating threshold_cluster_load of backends.
 	load := ((running * 2) + (queued*1)/3)
 
 	return load
@@ -11,6 +11,13 @@ type Metrics struct {
 	executionlastRunAt *prometheus.GaugeVec
 	executionDurations *prometheus.HistogramVec
 	backendLoad        *prometheus.GaugeVec
+
+	backendQueuedQueries *prometheus.GaugeVec
+	backendAvgQueueTime  *prometheus.GaugeVec
+	backendActiveNodes   *prometheus.GaugeVec
+
+	probesTotal    *prometheus.CounterVec
+	backendHealthy *prometheus.GaugeVec
 }
 
 var metrics *Metrics
@@ -50,4 +57,44 @@ func initMetrics() {
 		},
 		[]string{"env", "backend"},
 	).MustCurryWith(prometheus.Labels{"env": env})
+
+	metrics.backendQueuedQueries = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trino_gateway_monitor_backend_queued_queries",
+			Help: "Queued queries in backend observed by last run of monitor task.",
+		},
+		[]string{"env", "backend"},
+	).MustCurryWith(prometheus.Labels{"env": env})
+
+	metrics.backendAvgQueueTime = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trino_gateway_monitor_backend_avg_queue_time_seconds",
+			Help: "Average queue time of recent queries in backend observed by last run of monitor task.",
+		},
+		[]string{"env", "backend"},
+	).MustCurryWith(prometheus.Labels{"env": env})
+
+	metrics.backendActiveNodes = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trino_gateway_monitor_backend_active_nodes",
+			Help: "Active nodes in backend observed by last run of monitor task.",
+		},
+		[]string{"env", "backend"},
+	).MustCurryWith(prometheus.Labels{"env": env})
+
+	metrics.probesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "trino_gateway_monitor_probes_total",
+			Help: "Number of health probes of backends.",
+		},
+		[]string{"env", "backend", "healthy"},
+	).MustCurryWith(prometheus.Labels{"env": env})
+
+	metrics.backendHealthy = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trino_gateway_monitor_backend_healthy",
+			Help: "Health state of backend as evaluated by monitor, 1 if healthy.",
+		},
+		[]string{"env", "backend"},
+	).MustCurryWith(prometheus.Labels{"env": env})
 }
@@ -8,23 +8,35 @@ import (
 
 	"github.com/go-co-op/gocron"
 	"github.com/razorpay/trino-gateway/internal/provider"
-	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
 )
 
 type Monitor struct {
-	core ICore
+	core     ICore
+	probeCfg ProbeConfig
+
+	mu      sync.Mutex
+	probers map[string]*prober
 }
 
 func init() {
 	initMetrics()
 }
 
-func NewMonitor(core ICore) *Monitor {
-	return &Monitor{
-		core: core,
+// NewMonitor returns a monitor probing backends as per `monitor.probe`, error if the config is invalid
+func NewMonitor(core ICore) (*Monitor, error) {
+	probeCfg, err := probeConfigFromBoot()
+	if err != nil {
+		return nil, err
 	}
+	return &Monitor{
+		core:     core,
+		probeCfg: probeCfg,
+		probers:  make(map[string]*prober),
+	}, nil
 }
 
+// Schedule periodically reconciles probe loops with the backends, every backend
+// is probed in its own loop as per the probe config.
 func (m *Monitor) Schedule(ctx *context.Context, interval string) error {
 	s := gocron.NewScheduler(time.UTC)
 	j, err := s.Every(interval).Do(m.Execute, ctx)
@@ -42,6 +54,8 @@ func (m *Monitor) Schedule(ctx *context.Context, interval string) error {
 	return nil
 }
 
+// Execute starts probe loops for new backends, stops them for deleted ones and
+// refreshes attributes of the existing ones.
 func (m *Monitor) Execute(ctx *context.Context) {
 	provider.Logger(*ctx).Info("Executing monitoring task")
 
@@ -57,47 +71,47 @@ func (m *Monitor) Execute(ctx *context.Context) {
 			WithLabelValues().SetToCurrentTime()
 	}(time.Now())
 
-	provider.Logger(*ctx).Info("Evaluating new state for backends")
-	newStates, err := m.core.EvaluateBackendNewState(ctx)
+	backends, err := m.core.GetAllBackends(ctx)
 	if err != nil {
-		provider.Logger(*ctx).WithError(err).Error("Error evaluating new states for backends")
+		provider.Logger(*ctx).WithError(err).Error("Error fetching backends")
 		return
 	}
 
-	if len(newStates.Healthy) == 0 {
-		provider.Logger(*ctx).Error("No Backends are in Healthy state.")
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current := make(map[string]bool, len(backends))
+	for _, b := range backends {
+		current[b.GetId()] = true
+		if p, ok := m.probers[b.GetId()]; ok {
+			p.update(b)
+			continue
+		}
+		provider.Logger(*ctx).Infow("Starting probes for backend", map[string]interface{}{"backend": b})
+		p := newProber(m.core, m.probeCfg, b)
+		probeCtx, cancel := context.WithCancel(*ctx)
+		p.cancel = cancel
+		m.probers[b.GetId()] = p
+		go p.run(probeCtx)
 	}
 
-	provider.Logger(*ctx).Debug("Marking healthy/unhealthy backends as per evaluated states")
-	var wg sync.WaitGroup
-	for _, b := range newStates.Unhealthy {
-		wg.Add(1)
-		go func(x *gatewayv1.Backend) {
-			defer wg.Done()
-			err = m.core.MarkUnhealthyBackend(ctx, x)
-			if err != nil {
-				provider.Logger(*ctx).WithError(err).Errorw(
-					"Failure marking backend as Unhealthy",
-					map[string]interface{}{"backend": x})
-			}
-		}(b)
+	healthy := 0
+	for id, p := range m.probers {
+		if !current[id] {
+			provider.Logger(*ctx).Infow("Stopping probes for deleted backend", map[string]interface{}{"backend_id": id})
+			p.cancel()
+			delete(m.probers, id)
+			metrics.backendHealthy.DeleteLabelValues(id)
+			continue
+		}
+		if p.isHealthy() {
+			healthy++
+		}
 	}
 
-	for _, b := range newStates.Healthy {
-		wg.Add(1)
-		go func(x *gatewayv1.Backend) {
-			defer wg.Done()
-			err = m.core.MarkHealthyBackend(ctx, x)
-			if err != nil {
-				provider.Logger(*ctx).WithError(err).Errorw(
-					"Failure marking backend as Healthy",
-					map[string]interface{}{"backend": x})
-			}
-		}(b)
+	if healthy == 0 {
+		provider.Logger(*ctx).Error("No Backends are in Healthy state.")
 	}
 
-	// Wait for all backend health updates to complete.
-	wg.Wait()
-
 	provider.Logger(*ctx).Info("Finished executing monitoring task")
 }
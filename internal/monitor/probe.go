@@ -0,0 +1,215 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/razorpay/trino-gateway/internal/boot"
+	"github.com/razorpay/trino-gateway/internal/provider"
+	"github.com/razorpay/trino-gateway/internal/utils"
+	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
+)
+
+type ProbeConfig struct {
+	Interval time.Duration
+	Timeout  time.Duration
+	// upper bound of the probe interval of unhealthy backends, backoff is disabled if below Interval
+	MaxBackoff time.Duration
+	// consecutive failed probes required for marking a healthy backend unhealthy
+	FailureThreshold int
+	// consecutive successful probes required for marking an unhealthy backend healthy
+	SuccessThreshold int
+}
+
+func probeConfigFromBoot() (ProbeConfig, error) {
+	cfg := boot.Config.Monitor.Probe
+	c := ProbeConfig{
+		FailureThreshold: cfg.FailureThreshold,
+		SuccessThreshold: cfg.SuccessThreshold,
+	}
+	var err error
+	if c.Interval, err = time.ParseDuration(cfg.Interval); err != nil {
+		return ProbeConfig{}, fmt.Errorf("invalid monitor.probe.interval: %w", err)
+	}
+	if c.Timeout, err = time.ParseDuration(cfg.Timeout); err != nil {
+		return ProbeConfig{}, fmt.Errorf("invalid monitor.probe.timeout: %w", err)
+	}
+	if c.MaxBackoff, err = utils.ParseOptionalDuration(cfg.MaxBackoff); err != nil {
+		return ProbeConfig{}, fmt.Errorf("invalid monitor.probe.maxBackoff: %w", err)
+	}
+	return c, c.Validate()
+}
+
+// Validate returns an error for configs probing backends without a delay or timing out probes right away
+func (c ProbeConfig) Validate() error {
+	if c.Interval <= 0 {
+		return errors.New("monitor.probe.interval must be positive")
+	}
+	if c.Timeout <= 0 {
+		return errors.New("monitor.probe.timeout must be positive")
+	}
+	return nil
+}
+
+// healthState tracks consecutive probe results of a backend, health state only flips
+// once the thresholds are crossed so that a single blip doesn't drain a cluster.
+type healthState struct {
+	healthy   bool
+	failures  int
+	successes int
+}
+
+// observe records the probe result, returns whether health state of the backend should flip
+func (s *healthState) observe(r *ProbeResult, cfg ProbeConfig) bool {
+	if r.Healthy {
+		s.failures = 0
+		s.successes++
+		return !s.healthy && s.successes >= cfg.SuccessThreshold
+	}
+	s.successes = 0
+	if r.Immediate {
+		// not a failure of the cluster, probe at regular interval to catch it once eligible
+		s.failures = 0
+		return s.healthy
+	}
+	s.failures++
+	return s.healthy && s.failures >= cfg.FailureThreshold
+}
+
+func (s *healthState) setHealthy(healthy bool) {
+	s.healthy = healthy
+	s.failures = 0
+	s.successes = 0
+}
+
+// nextDelay returns the delay before next probe, backing off exponentially for unhealthy backends
+// upto MaxBackoff
+func (s *healthState) nextDelay(cfg ProbeConfig) time.Duration {
+	if s.healthy || s.failures <= 1 {
+		return cfg.Interval
+	}
+	maxDelay := max(cfg.MaxBackoff, cfg.Interval)
+	delay := cfg.Interval
+	for i := 1; i < s.failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+// prober periodically probes a single backend and marks it healthy/unhealthy
+type prober struct {
+	core   ICore
+	cfg    ProbeConfig
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	backend *gatewayv1.Backend
+	state   healthState
+}
+
+func newProber(core ICore, cfg ProbeConfig, b *gatewayv1.Backend) *prober {
+	return &prober{
+		core:    core,
+		cfg:     cfg,
+		backend: b,
+		state:   healthState{healthy: b.GetIsHealthy()},
+	}
+}
+
+// update refreshes the backend attributes, health state changed outside of the
+// monitor (e.g. by the router) is adopted and has to cross the thresholds to flip again
+func (p *prober) update(b *gatewayv1.Backend) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.backend = b
+	if b.GetIsHealthy() != p.state.healthy {
+		p.state.setHealthy(b.GetIsHealthy())
+	}
+}
+
+func (p *prober) isHealthy() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state.healthy
+}
+
+func (p *prober) run(ctx context.Context) {
+	for {
+		delay := p.probe(&ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (p *prober) probe(ctx *context.Context) time.Duration {
+	p.mu.Lock()
+	b := p.backend
+	p.mu.Unlock()
+
+	probeCtx, cancel := context.WithTimeout(*ctx, p.cfg.Timeout)
+	res := p.core.ProbeBackend(&probeCtx, b)
+	cancel()
+	if (*ctx).Err() != nil {
+		// stopped while probing
+		return 0
+	}
+	metrics.probesTotal.WithLabelValues(b.GetId(), fmt.Sprint(res.Healthy)).Inc()
+
+	// transition is computed under the lock but applied via the api without holding it,
+	// so that updates of the backend by the monitor don't wait for the api call
+	p.mu.Lock()
+	flip := p.state.observe(res, p.cfg)
+	healthy := p.state.healthy
+	var reason string
+	if flip && healthy {
+		reason = fmt.Sprint(res.Reason, " (", p.state.failures, " consecutive failed probes)")
+		if res.Immediate {
+			reason = res.Reason
+		}
+	} else if flip {
+		reason = fmt.Sprint(p.state.successes, " consecutive successful probes")
+	}
+	p.mu.Unlock()
+
+	var err error
+	if flip && healthy {
+		err = p.core.MarkUnhealthyBackend(ctx, b, reason)
+	} else if flip {
+		err = p.core.MarkHealthyBackend(ctx, b, reason)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if flip {
+		if err != nil {
+			// state is retained, so it is retried on next probe
+			provider.Logger(*ctx).WithError(err).Errorw(
+				"Failure updating health state of backend",
+				map[string]interface{}{"backend": b, "healthy": !healthy})
+		} else {
+			provider.Logger(*ctx).Infow(
+				"Health state of backend changed",
+				map[string]interface{}{"backend_id": b.GetId(), "healthy": !healthy, "reason": res.Reason})
+			// unless already adopted from an update of the backend meanwhile
+			if p.state.healthy == healthy {
+				p.state.setHealthy(!healthy)
+			}
+		}
+	}
+	metrics.backendHealthy.WithLabelValues(b.GetId()).Set(boolToFloat(p.state.healthy))
+
+	return p.state.nextDelay(p.cfg)
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,98 @@
+package monitor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/razorpay/trino-gateway/pkg/logger"
+	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_healthState(t *testing.T) {
+	cfg := ProbeConfig{Interval: 10 * time.Second, MaxBackoff: time.Minute, FailureThreshold: 3, SuccessThreshold: 2}
+	failed := &ProbeResult{Reason: "cluster not ready"}
+	succeeded := &ProbeResult{Healthy: true}
+
+	s := &healthState{healthy: true}
+	assert.False(t, s.observe(failed, cfg))
+	assert.False(t, s.observe(failed, cfg))
+	// a success in between resets the failures
+	assert.False(t, s.observe(succeeded, cfg))
+	assert.False(t, s.observe(failed, cfg))
+	assert.False(t, s.observe(failed, cfg))
+	assert.True(t, s.observe(failed, cfg))
+	assert.Equal(t, cfg.Interval, s.nextDelay(cfg))
+
+	s.setHealthy(false)
+	// exponential backoff for unhealthy backend
+	delays := []time.Duration{10 * time.Second, 10 * time.Second, 20 * time.Second, 40 * time.Second, time.Minute, time.Minute}
+	for _, d := range delays {
+		assert.Equal(t, d, s.nextDelay(cfg))
+		assert.False(t, s.observe(failed, cfg))
+	}
+
+	assert.False(t, s.observe(succeeded, cfg))
+	assert.Equal(t, cfg.Interval, s.nextDelay(cfg))
+	assert.True(t, s.observe(succeeded, cfg))
+	s.setHealthy(true)
+
+	// immediate results aren't damped
+	assert.True(t, s.observe(&ProbeResult{Reason: "outside uptime schedule", Immediate: true}, cfg))
+	s.setHealthy(false)
+	assert.False(t, s.observe(&ProbeResult{Reason: "outside uptime schedule", Immediate: true}, cfg))
+	assert.Equal(t, cfg.Interval, s.nextDelay(cfg))
+}
+
+func Test_healthStateBackoffBound(t *testing.T) {
+	s := &healthState{healthy: false, failures: 100}
+	// delay doesn't overflow without a max backoff
+	assert.Equal(t, 10*time.Second, s.nextDelay(ProbeConfig{Interval: 10 * time.Second}))
+	assert.Equal(t, time.Hour, s.nextDelay(ProbeConfig{Interval: 10 * time.Second, MaxBackoff: time.Hour}))
+}
+
+func Test_probeConfigValidate(t *testing.T) {
+	assert.Nil(t, ProbeConfig{Interval: time.Second, Timeout: time.Second}.Validate())
+	assert.NotNil(t, ProbeConfig{Timeout: time.Second}.Validate())
+	assert.NotNil(t, ProbeConfig{Interval: time.Second}.Validate())
+}
+
+// blockingCore fails probes & blocks marking backends unhealthy till released
+type blockingCore struct {
+	ICore
+	marking chan struct{}
+	release chan struct{}
+}
+
+func (c *blockingCore) ProbeBackend(ctx *context.Context, b *gatewayv1.Backend) *ProbeResult {
+	return &ProbeResult{Reason: "outside uptime schedule", Immediate: true}
+}
+
+func (c *blockingCore) MarkUnhealthyBackend(ctx *context.Context, b *gatewayv1.Backend, reason string) error {
+	close(c.marking)
+	<-c.release
+	return nil
+}
+
+func Test_proberMarkWithoutLock(t *testing.T) {
+	l, err := logger.NewLogger(logger.Config{LogLevel: logger.Warn})
+	assert.Nil(t, err)
+	ctx := context.WithValue(context.Background(), logger.LoggerCtxKey, l)
+
+	core := &blockingCore{marking: make(chan struct{}), release: make(chan struct{})}
+	p := newProber(core, ProbeConfig{Interval: time.Second, Timeout: time.Second}, &gatewayv1.Backend{Id: "b1", IsHealthy: true})
+	done := make(chan struct{})
+	go func() {
+		p.probe(&ctx)
+		close(done)
+	}()
+
+	<-core.marking
+	// the prober isn't locked while the backend is being marked
+	p.update(&gatewayv1.Backend{Id: "b1", IsHealthy: true})
+	assert.True(t, p.isHealthy())
+	close(core.release)
+	<-done
+	assert.False(t, p.isHealthy())
+}
@@ -2,6 +2,7 @@ package monitor
 
 import (
 	"context"
+	"crypto/tls"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -28,6 +29,8 @@ type TrinoClient struct {
 	url  url.URL
 	user string
 	pass string
+	// nil if defaults apply
+	tlsConfig *tls.Config
 }
 
 func NewTrinoClient(ctx *context.Context, url url.URL, user string) *TrinoClient {
@@ -48,6 +51,7 @@ func (t *TrinoClient) httpClient(ctx *context.Context) *http.Client {
 			MaxIdleConns:          3,
 			IdleConnTimeout:       10 * time.Second,
 			TLSHandshakeTimeout:   5 * time.Second,
+			TLSClientConfig:       t.tlsConfig,
 			ExpectContinueTimeout: 2 * time.Second,
 		},
 	}
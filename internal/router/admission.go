@@ -0,0 +1,350 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/razorpay/trino-gateway/internal/provider"
+	"github.com/razorpay/trino-gateway/internal/router/admission"
+	"github.com/razorpay/trino-gateway/internal/router/queryresults"
+	"github.com/razorpay/trino-gateway/internal/router/quota"
+	"github.com/razorpay/trino-gateway/internal/routing"
+	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
+)
+
+// Trino error codes for queries failed by the gateway's admission control
+const (
+	errorCodeQueryQueueFull    = 131074
+	errorCodeExceededTimeLimit = 131075
+)
+
+type admittedRoutingKey struct{}
+
+// admittedRouting is the routing evaluated by the admission handler for a query submission
+type admittedRouting struct {
+	backendId string
+	groupId   string
+	// nil if the group doesn't have admission control
+	slot *admission.Slot
+	// the parsed submission, so the proxy doesn't parse it again
+	request *QueryRequest
+}
+
+func admittedRoutingFromRequest(req *http.Request) *admittedRouting {
+	a, _ := req.Context().Value(admittedRoutingKey{}).(*admittedRouting)
+	return a
+}
+
+// queuedQuery is the submission of a query queued at the gateway, dispatched to a backend once admitted
+type queuedQuery struct {
+	header   http.Header
+	body     []byte
+	rawQuery string
+	// part of the nextUri, so ids of queued queries can't be used to poll them
+	slug string
+	// nil if quotas aren't applicable to the query
+	quotaLease *quota.Lease
+	request    *QueryRequest
+}
+
+// AdmissionHandler queues query submissions for groups at their admission limits,
+// serving the queued queries till they are admitted & dispatched to a backend.
+func (r *RouterServer) AdmissionHandler(ctx *context.Context, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if r.admission == nil {
+			h.ServeHTTP(w, req)
+			return
+		}
+		if req.Method == http.MethodPost && req.URL.Path == "/v1/statement" {
+			r.admitQuery(ctx, w, req, h)
+			return
+		}
+		if strings.HasPrefix(req.URL.Path, "/v1/statement/queued/") {
+			if queryId, _ := extractQueryIdFromNextUri(req.URL.Path); admission.IsTicketId(queryId) {
+				r.serveQueuedQuery(ctx, w, req, h, queryId)
+				return
+			}
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
+func (r *RouterServer) admitQuery(ctx *context.Context, w http.ResponseWriter, req *http.Request, h http.Handler) {
+	snapshot := r.routingSnapshot.get()
+	if snapshot == nil {
+		h.ServeHTTP(w, req)
+		return
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		provider.Logger(*ctx).WithError(err).Error(fmt.Sprint(LOG_TAG, "unable to read body of query submission"))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	cReq, err := r.ParseClientRequest(ctx, req)
+	// invalid requests, queries routed by id, statements of transactions & of prepared statements
+	// pinned to a backend are handled as usual
+	if err != nil || cReq.Validate() != nil {
+		h.ServeHTTP(w, req)
+		return
+	}
+	qReq, ok := cReq.(*QueryRequest)
+	if !ok || qReq.Query.GetId() != "" || qReq.inTransaction() || qReq.preparedRouting != nil {
+		h.ServeHTTP(w, req)
+		return
+	}
+
+	params := &routing.ClientParams{
+		ListeningPort:              qReq.incomingPort,
+		Hostname:                   qReq.clientHost,
+		Sni:                        qReq.sni,
+		HeaderConnectionProperties: qReq.headerConnectionProperties,
+		HeaderClientTags:           qReq.headerClientTags,
+		User:                       qReq.Query.GetUsername(),
+	}
+	if qReq.inspectedSql != nil {
+		params.StatementType = qReq.inspectedSql.StatementType
+		params.Catalogs = qReq.inspectedSql.Catalogs
+		params.Schemas = qReq.inspectedSql.Schemas
+		params.Tables = qReq.inspectedSql.Tables
+	}
+	backendId, groupId, err := r.routingSnapshot.evaluateBackend(snapshot, params)
+	if err != nil {
+		// proxy evaluates it again & fails the request
+		h.ServeHTTP(w, req)
+		return
+	}
+	group := snapshot.Groups[groupId]
+	if group.Admission == nil {
+		h.ServeHTTP(w, withAdmittedRouting(req, &admittedRouting{backendId: backendId, groupId: groupId, request: qReq}))
+		return
+	}
+
+	q := &queuedQuery{
+		header:     req.Header.Clone(),
+		body:       body,
+		rawQuery:   req.URL.RawQuery,
+		slug:       newSlug(),
+		quotaLease: quotaLeaseFromRequest(req),
+		request:    qReq,
+	}
+	outcome, slot, ticket := r.admission.Admit(groupId, admissionLimits(group.Admission), backendId != "", q, time.Now())
+	r.observeAdmission(groupId, outcome)
+	provider.Logger(*ctx).Debugw(fmt.Sprint(LOG_TAG, "admission evaluated for query"), map[string]interface{}{
+		"group_id": groupId,
+		"outcome":  outcome.String(),
+	})
+
+	switch outcome {
+	case admission.Admitted:
+		r.routingSnapshot.commitRouted(groupId, backendId)
+		h.ServeHTTP(w, withAdmittedRouting(req, &admittedRouting{backendId: backendId, groupId: groupId, slot: slot, request: qReq}))
+	case admission.Queued:
+		r.writeQueuedResults(w, req, ticket, q, 0)
+	default:
+		r.releaseQuotaLease(q.quotaLease)
+		r.writeFailedResults(w, req, "", &queryresults.Error{
+			Message:   fmt.Sprintf("Too many queued queries for group %s", groupId),
+			ErrorCode: errorCodeQueryQueueFull,
+			ErrorName: "QUERY_QUEUE_FULL",
+			ErrorType: "INSUFFICIENT_RESOURCES",
+		})
+	}
+}
+
+// serveQueuedQuery waits for admission of the queued query for up to `gateway.admission.pollWait`,
+// dispatching it to a backend once admitted.
+func (r *RouterServer) serveQueuedQuery(ctx *context.Context, w http.ResponseWriter, req *http.Request, h http.Handler, ticketId string) {
+	// /v1/statement/queued/{queryId}/{slug}/{token}
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, "/v1/statement/queued/"), "/")
+	ticket, ok := r.admission.Lookup(ticketId)
+	if !ok || len(parts) != 3 || parts[1] != ticket.Payload.(*queuedQuery).slug {
+		r.writeGatewayError(w, req, queryresults.ErrGatewayQueryNotFound, fmt.Sprintf("Query %s not found", ticketId))
+		return
+	}
+	q := ticket.Payload.(*queuedQuery)
+	token, _ := strconv.ParseInt(parts[2], 10, 64)
+
+	if req.Method == http.MethodDelete {
+		if r.admission.Cancel(ticketId) {
+			r.releaseQuotaLease(q.quotaLease)
+		}
+		r.observeAdmissionStats(ticket.GroupId)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if q.quotaLease != nil {
+		r.quota.TouchLease(q.quotaLease, time.Now())
+	}
+
+	timer := time.NewTimer(r.admissionPollWait)
+	defer timer.Stop()
+	for {
+		changed := r.admission.Changed()
+		var backendId string
+		if snapshot := r.routingSnapshot.get(); snapshot != nil {
+			backendId = r.routingSnapshot.selectGroupBackend(snapshot, ticket.GroupId)
+		}
+
+		outcome, slot, _ := r.admission.Poll(ticketId, backendId != "", time.Now())
+		switch outcome {
+		case admission.Admitted:
+			r.observeAdmission(ticket.GroupId, outcome)
+			r.routingSnapshot.commitRouted(ticket.GroupId, backendId)
+			provider.Logger(*ctx).Debugw(fmt.Sprint(LOG_TAG, "queued query admitted"), map[string]interface{}{
+				"queued_query_id": ticketId,
+				"group_id":        ticket.GroupId,
+				"backend_id":      backendId,
+			})
+			submission := queuedQuerySubmission(req, q)
+			if q.quotaLease != nil {
+				submission = withQuotaLease(submission, q.quotaLease)
+			}
+			h.ServeHTTP(w, withAdmittedRouting(
+				submission,
+				&admittedRouting{backendId: backendId, groupId: ticket.GroupId, slot: slot, request: q.request},
+			))
+			return
+		case admission.TimedOut:
+			r.observeAdmission(ticket.GroupId, outcome)
+			r.releaseQuotaLease(q.quotaLease)
+			r.writeFailedResults(w, req, ticketId, &queryresults.Error{
+				Message:   fmt.Sprintf("Query exceeded maximum queued time of group %s", ticket.GroupId),
+				ErrorCode: errorCodeExceededTimeLimit,
+				ErrorName: "EXCEEDED_TIME_LIMIT",
+				ErrorType: "INSUFFICIENT_RESOURCES",
+			})
+			return
+		case admission.NotFound:
+			r.writeGatewayError(w, req, queryresults.ErrGatewayQueryNotFound, fmt.Sprintf("Query %s not found", ticketId))
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			r.writeQueuedResults(w, req, ticket, q, token+1)
+			return
+		case <-req.Context().Done():
+			return
+		}
+	}
+}
+
+// queuedQuerySubmission returns the original submission of the queued query,
+// for dispatching it to a backend in response to the poll request.
+func queuedQuerySubmission(req *http.Request, q *queuedQuery) *http.Request {
+	res := req.Clone(req.Context())
+	res.Method = http.MethodPost
+	res.URL.Path = "/v1/statement"
+	res.URL.RawPath = ""
+	res.URL.RawQuery = q.rawQuery
+	res.Header = q.header.Clone()
+	res.Body = io.NopCloser(bytes.NewReader(q.body))
+	res.ContentLength = int64(len(q.body))
+	return res
+}
+
+func withAdmittedRouting(req *http.Request, a *admittedRouting) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), admittedRoutingKey{}, a))
+}
+
+func admissionLimits(a *routing.Admission) admission.Limits {
+	return admission.Limits{
+		MaxConcurrency: int(a.MaxConcurrency),
+		MaxQueued:      int(a.MaxQueued),
+		QueueTimeout:   time.Duration(a.QueueTimeoutSecs) * time.Second,
+	}
+}
+
+func newSlug() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func (r *RouterServer) writeQueuedResults(w http.ResponseWriter, req *http.Request, ticket *admission.Ticket, q *queuedQuery, token int64) {
+	baseUrl := r.gatewayBaseUrl(req)
+	elapsed := time.Since(ticket.EnqueuedAt).Milliseconds()
+	queryresults.Write(w, http.StatusOK, &queryresults.Results{
+		Id:      ticket.ID,
+		InfoUri: queryresults.InfoUri(baseUrl, ticket.ID),
+		NextUri: fmt.Sprintf("%s/v1/statement/queued/%s/%s/%d", baseUrl, ticket.ID, q.slug, token),
+		Stats: queryresults.Stats{
+			State:             "QUEUED",
+			Queued:            true,
+			QueuedTimeMillis:  elapsed,
+			ElapsedTimeMillis: elapsed,
+		},
+	})
+}
+
+// writeFailedResults fails the query, clients stop polling as the results don't have a nextUri
+func (r *RouterServer) writeFailedResults(w http.ResponseWriter, req *http.Request, queryId string, queryErr *queryresults.Error) {
+	queryresults.Write(w, http.StatusOK, &queryresults.Results{
+		Id:      queryId,
+		InfoUri: queryresults.InfoUri(r.gatewayBaseUrl(req), queryId),
+		Stats:   queryresults.Stats{State: "FAILED"},
+		Error:   queryErr,
+	})
+}
+
+func (r *RouterServer) observeAdmission(groupId string, outcome admission.Outcome) {
+	metrics.admissionOutcomesTotal.WithLabelValues(groupId, outcome.String()).Inc()
+	r.observeAdmissionStats(groupId)
+}
+
+func (r *RouterServer) observeAdmissionStats(groupId string) {
+	running, queued := r.admission.Stats(groupId)
+	metrics.admissionRunningQueries.WithLabelValues(groupId).Set(float64(running))
+	metrics.admissionQueuedQueries.WithLabelValues(groupId).Set(float64(queued))
+}
+
+// bindAdmissionSlot associates slot of the admitted query with its id on the backend,
+// releasing it if the backend didn't accept the query.
+func (r *RouterServer) bindAdmissionSlot(req *http.Request, cReq ClientRequest, status int) {
+	a := admittedRoutingFromRequest(req)
+	if a == nil || a.slot == nil {
+		return
+	}
+	if q, ok := cReq.(*QueryRequest); ok && status == http.StatusOK && q.Query.GetId() != "" && !isTerminalQueryState(q.Query.GetState()) {
+		r.admission.Bind(a.slot, q.Query.GetId(), time.Now())
+		return
+	}
+	r.releaseAdmissionSlot(req)
+}
+
+func (r *RouterServer) releaseAdmissionSlot(req *http.Request) {
+	if a := admittedRoutingFromRequest(req); a != nil && a.slot != nil {
+		r.admission.Release(a.slot)
+		r.observeAdmissionStats(a.groupId)
+	}
+}
+
+// trackAdmittedQuery releases slot of the query once it completes, queries without
+// activity release their slots on `gateway.admission.clientTimeout`.
+func (r *RouterServer) trackAdmittedQuery(queryId string, state gatewayv1.Query_State) {
+	if r.admission == nil {
+		return
+	}
+	if isTerminalQueryState(state) {
+		r.admission.ReleaseQuery(queryId)
+	} else {
+		r.admission.Touch(queryId, time.Now())
+	}
+}
+
+func isTerminalQueryState(state gatewayv1.Query_State) bool {
+	return state == gatewayv1.Query_FINISHED || state == gatewayv1.Query_FAILED || state == gatewayv1.Query_CANCELED
+}
@@ -0,0 +1,336 @@
+// Package admission queues queries at the gateway when a routing group is at its
+// concurrency limit or none of its backends can accept queries, admitting them
+// in order of arrival as slots free up.
+package admission
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Suffix of ids of queued queries, in place of the coordinator id of Trino query ids
+const ticketIdSuffix = "_gateway"
+
+// Interval at which abandoned slots & tickets are cleaned up
+const cleanupInterval = time.Second
+
+type Limits struct {
+	// queries of the group running concurrently, 0 is unlimited
+	MaxConcurrency int
+	// queries beyond this are rejected, 0 disables queueing
+	MaxQueued int
+	// queued queries time out once they have waited for this long, 0 is unlimited
+	QueueTimeout time.Duration
+}
+
+type Outcome int
+
+const (
+	Admitted Outcome = iota
+	Queued
+	Rejected
+	TimedOut
+	NotFound
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Admitted:
+		return "admitted"
+	case Queued:
+		return "queued"
+	case Rejected:
+		return "rejected"
+	case TimedOut:
+		return "timed_out"
+	default:
+		return "not_found"
+	}
+}
+
+// Slot is held by an admitted query till it completes
+type Slot struct {
+	groupId  string
+	queryId  string
+	lastSeen time.Time
+	released bool
+}
+
+// Ticket is held by a queued query till it is admitted
+type Ticket struct {
+	ID         string
+	GroupId    string
+	EnqueuedAt time.Time
+	// opaque to the controller, e.g. the request to be dispatched once admitted
+	Payload interface{}
+
+	timeout    time.Duration
+	lastPolled time.Time
+	timedOut   bool
+}
+
+type group struct {
+	limits  Limits
+	running map[*Slot]struct{}
+	waiting []*Ticket
+}
+
+func (g *group) freeSlots() int {
+	if g.limits.MaxConcurrency <= 0 {
+		return int(^uint(0) >> 1)
+	}
+	return g.limits.MaxConcurrency - len(g.running)
+}
+
+// Controller tracks running & queued queries of all groups
+type Controller struct {
+	// slots & tickets not seen for this long are considered abandoned by the client
+	clientTimeout time.Duration
+
+	mu           sync.Mutex
+	groups       map[string]*group
+	tickets      map[string]*Ticket
+	slotsByQuery map[string]*Slot
+	seq          int
+	lastCleanup  time.Time
+	// closed whenever slots are freed
+	changed chan struct{}
+}
+
+func NewController(clientTimeout time.Duration) *Controller {
+	return &Controller{
+		clientTimeout: clientTimeout,
+		groups:        make(map[string]*group),
+		tickets:       make(map[string]*Ticket),
+		slotsByQuery:  make(map[string]*Slot),
+		changed:       make(chan struct{}),
+	}
+}
+
+// IsTicketId returns whether the query id is of a query queued at the gateway
+func IsTicketId(id string) bool {
+	return strings.HasSuffix(id, ticketIdSuffix)
+}
+
+// Admit admits a query for the group if it has a free slot & none are queued ahead of it,
+// queues the query otherwise. Queries are rejected if the queue is full.
+func (c *Controller) Admit(groupId string, limits Limits, backendAvailable bool, payload interface{}, now time.Time) (Outcome, *Slot, *Ticket) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cleanup(now)
+
+	g, ok := c.groups[groupId]
+	if !ok {
+		g = &group{running: make(map[*Slot]struct{})}
+		c.groups[groupId] = g
+	}
+	g.limits = limits
+
+	if len(g.waiting) == 0 && backendAvailable && g.freeSlots() > 0 {
+		return Admitted, c.acquire(g, groupId, now), nil
+	}
+	if len(g.waiting) >= limits.MaxQueued {
+		return Rejected, nil, nil
+	}
+
+	c.seq++
+	t := &Ticket{
+		ID:         fmt.Sprintf("%s_%05d%s", now.UTC().Format("20060102_150405"), c.seq%100000, ticketIdSuffix),
+		GroupId:    groupId,
+		EnqueuedAt: now,
+		Payload:    payload,
+		timeout:    limits.QueueTimeout,
+		lastPolled: now,
+	}
+	g.waiting = append(g.waiting, t)
+	c.tickets[t.ID] = t
+	return Queued, nil, t
+}
+
+// Poll admits the queued query once slots are free for queries ahead of it in the queue.
+func (c *Controller) Poll(ticketId string, backendAvailable bool, now time.Time) (Outcome, *Slot, *Ticket) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cleanup(now)
+
+	t, ok := c.tickets[ticketId]
+	if !ok {
+		return NotFound, nil, nil
+	}
+	t.lastPolled = now
+	g := c.groups[t.GroupId]
+	if !t.timedOut && t.timeout > 0 && now.Sub(t.EnqueuedAt) >= t.timeout {
+		c.dequeue(g, t)
+		t.timedOut = true
+	}
+	if t.timedOut {
+		delete(c.tickets, t.ID)
+		return TimedOut, nil, t
+	}
+
+	pos := 0
+	for i := range g.waiting {
+		if g.waiting[i] == t {
+			pos = i
+			break
+		}
+	}
+	if backendAvailable && pos < g.freeSlots() {
+		c.dequeue(g, t)
+		delete(c.tickets, t.ID)
+		return Admitted, c.acquire(g, t.GroupId, now), t
+	}
+	return Queued, nil, t
+}
+
+// Lookup returns the ticket of the queued query
+func (c *Controller) Lookup(ticketId string) (*Ticket, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.tickets[ticketId]
+	return t, ok
+}
+
+// Cancel removes the queued query, returns false if it isn't queued
+func (c *Controller) Cancel(ticketId string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.tickets[ticketId]
+	if !ok {
+		return false
+	}
+	c.dequeue(c.groups[t.GroupId], t)
+	delete(c.tickets, t.ID)
+	c.notify()
+	return true
+}
+
+// Bind associates the slot with id of the query on the backend, for releasing it by query id
+func (c *Controller) Bind(slot *Slot, queryId string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if slot.released {
+		return
+	}
+	slot.queryId = queryId
+	slot.lastSeen = now
+	c.slotsByQuery[queryId] = slot
+}
+
+// Touch records activity of the query, slots of queries without activity are released
+// once the client timeout elapses.
+func (c *Controller) Touch(queryId string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if slot, ok := c.slotsByQuery[queryId]; ok {
+		slot.lastSeen = now
+	}
+}
+
+func (c *Controller) Release(slot *Slot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.release(slot)
+}
+
+// ReleaseQuery releases slot held by the query, no-op if it doesn't hold one
+func (c *Controller) ReleaseQuery(queryId string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if slot, ok := c.slotsByQuery[queryId]; ok {
+		c.release(slot)
+	}
+}
+
+// Changed returns a channel which is closed when slots are freed
+func (c *Controller) Changed() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.changed
+}
+
+// Stats returns number of running & queued queries of the group
+func (c *Controller) Stats(groupId string) (running int, queued int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if g, ok := c.groups[groupId]; ok {
+		return len(g.running), len(g.waiting)
+	}
+	return 0, 0
+}
+
+func (c *Controller) acquire(g *group, groupId string, now time.Time) *Slot {
+	slot := &Slot{groupId: groupId, lastSeen: now}
+	g.running[slot] = struct{}{}
+	return slot
+}
+
+func (c *Controller) release(slot *Slot) {
+	if slot.released {
+		return
+	}
+	slot.released = true
+	delete(c.groups[slot.groupId].running, slot)
+	if slot.queryId != "" {
+		delete(c.slotsByQuery, slot.queryId)
+	}
+	c.notify()
+}
+
+func (c *Controller) dequeue(g *group, t *Ticket) {
+	for i := range g.waiting {
+		if g.waiting[i] == t {
+			g.waiting = append(g.waiting[:i], g.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *Controller) notify() {
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// cleanup releases slots & removes tickets abandoned by clients, times out queued queries
+func (c *Controller) cleanup(now time.Time) {
+	if now.Sub(c.lastCleanup) < cleanupInterval {
+		return
+	}
+	c.lastCleanup = now
+
+	for _, g := range c.groups {
+		if c.clientTimeout > 0 {
+			for slot := range g.running {
+				if now.Sub(slot.lastSeen) > c.clientTimeout {
+					c.release(slot)
+				}
+			}
+		}
+		waiting := g.waiting[:0]
+		for _, t := range g.waiting {
+			if t.timeout > 0 && now.Sub(t.EnqueuedAt) >= t.timeout {
+				// retained till polled, for failing the query
+				t.timedOut = true
+				continue
+			}
+			if c.clientTimeout > 0 && now.Sub(t.lastPolled) > c.clientTimeout {
+				delete(c.tickets, t.ID)
+				continue
+			}
+			waiting = append(waiting, t)
+		}
+		if len(waiting) != len(g.waiting) {
+			c.notify()
+		}
+		g.waiting = waiting
+	}
+	if c.clientTimeout > 0 {
+		for id, t := range c.tickets {
+			if t.timedOut && now.Sub(t.lastPolled) > c.clientTimeout {
+				delete(c.tickets, id)
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package admission
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_controllerAdmit(t *testing.T) {
+	c := NewController(time.Minute)
+	now := time.Unix(1700000000, 0)
+	limits := Limits{MaxConcurrency: 1, MaxQueued: 1, QueueTimeout: time.Minute}
+
+	outcome, slot, _ := c.Admit("g1", limits, true, nil, now)
+	assert.Equal(t, Admitted, outcome)
+	assert.NotNil(t, slot)
+
+	// at concurrency limit
+	outcome, _, ticket := c.Admit("g1", limits, true, "payload", now)
+	assert.Equal(t, Queued, outcome)
+	assert.True(t, IsTicketId(ticket.ID))
+	assert.Equal(t, "payload", ticket.Payload)
+
+	// queue full
+	outcome, _, _ = c.Admit("g1", limits, true, nil, now)
+	assert.Equal(t, Rejected, outcome)
+
+	// other groups are limited separately
+	outcome, _, _ = c.Admit("g2", limits, true, nil, now)
+	assert.Equal(t, Admitted, outcome)
+
+	outcome, _, _ = c.Poll(ticket.ID, true, now.Add(time.Second))
+	assert.Equal(t, Queued, outcome)
+
+	changed := c.Changed()
+	c.Bind(slot, "q1", now)
+	c.ReleaseQuery("q1")
+	select {
+	case <-changed:
+	default:
+		t.Error("release should signal change")
+	}
+
+	// admitted only when backends of the group are available
+	outcome, _, _ = c.Poll(ticket.ID, false, now.Add(2*time.Second))
+	assert.Equal(t, Queued, outcome)
+	outcome, slot, _ = c.Poll(ticket.ID, true, now.Add(3*time.Second))
+	assert.Equal(t, Admitted, outcome)
+	assert.NotNil(t, slot)
+	outcome, _, _ = c.Poll(ticket.ID, true, now.Add(3*time.Second))
+	assert.Equal(t, NotFound, outcome)
+
+	running, queued := c.Stats("g1")
+	assert.Equal(t, 1, running)
+	assert.Equal(t, 0, queued)
+}
+
+func Test_controllerQueueOrder(t *testing.T) {
+	c := NewController(time.Minute)
+	now := time.Unix(1700000000, 0)
+	limits := Limits{MaxConcurrency: 1, MaxQueued: 5, QueueTimeout: time.Minute}
+
+	// queued as none of the backends are available
+	_, _, t1 := c.Admit("g1", limits, false, nil, now)
+	// queued behind t1 even though backends became available
+	outcome, _, t2 := c.Admit("g1", limits, true, nil, now)
+	assert.Equal(t, Queued, outcome)
+
+	outcome, _, _ = c.Poll(t2.ID, true, now)
+	assert.Equal(t, Queued, outcome)
+	outcome, slot, _ := c.Poll(t1.ID, true, now)
+	assert.Equal(t, Admitted, outcome)
+
+	c.Release(slot)
+	// released slots aren't released again
+	c.Release(slot)
+	outcome, _, _ = c.Poll(t2.ID, true, now)
+	assert.Equal(t, Admitted, outcome)
+	running, _ := c.Stats("g1")
+	assert.Equal(t, 1, running)
+
+	// cancelled queries leave the queue
+	_, _, t3 := c.Admit("g1", limits, true, nil, now)
+	assert.True(t, c.Cancel(t3.ID))
+	assert.False(t, c.Cancel(t3.ID))
+}
+
+func Test_controllerTimeouts(t *testing.T) {
+	c := NewController(time.Minute)
+	now := time.Unix(1700000000, 0)
+	limits := Limits{MaxConcurrency: 1, MaxQueued: 5, QueueTimeout: 30 * time.Second}
+
+	_, slot, _ := c.Admit("g1", limits, true, nil, now)
+	c.Bind(slot, "q1", now)
+	_, _, t1 := c.Admit("g1", limits, true, nil, now)
+	_, _, t2 := c.Admit("g1", limits, true, nil, now.Add(20*time.Second))
+
+	outcome, _, _ := c.Poll(t1.ID, true, now.Add(30*time.Second))
+	assert.Equal(t, TimedOut, outcome)
+
+	// slot of the query is retained while the client polls it
+	c.Touch("q1", now.Add(50*time.Second))
+	outcome, _, _ = c.Poll(t2.ID, true, now.Add(49*time.Second))
+	assert.Equal(t, Queued, outcome)
+
+	// t2 isn't polled anymore, slot of q1 is released once abandoned
+	_, _, t3 := c.Admit("g1", limits, true, nil, now.Add(100*time.Second))
+	outcome, _, _ = c.Poll(t3.ID, true, now.Add(115*time.Second))
+	assert.Equal(t, Admitted, outcome)
+	outcome, _, _ = c.Poll(t2.ID, true, now.Add(115*time.Second))
+	assert.Equal(t, NotFound, outcome)
+}
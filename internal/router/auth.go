@@ -4,13 +4,17 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/razorpay/trino-gateway/internal/boot"
 	"github.com/razorpay/trino-gateway/internal/provider"
+	"github.com/razorpay/trino-gateway/internal/router/authn"
+	"github.com/razorpay/trino-gateway/internal/router/queryresults"
 	"github.com/razorpay/trino-gateway/internal/router/trinoheaders"
 	"github.com/razorpay/trino-gateway/internal/utils"
 	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
@@ -75,47 +79,240 @@ func (s *AuthService) ValidateFromValidationProvider(ctx *context.Context, usern
 	return data.OK, nil
 }
 
+// Authenticate validates the credentials via the validation provider, results are cached by
+// the delegated authenticator of the router
 func (s *AuthService) Authenticate(ctx *context.Context, username string, password string) (bool, error) {
-	authCache := s.GetInMemoryAuthCache(ctx)
+	return s.ValidateFromValidationProvider(ctx, username, password)
+}
+
+// delegatedAuthenticator authenticates passwords via the validation provider of `auth.router.delegatedAuth`
+type delegatedAuthenticator struct {
+	ctx     *context.Context
+	service IAuthService
+}
 
-	if entry, exists := authCache.Get(username); exists && entry == password {
-		authCache.Update(username, password)
-		return true, nil
+func (a *delegatedAuthenticator) Authenticate(_ context.Context, c authn.Credentials) (string, error) {
+	if c.Password == "" {
+		return "", authn.ErrUnsupported
+	}
+	isValid, err := a.service.Authenticate(a.ctx, c.User, c.Password)
+	if err != nil {
+		return "", err
 	}
+	if !isValid {
+		return "", authn.ErrInvalidCredentials
+	}
+	return c.User, nil
+}
 
-	isValid, err := s.ValidateFromValidationProvider(ctx, username, password)
+// NewAuthenticatorChains returns the chain of authenticators of each of the gateway ports as per `auth.router`
+func NewAuthenticatorChains(ctx *context.Context) (map[int]*authn.Chain, error) {
+	cfg := boot.Config.Auth.Router.DelegatedAuth
+	successTtl, _ := time.ParseDuration(cfg.CacheTTLMinutes)
+	failureTtl, _ := time.ParseDuration(cfg.CacheFailureTTL)
+	cached, err := authn.NewCached(
+		&delegatedAuthenticator{
+			ctx: ctx,
+			service: &AuthService{
+				ValidationProviderURL:   cfg.ValidationProviderURL,
+				ValidationProviderToken: cfg.ValidationProviderToken,
+			},
+		},
+		authn.CacheOptions{
+			SuccessTtl: successTtl,
+			FailureTtl: failureTtl,
+			MaxEntries: cfg.CacheMaxEntries,
+			Observe: func(outcome string) {
+				metrics.authCacheLookupsTotal.WithLabelValues(authn.TypeDelegated, outcome).Inc()
+			},
+		})
 	if err != nil {
-		return false, err
+		return nil, err
 	}
+	delegated := authn.Named{Name: authn.TypeDelegated, Authenticator: cached}
 
-	if isValid {
-		authCache.Update(username, password)
+	byName := make(map[string]authn.Named)
+	for _, c := range boot.Config.Auth.Router.Authenticators {
+		if _, exists := byName[c.Name]; exists || c.Name == "" {
+			return nil, fmt.Errorf("authenticator name %q is empty or not unique", c.Name)
+		}
+		if c.Type == authn.TypeDelegated {
+			byName[c.Name] = authn.Named{Name: c.Name, Authenticator: delegated.Authenticator}
+			continue
+		}
+		timeout, _ := time.ParseDuration(c.Timeout)
+		a, err := authn.New(authn.Config{
+			Name:           c.Name,
+			Type:           c.Type,
+			File:           c.File,
+			Url:            c.Url,
+			UserDnTemplate: c.UserDnTemplate,
+			CaFile:         c.CaFile,
+			Timeout:        timeout,
+			JwksFile:       c.JwksFile,
+			Issuer:         c.Issuer,
+			Audience:       c.Audience,
+			PrincipalClaim: c.PrincipalClaim,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("authenticator %s: %w", c.Name, err)
+		}
+		byName[c.Name] = authn.Named{Name: c.Name, Authenticator: a}
 	}
 
-	return isValid, nil
+	chains := make(map[int]*authn.Chain, len(boot.Config.Gateway.Ports))
+	for _, p := range boot.Config.Auth.Router.Ports {
+		if !utils.SliceContains(boot.Config.Gateway.Ports, p.Port) {
+			return nil, fmt.Errorf("auth port %d is not one of the gateway ports", p.Port)
+		}
+		authenticators := make([]authn.Named, len(p.Authenticators))
+		for i, name := range p.Authenticators {
+			a, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown authenticator %s of port %d", name, p.Port)
+			}
+			authenticators[i] = a
+		}
+		chains[p.Port] = authn.NewChain(authenticators...)
+	}
+	for _, port := range boot.Config.Gateway.Ports {
+		if _, ok := chains[port]; !ok {
+			chains[port] = authn.NewChain(delegated)
+		}
+	}
+	return chains, nil
 }
 
-func (s *AuthService) GetInMemoryAuthCache(ctx *context.Context) utils.ISimpleCache {
-	ctxKeyName := "routerAuthCache"
-	authCache, ok := (*ctx).Value(ctxKeyName).(*utils.InMemorySimpleCache)
+// NewImpersonationRules returns the impersonation rules of `auth.router.impersonation`, nil if no file is set
+func NewImpersonationRules() (*authn.ImpersonationRules, error) {
+	cfg := boot.Config.Auth.Router.Impersonation
+	if cfg.File == "" {
+		return nil, nil
+	}
+	refreshInterval, _ := time.ParseDuration(cfg.RefreshInterval)
+	return authn.NewImpersonationRules(cfg.File, refreshInterval)
+}
 
-	if !ok {
+type principalKey struct{}
 
-		expiryInterval, _ := time.ParseDuration(boot.Config.Auth.Router.DelegatedAuth.CacheTTLMinutes)
+func withPrincipal(req *http.Request, principal string) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), principalKey{}, principal))
+}
 
-		authCache = &utils.InMemorySimpleCache{
-			Cache: make(map[string]struct {
-				Timestamp time.Time
-				Value     string
-			}),
-			ExpiryInterval: expiryInterval,
+// principalFromRequest returns the authenticated principal of the request, empty if it wasn't authenticated
+func principalFromRequest(req *http.Request) string {
+	principal, _ := req.Context().Value(principalKey{}).(string)
+	return principal
+}
+
+// authorizeUser checks the principal may act as the Trino user of the request, which defaults to
+// the principal. Writes the error response & returns false if it isn't allowed.
+func (r *RouterServer) authorizeUser(ctx *context.Context, w http.ResponseWriter, req *http.Request, principal string) bool {
+	user := trinoheaders.Get(trinoheaders.User, req)
+	if user == "" {
+		trinoheaders.Set(trinoheaders.User, principal, req)
+		return true
+	}
+	if !r.impersonation.CanImpersonate(*ctx, principal, user) {
+		errorMsg := fmt.Sprintf("Principal - %s is not allowed to impersonate User - %s", principal, user)
+		provider.Logger(*ctx).Debug(errorMsg)
+		r.writeGatewayError(w, req, queryresults.ErrGatewayAuthFailed, errorMsg)
+		return false
+	}
+	if user != principal {
+		provider.Logger(*ctx).Infow(fmt.Sprint(LOG_TAG, "Principal impersonating user"), map[string]interface{}{
+			"principal": principal,
+			"user":      user,
+			"port":      r.port,
+		})
+	}
+	return true
+}
+
+// authenticate returns the principal of the credentials if the authenticators of the port accept them,
+// errors if they couldn't be authenticated, e.g. an authentication server being down
+func (r *RouterServer) authenticate(ctx *context.Context, creds authn.Credentials) (string, bool, error) {
+	principal, name, err := r.authenticators.Authenticate(*ctx, creds)
+	if errors.Is(err, authn.ErrInvalidCredentials) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	provider.Logger(*ctx).Debugw(fmt.Sprint(LOG_TAG, "Authenticated client"), map[string]interface{}{
+		"principal":     principal,
+		"authenticator": name,
+	})
+	return principal, true, nil
+}
+
+// bearerToken returns the token of bearer authorization of the request
+func bearerToken(req *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+func enabledAuthExemptions(items []*gatewayv1.AuthExemption) []authn.Exemption {
+	var exemptions []authn.Exemption
+	for _, e := range items {
+		if !e.GetIsEnabled() {
+			continue
 		}
-		*ctx = context.WithValue(*ctx, ctxKeyName, authCache)
+		exemptions = append(exemptions, authn.Exemption{
+			ID:       e.GetId(),
+			KeyType:  e.GetKeyType().String(),
+			KeyValue: e.GetKeyValue(),
+		})
 	}
-	return authCache
+	return exemptions
+}
+
+func (r *RouterServer) authExemptions(ctx *context.Context) ([]authn.Exemption, error) {
+	if exemptions, ok := r.routingSnapshot.getAuthExemptions(); ok {
+		return exemptions, nil
+	}
+	res, err := r.gatewayApiClient.AuthExemption.ListAllAuthExemptions(*ctx, &gatewayv1.Empty{})
+	if err != nil {
+		return nil, err
+	}
+	return enabledAuthExemptions(res.GetItems()), nil
+}
+
+// matchAuthExemption returns the auth exemption of the client request, nil if it isn't exempted from
+// authentication. Requests are authenticated if exemptions can't be evaluated.
+// Empty user & source of the client don't match exemptions keyed on them.
+func (r *RouterServer) matchAuthExemption(ctx *context.Context, client authn.Client) *authn.Exemption {
+	exemptions, err := r.authExemptions(ctx)
+	if err != nil {
+		provider.Logger(*ctx).WithError(err).Errorw(
+			fmt.Sprint(LOG_TAG, "Failed to evaluate auth exemptions. Assuming the request isn't exempted."),
+			map[string]interface{}{
+				"port": r.port,
+			})
+		return nil
+	}
+	exemption := authn.MatchExemption(exemptions, client)
+	if exemption == nil {
+		return nil
+	}
+	provider.Logger(*ctx).Infow(fmt.Sprint(LOG_TAG, "Request exempted from authentication"), map[string]interface{}{
+		"auth_exemption_id": exemption.ID,
+		"user":              client.User,
+		"port":              client.ListeningPort,
+		"client_ip":         client.Ip,
+		"source":            client.Source,
+	})
+	metrics.authExemptionsUsedTotal.WithLabelValues(exemption.ID, fmt.Sprint(r.port)).Inc()
+	return exemption
 }
 
 func (r *RouterServer) isAuthDelegated(ctx *context.Context) (bool, error) {
+	if snapshot := r.routingSnapshot.get(); snapshot != nil {
+		return snapshot.IsAuthDelegated(int32(r.port)), nil
+	}
 	res, err := r.gatewayApiClient.Policy.EvaluateAuthDelegationForClient(*ctx, &gatewayv1.EvaluateAuthDelegationRequest{IncomingPort: int32(r.port)})
 	if err != nil {
 		provider.Logger(*ctx).WithError(err).Errorw(
@@ -133,6 +330,34 @@ func (r *RouterServer) AuthHandler(ctx *context.Context, h http.Handler) http.Ha
 		if isAuth, _ := r.isAuthDelegated(ctx); isAuth {
 			// TODO: Refactor auth type handling to a dedicated type
 
+			// user & source are sent by clients unauthenticated, so only exemptions of the port &
+			// client ips apply to ports authenticating clients
+			client := authn.Client{ListeningPort: r.port, Ip: clientIp(req)}
+			if r.matchAuthExemption(ctx, client) != nil {
+				h.ServeHTTP(w, req)
+				return
+			}
+
+			// BearerAuth, user is the principal of the token
+			if token, isBearerAuth := bearerToken(req); isBearerAuth {
+				req.Header.Del("Authorization")
+				principal, isAuthenticated, err := r.authenticate(ctx, authn.Credentials{BearerToken: token})
+				if err != nil {
+					provider.Logger(*ctx).WithError(err).Error("Unable to Authenticate bearer token")
+					r.writeGatewayError(w, req, queryresults.ErrGatewayAuthUnavailable, "Unable to Authenticate the user")
+					return
+				}
+				if !isAuthenticated {
+					r.writeGatewayError(w, req, queryresults.ErrGatewayAuthFailed, "Token not authenticated")
+					return
+				}
+				if !r.authorizeUser(ctx, w, req, principal) {
+					return
+				}
+				h.ServeHTTP(w, withPrincipal(req, principal))
+				return
+			}
+
 			// BasicAuth
 			username, password, isBasicAuth := req.BasicAuth()
 
@@ -142,13 +367,6 @@ func (r *RouterServer) AuthHandler(ctx *context.Context, h http.Handler) http.Ha
 				username = trinoheaders.Get(trinoheaders.User, req)
 				password = trinoheaders.Get(trinoheaders.Password, req)
 			} else {
-				if u := trinoheaders.Get(trinoheaders.User, req); u != username {
-					errorMsg := fmt.Sprintf("Username from basicauth - %s does not match with User principal - %s", username, u)
-					provider.Logger(*ctx).Debug(errorMsg)
-					http.Error(w, errorMsg, http.StatusUnauthorized)
-					return
-				}
-
 				// Remove auth details from request
 				req.Header.Del("Authorization")
 			}
@@ -158,64 +376,58 @@ func (r *RouterServer) AuthHandler(ctx *context.Context, h http.Handler) http.Ha
 			if isNoAuth {
 				provider.Logger(*ctx).Debug("No Auth type detected")
 				errorMsg := "Password required"
-				http.Error(w, errorMsg, http.StatusUnauthorized)
+				r.writeGatewayError(w, req, queryresults.ErrGatewayAuthFailed, errorMsg)
 				return
 			}
 
-			isAuthenticated, err := r.authService.Authenticate(ctx, username, password)
+			principal, isAuthenticated, err := r.authenticate(ctx, authn.Credentials{User: username, Password: password})
 			if err != nil {
 				errorMsg := fmt.Sprintf("Unable to Authenticate users. Getting error - %s", err)
 				provider.Logger(*ctx).Error(errorMsg)
-				http.Error(w, "Unable to Authenticate the user", http.StatusNotFound)
+				r.writeGatewayError(w, req, queryresults.ErrGatewayAuthUnavailable, "Unable to Authenticate the user")
 				return
 			}
 			if !isAuthenticated {
 				provider.Logger(*ctx).Debug(fmt.Sprintf("User - %s not authenticated", username))
-				http.Error(w, "User not authenticated", http.StatusUnauthorized)
+				r.writeGatewayError(w, req, queryresults.ErrGatewayAuthFailed, "User not authenticated")
 				return
 			}
-			h.ServeHTTP(w, req)
+			// users of basic auth may impersonate other users as per the impersonation rules
+			if !r.authorizeUser(ctx, w, req, principal) {
+				return
+			}
+			h.ServeHTTP(w, withPrincipal(req, principal))
 		} else {
 			// whacky stuff
 			username, password, isBasicAuth := req.BasicAuth()
 
 			// CustomAuth
 			if isBasicAuth {
-				exemptedUsers := []string{
-					"capital-scorecard",
-					"care",
-					"cyber-helpdesk",
-					"datum",
-					"disputes",
-					"magic-checkout",
-					"partnerships",
-					"prod_api",
-					"api-service-payments.de-apps@razorpay.com",
-					"settlements",
+				client := authn.Client{
+					User:          username,
+					ListeningPort: r.port,
+					Ip:            clientIp(req),
+					Source:        trinoheaders.Get(trinoheaders.Source, req),
 				}
-
-				if !utils.SliceContains(exemptedUsers, username) {
-					if u := trinoheaders.Get(trinoheaders.User, req); u != username {
-						errorMsg := fmt.Sprintf("Username from basicauth - %s does not match with User principal - %s", username, u)
-						provider.Logger(*ctx).Debug(errorMsg)
-						http.Error(w, errorMsg, http.StatusUnauthorized)
-						return
-					}
-
+				if r.matchAuthExemption(ctx, client) == nil {
 					// Remove auth details from request
 					req.Header.Del("Authorization")
-					isAuthenticated, err := r.authService.Authenticate(ctx, username, password)
+					principal, isAuthenticated, err := r.authenticate(ctx, authn.Credentials{User: username, Password: password})
 					if err != nil {
 						errorMsg := fmt.Sprintf("Unable to Authenticate user: %s. Getting error - %s", username, err)
 						provider.Logger(*ctx).Error(errorMsg)
-						http.Error(w, "Unable to Authenticate the user", http.StatusNotFound)
+						r.writeGatewayError(w, req, queryresults.ErrGatewayAuthUnavailable, "Unable to Authenticate the user")
 						return
 					}
 					if !isAuthenticated {
 						provider.Logger(*ctx).Debug(fmt.Sprintf("User - %s not authenticated", username))
-						http.Error(w, "User not authenticated", http.StatusUnauthorized)
+						r.writeGatewayError(w, req, queryresults.ErrGatewayAuthFailed, "User not authenticated")
+						return
+					}
+					if !r.authorizeUser(ctx, w, req, principal) {
 						return
 					}
+					req = withPrincipal(req, principal)
 				}
 			}
 			h.ServeHTTP(w, req)
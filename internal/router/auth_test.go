@@ -2,9 +2,17 @@ package router
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/razorpay/trino-gateway/internal/router/authn"
+	"github.com/razorpay/trino-gateway/internal/routing"
 	"github.com/razorpay/trino-gateway/pkg/logger"
+	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -13,8 +21,10 @@ import (
 // returns the current testing context
 type AuthSuite struct {
 	suite.Suite
-	authService *AuthService
-	ctx         *context.Context
+	ctx *context.Context
+	// validation provider accepting "secret" as password of any user
+	provider      *httptest.Server
+	providerCalls atomic.Int32
 }
 
 func (suite *AuthSuite) SetupTest() {
@@ -30,25 +40,127 @@ func (suite *AuthSuite) SetupTest() {
 	c := context.WithValue(context.Background(), logger.LoggerCtxKey, l)
 
 	suite.ctx = &c
-	suite.authService = &AuthService{}
+	suite.providerCalls.Store(0)
+	suite.provider = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		suite.providerCalls.Add(1)
+		var payload struct {
+			Token string `json:"token"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		_ = json.NewEncoder(w).Encode(map[string]bool{"ok": payload.Token == "secret"})
+	}))
 }
 
-func (suite *AuthSuite) Test_GetInMemoryAuthCache_Persistance() {
-	key := "testKey"
-	value := "testValue"
+func (suite *AuthSuite) TearDownTest() {
+	suite.provider.Close()
+}
+
+func (suite *AuthSuite) Test_DelegatedAuthenticator_Cached() {
+	cached, err := authn.NewCached(
+		&delegatedAuthenticator{ctx: suite.ctx, service: &AuthService{ValidationProviderURL: suite.provider.URL}},
+		authn.CacheOptions{SuccessTtl: time.Minute, FailureTtl: time.Minute, MaxEntries: 10},
+	)
+	suite.Nil(err)
 
-	authCache := suite.authService.GetInMemoryAuthCache(suite.ctx)
-	authCache.Update(key, value)
+	for i := 0; i < 3; i++ {
+		principal, err := cached.Authenticate(*suite.ctx, authn.Credentials{User: "alice", Password: "secret"})
+		suite.Nil(err)
+		suite.Equal("alice", principal)
+	}
+	suite.Equal(int32(1), suite.providerCalls.Load(), "Accepted credentials aren't cached")
+
+	for i := 0; i < 3; i++ {
+		_, err := cached.Authenticate(*suite.ctx, authn.Credentials{User: "bob", Password: "wrong"})
+		suite.ErrorIs(err, authn.ErrInvalidCredentials)
+	}
+	suite.Equal(int32(2), suite.providerCalls.Load(), "Rejected credentials aren't cached")
+}
 
-	authCacheInstance2 := suite.authService.GetInMemoryAuthCache(suite.ctx)
-	entry, exists := authCacheInstance2.Get(key)
+// authServer returns a router server of port 8080 having auth delegated, exempting the exemptions
+func (suite *AuthSuite) authServer(exemptions []authn.Exemption) *RouterServer {
+	snapshot := routing.NewSnapshot(
+		[]*gatewayv1.Policy{{
+			Id:              "auth",
+			Rule:            &gatewayv1.Policy_Rule{Type: gatewayv1.Policy_Rule_listening_port, Value: "8080"},
+			Group:           "default",
+			IsEnabled:       true,
+			IsAuthDelegated: true,
+		}},
+		nil, nil, nil, "default",
+	)
+	delegated := &delegatedAuthenticator{ctx: suite.ctx, service: &AuthService{ValidationProviderURL: suite.provider.URL}}
+	return &RouterServer{
+		port:            8080,
+		authenticators:  authn.NewChain(authn.Named{Name: authn.TypeDelegated, Authenticator: delegated}),
+		routingSnapshot: &routingSnapshotStore{snapshot: snapshot, authExemptions: exemptions, authExemptionsLoaded: true},
+	}
+}
 
-	suite.Truef(exists, "Second cache instance doesn't have same key")
-	if exists {
-		suite.Equalf(value, entry, "Second Cache instance value doesn't match.")
+func (suite *AuthSuite) Test_AuthHandler_DelegatedPortExemptions() {
+	tests := []struct {
+		name       string
+		exemptions []authn.Exemption
+		user       string
+		basicAuth  []string
+		remoteAddr string
+		exempted   bool
+	}{
+		{
+			name:       "user exemption without password",
+			exemptions: []authn.Exemption{{ID: "datum", KeyType: authn.ExemptUser, KeyValue: "datum"}},
+			user:       "datum",
+		},
+		{
+			name:       "user exemption with wrong password",
+			exemptions: []authn.Exemption{{ID: "datum", KeyType: authn.ExemptUser, KeyValue: "datum"}},
+			user:       "datum",
+			basicAuth:  []string{"datum", "wrong"},
+		},
+		{
+			name:       "source exemption",
+			exemptions: []authn.Exemption{{ID: "superset", KeyType: authn.ExemptSource, KeyValue: "superset"}},
+			user:       "alice",
+		},
+		{
+			name:       "port exemption",
+			exemptions: []authn.Exemption{{ID: "port", KeyType: authn.ExemptListeningPort, KeyValue: "8080"}},
+			user:       "alice",
+			exempted:   true,
+		},
+		{
+			name:       "client ip exemption",
+			exemptions: []authn.Exemption{{ID: "vpc", KeyType: authn.ExemptSourceIp, KeyValue: "10.0.0.0/8"}},
+			user:       "alice",
+			remoteAddr: "10.1.2.3:40000",
+			exempted:   true,
+		},
 	}
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			served := false
+			handler := suite.authServer(tt.exemptions).AuthHandler(suite.ctx, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				served = true
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/v1/info", nil)
+			req.Header.Set("X-Trino-User", tt.user)
+			req.Header.Set("X-Trino-Source", "superset")
+			if tt.basicAuth != nil {
+				req.SetBasicAuth(tt.basicAuth[0], tt.basicAuth[1])
+			}
+			if tt.remoteAddr != "" {
+				req.RemoteAddr = tt.remoteAddr
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
 
+			suite.Equal(tt.exempted, served)
+			if !tt.exempted {
+				suite.Equal(http.StatusUnauthorized, w.Code)
+			}
+		})
+	}
 }
+
 func TestAuthSuite(t *testing.T) {
 	suite.Run(t, new(AuthSuite))
 }
@@ -0,0 +1,111 @@
+// Package authn authenticates clients of the router via a chain of authenticators, e.g. users of
+// an htpasswd file, binds to an LDAP directory or JWTs signed by keys of a JWKS file.
+package authn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrUnsupported is returned by authenticators not handling credentials of the kind sent by the client
+	ErrUnsupported = errors.New("credentials not supported by the authenticator")
+	// ErrInvalidCredentials is returned by authenticators rejecting the credentials
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Types of authenticators
+const (
+	TypeHtpasswd  = "htpasswd"
+	TypeLdap      = "ldap"
+	TypeJwt       = "jwt"
+	TypeDelegated = "delegated"
+)
+
+// Credentials sent by a client, either a user & password or a bearer token
+type Credentials struct {
+	User        string
+	Password    string
+	BearerToken string
+}
+
+type Authenticator interface {
+	// Authenticate returns the principal authenticated by the credentials
+	Authenticate(ctx context.Context, c Credentials) (string, error)
+}
+
+// Config of an authenticator, fields apply as per its type
+type Config struct {
+	Name string
+	Type string
+
+	// htpasswd file of bcrypt hashed passwords
+	File string
+
+	// ldap(s):// url of the directory server
+	Url string
+	// dn bound as for authenticating a user, `{user}` is replaced by the escaped user
+	UserDnTemplate string
+	// CA bundle for verifying the server certificate of ldaps urls, system roots if empty
+	CaFile  string
+	Timeout time.Duration
+
+	JwksFile string
+	// validated if not empty
+	Issuer   string
+	Audience string
+	// claim of the principal, `sub` if empty
+	PrincipalClaim string
+}
+
+// New returns the authenticator of the config, authenticators of delegated type are built by the router
+func New(c Config) (Authenticator, error) {
+	switch c.Type {
+	case TypeHtpasswd:
+		return NewHtpasswd(c.File)
+	case TypeLdap:
+		return NewLdap(c.Url, c.UserDnTemplate, c.CaFile, c.Timeout)
+	case TypeJwt:
+		return NewJwt(c.JwksFile, c.Issuer, c.Audience, c.PrincipalClaim)
+	default:
+		return nil, fmt.Errorf("unknown type %q of authenticator %s", c.Type, c.Name)
+	}
+}
+
+// Named is an authenticator of a chain
+type Named struct {
+	Name string
+	Authenticator
+}
+
+// Chain tries its authenticators in order till one of them accepts the credentials
+type Chain struct {
+	authenticators []Named
+}
+
+func NewChain(authenticators ...Named) *Chain {
+	return &Chain{authenticators: authenticators}
+}
+
+// Authenticate returns the principal authenticated by the credentials & the name of the authenticator
+// accepting them. Fails with ErrInvalidCredentials if none of the authenticators accepted them, with
+// the last error of an authenticator unable to authenticate them otherwise, e.g. its server being down.
+func (c *Chain) Authenticate(ctx context.Context, creds Credentials) (string, string, error) {
+	var failure error
+	for _, a := range c.authenticators {
+		principal, err := a.Authenticate(ctx, creds)
+		switch {
+		case err == nil:
+			return principal, a.Name, nil
+		case errors.Is(err, ErrUnsupported), errors.Is(err, ErrInvalidCredentials):
+		default:
+			failure = fmt.Errorf("authenticator %s: %w", a.Name, err)
+		}
+	}
+	if failure != nil {
+		return "", "", failure
+	}
+	return "", "", ErrInvalidCredentials
+}
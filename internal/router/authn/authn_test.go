@@ -0,0 +1,112 @@
+package authn
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type stubAuthenticator struct {
+	principal string
+	err       error
+}
+
+func (s stubAuthenticator) Authenticate(context.Context, Credentials) (string, error) {
+	return s.principal, s.err
+}
+
+func Test_chainAuthenticate(t *testing.T) {
+	unavailable := errors.New("connection refused")
+	tests := []struct {
+		name          string
+		chain         *Chain
+		wantPrincipal string
+		wantName      string
+		wantErr       error
+	}{
+		{
+			name: "first accepting authenticator wins",
+			chain: NewChain(
+				Named{"jwt", stubAuthenticator{err: ErrUnsupported}},
+				Named{"file", stubAuthenticator{err: ErrInvalidCredentials}},
+				Named{"ldap", stubAuthenticator{principal: "alice"}},
+				Named{"delegated", stubAuthenticator{principal: "bob"}},
+			),
+			wantPrincipal: "alice",
+			wantName:      "ldap",
+		},
+		{
+			name: "failures of authenticators are skipped over",
+			chain: NewChain(
+				Named{"ldap", stubAuthenticator{err: unavailable}},
+				Named{"file", stubAuthenticator{principal: "alice"}},
+			),
+			wantPrincipal: "alice",
+			wantName:      "file",
+		},
+		{
+			name: "failure is returned if none accepted",
+			chain: NewChain(
+				Named{"ldap", stubAuthenticator{err: unavailable}},
+				Named{"file", stubAuthenticator{err: ErrInvalidCredentials}},
+			),
+			wantErr: unavailable,
+		},
+		{
+			name: "rejected by all",
+			chain: NewChain(
+				Named{"jwt", stubAuthenticator{err: ErrUnsupported}},
+				Named{"file", stubAuthenticator{err: ErrInvalidCredentials}},
+			),
+			wantErr: ErrInvalidCredentials,
+		},
+		{
+			name:    "empty chain",
+			chain:   NewChain(),
+			wantErr: ErrInvalidCredentials,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, name, err := tt.chain.Authenticate(context.Background(), Credentials{User: "alice", Password: "secret"})
+			assert.Equal(t, tt.wantPrincipal, principal)
+			assert.Equal(t, tt.wantName, name)
+			if tt.wantErr == nil {
+				assert.Nil(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_htpasswdAuthenticate(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	assert.Nil(t, err)
+	file := filepath.Join(t.TempDir(), "htpasswd")
+	assert.Nil(t, os.WriteFile(file, []byte("# users\nalice:"+string(hash)+"\n\n"), 0o600))
+
+	h, err := NewHtpasswd(file)
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	principal, err := h.Authenticate(ctx, Credentials{User: "alice", Password: "secret"})
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", principal)
+	_, err = h.Authenticate(ctx, Credentials{User: "alice", Password: "wrong"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = h.Authenticate(ctx, Credentials{User: "bob", Password: "secret"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = h.Authenticate(ctx, Credentials{BearerToken: "token"})
+	assert.ErrorIs(t, err, ErrUnsupported)
+
+	// hashes other than bcrypt are refused
+	assert.Nil(t, os.WriteFile(file, []byte("alice:{SHA}5en6G6MezRroT3XKqkdPOmY/BfQ=\n"), 0o600))
+	_, err = NewHtpasswd(file)
+	assert.NotNil(t, err)
+}
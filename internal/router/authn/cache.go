@@ -0,0 +1,181 @@
+package authn
+
+import (
+	"container/list"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Outcomes of lookups of credentials in the cache
+const (
+	CacheHit         = "hit"
+	CacheNegativeHit = "negative_hit"
+	CacheMiss        = "miss"
+)
+
+type CacheOptions struct {
+	// 0 disables caching of accepted credentials
+	SuccessTtl time.Duration
+	// 0 disables caching of rejected credentials
+	FailureTtl time.Duration
+	// least recently used entries are evicted beyond this many entries
+	MaxEntries int
+	// called with the outcome of each lookup, e.g. for exporting metrics
+	Observe func(outcome string)
+}
+
+// Cached caches results of its authenticator per user, so clients polling queries don't call
+// authentication servers for every request. Credentials are stored as salted hashes, the salt is
+// random per instance.
+type Cached struct {
+	authenticator Authenticator
+	opts          CacheOptions
+	salt          []byte
+	group         singleflight.Group
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// most recently used first
+	lru *list.List
+
+	now func() time.Time
+}
+
+type cacheEntry struct {
+	key       string
+	digest    [sha256.Size]byte
+	principal string
+	accepted  bool
+	expiresAt time.Time
+}
+
+func NewCached(authenticator Authenticator, opts CacheOptions) (*Cached, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if opts.Observe == nil {
+		opts.Observe = func(string) {}
+	}
+	return &Cached{
+		authenticator: authenticator,
+		opts:          opts,
+		salt:          salt,
+		entries:       make(map[string]*list.Element),
+		lru:           list.New(),
+		now:           time.Now,
+	}, nil
+}
+
+func (c *Cached) Authenticate(ctx context.Context, creds Credentials) (string, error) {
+	digest := c.digest(creds)
+	key := hex.EncodeToString(digest[:])
+	// entries of users are replaced on changes of their password, tokens are cached as such
+	entryKey := key
+	if creds.User != "" {
+		entryKey = creds.User
+	}
+	if principal, accepted, ok := c.lookup(entryKey, digest); ok {
+		if !accepted {
+			c.opts.Observe(CacheNegativeHit)
+			return "", ErrInvalidCredentials
+		}
+		c.opts.Observe(CacheHit)
+		return principal, nil
+	}
+	c.opts.Observe(CacheMiss)
+
+	// concurrent lookups of the same credentials share a single call to the authenticator
+	res, err, _ := c.group.Do(key, func() (interface{}, error) {
+		principal, err := c.authenticator.Authenticate(ctx, creds)
+		switch {
+		case err == nil:
+			c.store(entryKey, digest, principal, true)
+		case errors.Is(err, ErrInvalidCredentials):
+			c.store(entryKey, digest, "", false)
+		}
+		return principal, err
+	})
+	if err != nil {
+		return "", err
+	}
+	return res.(string), nil
+}
+
+func (c *Cached) digest(creds Credentials) [sha256.Size]byte {
+	h := sha256.New()
+	h.Write(c.salt)
+	for _, v := range []string{creds.User, creds.Password, creds.BearerToken} {
+		h.Write([]byte(v))
+		h.Write([]byte{0})
+	}
+	var digest [sha256.Size]byte
+	copy(digest[:], h.Sum(nil))
+	return digest
+}
+
+// lookup returns the cached result of the credentials, false if not cached
+func (c *Cached) lookup(key string, digest [sha256.Size]byte) (string, bool, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return "", false, false
+	}
+	e := el.Value.(*cacheEntry)
+	if !c.now().Before(e.expiresAt) {
+		c.remove(el)
+		return "", false, false
+	}
+	// a different password of the user is authenticated again
+	if subtle.ConstantTimeCompare(e.digest[:], digest[:]) != 1 {
+		return "", false, false
+	}
+	c.lru.MoveToFront(el)
+	return e.principal, e.accepted, true
+}
+
+func (c *Cached) store(key string, digest [sha256.Size]byte, principal string, accepted bool) {
+	ttl := c.opts.FailureTtl
+	if accepted {
+		ttl = c.opts.SuccessTtl
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.remove(el)
+	}
+	if ttl <= 0 || c.opts.MaxEntries <= 0 {
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry{
+		key:       key,
+		digest:    digest,
+		principal: principal,
+		accepted:  accepted,
+		expiresAt: c.now().Add(ttl),
+	})
+	for c.lru.Len() > c.opts.MaxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *Cached) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*cacheEntry).key)
+}
+
+// Len returns the number of cached entries
+func (c *Cached) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
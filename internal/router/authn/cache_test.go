@@ -0,0 +1,134 @@
+package authn
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingAuthenticator accepts "secret" as password of any user, blocking calls till release is closed
+type countingAuthenticator struct {
+	calls   atomic.Int32
+	release chan struct{}
+	err     error
+}
+
+func (a *countingAuthenticator) Authenticate(_ context.Context, c Credentials) (string, error) {
+	a.calls.Add(1)
+	if a.release != nil {
+		<-a.release
+	}
+	if a.err != nil {
+		return "", a.err
+	}
+	if c.Password != "secret" {
+		return "", ErrInvalidCredentials
+	}
+	return c.User, nil
+}
+
+func Test_cachedAuthenticate(t *testing.T) {
+	a := &countingAuthenticator{}
+	var outcomes []string
+	c, err := NewCached(a, CacheOptions{
+		SuccessTtl: time.Minute,
+		FailureTtl: time.Second,
+		MaxEntries: 10,
+		Observe:    func(outcome string) { outcomes = append(outcomes, outcome) },
+	})
+	assert.Nil(t, err)
+	now := time.Now()
+	c.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		principal, err := c.Authenticate(ctx, Credentials{User: "alice", Password: "secret"})
+		assert.Nil(t, err)
+		assert.Equal(t, "alice", principal)
+	}
+	assert.Equal(t, int32(1), a.calls.Load())
+
+	// other password of the user isn't served from the cache & replaces its entry
+	_, err = c.Authenticate(ctx, Credentials{User: "alice", Password: "wrong"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = c.Authenticate(ctx, Credentials{User: "alice", Password: "wrong"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	assert.Equal(t, int32(2), a.calls.Load())
+	assert.Equal(t, []string{CacheMiss, CacheHit, CacheMiss, CacheNegativeHit}, outcomes)
+
+	// rejections expire sooner
+	now = now.Add(2 * time.Second)
+	_, err = c.Authenticate(ctx, Credentials{User: "alice", Password: "wrong"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	assert.Equal(t, int32(3), a.calls.Load())
+
+	// plaintext of credentials isn't retained
+	for _, el := range c.entries {
+		e := el.Value.(*cacheEntry)
+		assert.False(t, bytes.Contains(e.digest[:], []byte("wrong")))
+	}
+}
+
+func Test_cachedErrorsNotCached(t *testing.T) {
+	a := &countingAuthenticator{err: errors.New("connection refused")}
+	c, _ := NewCached(a, CacheOptions{SuccessTtl: time.Minute, FailureTtl: time.Minute, MaxEntries: 10})
+	for i := 0; i < 2; i++ {
+		_, err := c.Authenticate(context.Background(), Credentials{User: "alice", Password: "secret"})
+		assert.NotNil(t, err)
+	}
+	assert.Equal(t, int32(2), a.calls.Load())
+	assert.Equal(t, 0, c.Len())
+}
+
+func Test_cachedEviction(t *testing.T) {
+	a := &countingAuthenticator{}
+	c, _ := NewCached(a, CacheOptions{SuccessTtl: time.Minute, FailureTtl: time.Minute, MaxEntries: 2})
+	ctx := context.Background()
+	for _, user := range []string{"alice", "bob", "alice", "carol"} {
+		_, err := c.Authenticate(ctx, Credentials{User: user, Password: "secret"})
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, int32(3), a.calls.Load())
+
+	// bob was the least recently used
+	_, _ = c.Authenticate(ctx, Credentials{User: "alice", Password: "secret"})
+	assert.Equal(t, int32(3), a.calls.Load())
+	_, _ = c.Authenticate(ctx, Credentials{User: "bob", Password: "secret"})
+	assert.Equal(t, int32(4), a.calls.Load())
+}
+
+func Test_cachedConcurrentLookups(t *testing.T) {
+	a := &countingAuthenticator{release: make(chan struct{})}
+	c, _ := NewCached(a, CacheOptions{SuccessTtl: time.Minute, FailureTtl: time.Minute, MaxEntries: 10})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			principal, err := c.Authenticate(context.Background(), Credentials{User: "alice", Password: "secret"})
+			if err == nil && principal != "alice" {
+				err = fmt.Errorf("unexpected principal %s", principal)
+			}
+			errs <- err
+		}()
+	}
+	// lookups waiting on the first call share its result
+	time.Sleep(50 * time.Millisecond)
+	close(a.release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, int32(1), a.calls.Load())
+}
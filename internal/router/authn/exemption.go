@@ -0,0 +1,57 @@
+package authn
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Key types of auth exemptions, as in the key_type enum of auth_exemptions table
+const (
+	ExemptUser          = "user"
+	ExemptListeningPort = "listening_port"
+	ExemptSourceIp      = "source_ip"
+	ExemptSource        = "source"
+)
+
+// Exemption lets requests of clients matching it bypass authentication
+type Exemption struct {
+	ID       string
+	KeyType  string
+	KeyValue string
+}
+
+// Client has the attributes of client requests exemptions are keyed on
+type Client struct {
+	User          string
+	ListeningPort int
+	Ip            string
+	Source        string
+}
+
+// MatchExemption returns the first of the exemptions matching the client, nil if none of them do
+func MatchExemption(exemptions []Exemption, c Client) *Exemption {
+	for i := range exemptions {
+		if exemptions[i].matches(c) {
+			return &exemptions[i]
+		}
+	}
+	return nil
+}
+
+func (e *Exemption) matches(c Client) bool {
+	switch e.KeyType {
+	case ExemptUser:
+		return c.User != "" && c.User == e.KeyValue
+	case ExemptListeningPort:
+		return strconv.Itoa(c.ListeningPort) == e.KeyValue
+	case ExemptSourceIp:
+		_, cidr, err := net.ParseCIDR(e.KeyValue)
+		ip := net.ParseIP(c.Ip)
+		return err == nil && ip != nil && cidr.Contains(ip)
+	case ExemptSource:
+		return c.Source != "" && strings.EqualFold(c.Source, e.KeyValue)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,40 @@
+package authn
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MatchExemption(t *testing.T) {
+	exemptions := []Exemption{
+		{ID: "datum", KeyType: ExemptUser, KeyValue: "datum"},
+		{ID: "internal-port", KeyType: ExemptListeningPort, KeyValue: "8081"},
+		{ID: "vpc", KeyType: ExemptSourceIp, KeyValue: "10.0.0.0/8"},
+		{ID: "airflow", KeyType: ExemptSource, KeyValue: "airflow"},
+	}
+	tests := []struct {
+		name   string
+		client Client
+		want   string
+	}{
+		{"user", Client{User: "datum", ListeningPort: 8080, Ip: "192.168.1.1"}, "datum"},
+		{"user is case sensitive", Client{User: "Datum", ListeningPort: 8080}, ""},
+		{"listening port", Client{User: "alice", ListeningPort: 8081}, "internal-port"},
+		{"source ip in cidr", Client{User: "alice", ListeningPort: 8080, Ip: "10.1.2.3"}, "vpc"},
+		{"source ip outside cidr", Client{User: "alice", ListeningPort: 8080, Ip: "11.1.2.3"}, ""},
+		{"source", Client{User: "alice", ListeningPort: 8080, Source: "Airflow"}, "airflow"},
+		{"none", Client{User: "alice", ListeningPort: 8080, Ip: "192.168.1.1", Source: "cli"}, ""},
+		{"empty user", Client{ListeningPort: 8080}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MatchExemption(exemptions, tt.client)
+			if tt.want == "" {
+				assert.Nil(t, got)
+			} else if assert.NotNil(t, got) {
+				assert.Equal(t, tt.want, got.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package authn
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Htpasswd authenticates users & passwords against bcrypt hashes of an htpasswd file,
+// as generated by `htpasswd -B`. The file is read once on creation.
+type Htpasswd struct {
+	hashes map[string][]byte
+}
+
+func NewHtpasswd(file string) (*Htpasswd, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hashes := make(map[string][]byte)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		user, hash, ok := strings.Cut(entry, ":")
+		if !ok || user == "" {
+			return nil, fmt.Errorf("%s:%d: expected user:hash", file, line)
+		}
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return nil, fmt.Errorf("%s:%d: only bcrypt hashes are supported: %w", file, line, err)
+		}
+		hashes[user] = []byte(hash)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return &Htpasswd{hashes: hashes}, nil
+}
+
+func (h *Htpasswd) Authenticate(_ context.Context, c Credentials) (string, error) {
+	if c.Password == "" {
+		return "", ErrUnsupported
+	}
+	hash, ok := h.hashes[c.User]
+	if !ok {
+		return "", ErrInvalidCredentials
+	}
+	err := bcrypt.CompareHashAndPassword(hash, []byte(c.Password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return "", ErrInvalidCredentials
+	}
+	if err != nil {
+		return "", err
+	}
+	return c.User, nil
+}
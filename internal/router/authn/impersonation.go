@@ -0,0 +1,139 @@
+package authn
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/razorpay/trino-gateway/internal/provider"
+)
+
+// ImpersonationRule allows or denies principals matching OriginalUser to act as Trino users
+// matching NewUser. NewUser can refer to groups of OriginalUser, e.g. `$1`.
+type ImpersonationRule struct {
+	OriginalUser string `json:"original_user"`
+	NewUser      string `json:"new_user"`
+	// true if not set
+	Allow *bool `json:"allow"`
+
+	originalUser *regexp.Regexp
+}
+
+// References to groups of original_user in new_user, e.g. $1 or ${1}
+var groupReference = regexp.MustCompile(`\$(\d+|\{\d+\})`)
+
+// ImpersonationRules decide which principals may act as which Trino users, rules are reloaded from
+// their file once refreshInterval elapses. Last loaded rules are retained if reloading fails.
+type ImpersonationRules struct {
+	file            string
+	refreshInterval time.Duration
+
+	mu       sync.RWMutex
+	rules    []ImpersonationRule
+	loadedAt time.Time
+}
+
+// NewImpersonationRules loads rules of the json file, e.g.
+// {"impersonation": [{"original_user": "superset", "new_user": ".*"}]}
+func NewImpersonationRules(file string, refreshInterval time.Duration) (*ImpersonationRules, error) {
+	rules, err := loadImpersonationRules(file)
+	if err != nil {
+		return nil, err
+	}
+	return &ImpersonationRules{
+		file:            file,
+		refreshInterval: refreshInterval,
+		rules:           rules,
+		loadedAt:        time.Now(),
+	}, nil
+}
+
+func loadImpersonationRules(file string) ([]ImpersonationRule, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var content struct {
+		Impersonation []ImpersonationRule `json:"impersonation"`
+	}
+	if err := json.Unmarshal(b, &content); err != nil {
+		return nil, fmt.Errorf("invalid impersonation rules file %s: %w", file, err)
+	}
+	for i := range content.Impersonation {
+		rule := &content.Impersonation[i]
+		if rule.originalUser, err = regexp.Compile("^(?:" + rule.OriginalUser + ")$"); err != nil {
+			return nil, fmt.Errorf("invalid original_user of impersonation rule %d: %w", i, err)
+		}
+		if _, err := regexp.Compile(rule.NewUser); err != nil {
+			return nil, fmt.Errorf("invalid new_user of impersonation rule %d: %w", i, err)
+		}
+	}
+	return content.Impersonation, nil
+}
+
+// CanImpersonate returns whether the principal may act as the user, as per the first rule matching
+// both of them. Principals can always act as themselves, & as no other user if none of the rules match.
+func (r *ImpersonationRules) CanImpersonate(ctx context.Context, principal string, user string) bool {
+	if principal == user {
+		return true
+	}
+	if r == nil {
+		return false
+	}
+	for _, rule := range r.get(ctx) {
+		if allow, matched := rule.evaluate(principal, user); matched {
+			return allow
+		}
+	}
+	return false
+}
+
+func (rule *ImpersonationRule) evaluate(principal string, user string) (bool, bool) {
+	groups := rule.originalUser.FindStringSubmatch(principal)
+	if groups == nil {
+		return false, false
+	}
+	// groups of the original user are substituted in the pattern of the new user, as literals
+	newUser := groupReference.ReplaceAllStringFunc(rule.NewUser, func(ref string) string {
+		i, _ := strconv.Atoi(strings.Trim(ref, "${}"))
+		if i >= len(groups) {
+			return ""
+		}
+		return regexp.QuoteMeta(groups[i])
+	})
+	re, err := regexp.Compile("^(?:" + newUser + ")$")
+	if err != nil || !re.MatchString(user) {
+		return false, false
+	}
+	return rule.Allow == nil || *rule.Allow, true
+}
+
+func (r *ImpersonationRules) get(ctx context.Context) []ImpersonationRule {
+	r.mu.RLock()
+	if r.refreshInterval <= 0 || time.Since(r.loadedAt) < r.refreshInterval {
+		defer r.mu.RUnlock()
+		return r.rules
+	}
+	r.mu.RUnlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.loadedAt) < r.refreshInterval {
+		return r.rules
+	}
+	// retry on next refresh if reloading fails
+	r.loadedAt = time.Now()
+	rules, err := loadImpersonationRules(r.file)
+	if err != nil {
+		provider.Logger(ctx).WithError(err).Error("Unable to reload impersonation rules, retaining the last loaded ones")
+		return r.rules
+	}
+	r.rules = rules
+	return r.rules
+}
@@ -0,0 +1,43 @@
+package authn
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_impersonationRules(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "impersonation.json")
+	assert.Nil(t, os.WriteFile(file, []byte(`{"impersonation": [
+		{"original_user": "superset", "new_user": "admin", "allow": false},
+		{"original_user": "superset", "new_user": ".*"},
+		{"original_user": "(.*)-svc", "new_user": "$1-.*"}
+	]}`), 0o600))
+	r, err := NewImpersonationRules(file, 0)
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	assert.True(t, r.CanImpersonate(ctx, "alice", "alice"))
+	assert.False(t, r.CanImpersonate(ctx, "alice", "bob"))
+	assert.True(t, r.CanImpersonate(ctx, "superset", "alice"))
+	// first matching rule decides
+	assert.False(t, r.CanImpersonate(ctx, "superset", "admin"))
+	// groups of the principal are substituted in the new user
+	assert.True(t, r.CanImpersonate(ctx, "etl-svc", "etl-daily"))
+	assert.False(t, r.CanImpersonate(ctx, "etl-svc", "finance-daily"))
+	assert.False(t, r.CanImpersonate(ctx, "e.l-svc", "etl-daily"))
+	// principals are matched in full
+	assert.False(t, r.CanImpersonate(ctx, "superset2", "alice"))
+
+	// without rules principals can only act as themselves
+	var none *ImpersonationRules
+	assert.True(t, none.CanImpersonate(ctx, "alice", "alice"))
+	assert.False(t, none.CanImpersonate(ctx, "superset", "alice"))
+
+	assert.Nil(t, os.WriteFile(file, []byte(`{"impersonation": [{"original_user": "(", "new_user": ".*"}]}`), 0o600))
+	_, err = NewImpersonationRules(file, 0)
+	assert.NotNil(t, err)
+}
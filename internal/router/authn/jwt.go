@@ -0,0 +1,144 @@
+package authn
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+// Allowed difference of clocks of the issuer & the gateway for validating exp & nbf claims
+const jwtLeeway = time.Minute
+
+// Algorithms of tokens accepted, tokens of others, e.g. none or HS256, are rejected
+var jwtAlgorithms = []jose.SignatureAlgorithm{jose.RS256, jose.RS384, jose.RS512, jose.ES256, jose.ES384, jose.ES512}
+
+// Jwt authenticates bearer tokens signed by keys of a JWKS file, RS* & ES* algorithms are supported.
+// The file is read once on creation.
+type Jwt struct {
+	keys           jose.JSONWebKeySet
+	issuer         string
+	audience       string
+	principalClaim string
+
+	now func() time.Time
+}
+
+func NewJwt(jwksFile string, issuer string, audience string, principalClaim string) (*Jwt, error) {
+	b, err := os.ReadFile(jwksFile)
+	if err != nil {
+		return nil, err
+	}
+	var keys jose.JSONWebKeySet
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return nil, fmt.Errorf("invalid jwks file %s: %w", jwksFile, err)
+	}
+	for _, k := range keys.Keys {
+		if _, err := keyAlgorithms(k); err != nil {
+			return nil, fmt.Errorf("invalid key %q of jwks file %s: %w", k.KeyID, jwksFile, err)
+		}
+	}
+	if len(keys.Keys) == 0 {
+		return nil, fmt.Errorf("no keys in jwks file %s", jwksFile)
+	}
+	if principalClaim == "" {
+		principalClaim = "sub"
+	}
+	return &Jwt{
+		keys:           keys,
+		issuer:         issuer,
+		audience:       audience,
+		principalClaim: principalClaim,
+		now:            time.Now,
+	}, nil
+}
+
+// keyAlgorithms returns the algorithms of tokens the key may verify, ES* algorithms are tied
+// to the curve of the key. The alg of the key, if set, is the only one allowed.
+func keyAlgorithms(k jose.JSONWebKey) ([]jose.SignatureAlgorithm, error) {
+	var algs []jose.SignatureAlgorithm
+	switch key := k.Key.(type) {
+	case *rsa.PublicKey:
+		algs = []jose.SignatureAlgorithm{jose.RS256, jose.RS384, jose.RS512}
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			algs = []jose.SignatureAlgorithm{jose.ES256}
+		case elliptic.P384():
+			algs = []jose.SignatureAlgorithm{jose.ES384}
+		case elliptic.P521():
+			algs = []jose.SignatureAlgorithm{jose.ES512}
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", key.Curve.Params().Name)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, public RSA & EC keys are supported", k.Key)
+	}
+	if k.Algorithm != "" {
+		if !slices.Contains(algs, jose.SignatureAlgorithm(k.Algorithm)) {
+			return nil, fmt.Errorf("algorithm %q doesn't match the key", k.Algorithm)
+		}
+		algs = []jose.SignatureAlgorithm{jose.SignatureAlgorithm(k.Algorithm)}
+	}
+	return algs, nil
+}
+
+func (j *Jwt) Authenticate(_ context.Context, c Credentials) (string, error) {
+	if c.BearerToken == "" {
+		return "", ErrUnsupported
+	}
+	claims, err := j.verify(c.BearerToken)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidCredentials, err)
+	}
+	principal, _ := claims[j.principalClaim].(string)
+	if principal == "" {
+		return "", fmt.Errorf("%w: missing %s claim", ErrInvalidCredentials, j.principalClaim)
+	}
+	return principal, nil
+}
+
+// verify checks the signature & registered claims of the token, returning all of its claims
+func (j *Jwt) verify(token string) (map[string]interface{}, error) {
+	tok, err := jwt.ParseSigned(token, jwtAlgorithms)
+	if err != nil {
+		return nil, err
+	}
+	// tokens have a single signature
+	header := tok.Headers[0]
+	keys := j.keys.Key(header.KeyID)
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("unknown key %q", header.KeyID)
+	}
+	key := keys[0]
+	algs, _ := keyAlgorithms(key)
+	if !slices.Contains(algs, jose.SignatureAlgorithm(header.Algorithm)) {
+		return nil, fmt.Errorf("algorithm %q doesn't match the key %q", header.Algorithm, header.KeyID)
+	}
+
+	var registered jwt.Claims
+	var claims map[string]interface{}
+	if err := tok.Claims(key.Key, &registered, &claims); err != nil {
+		return nil, err
+	}
+	if registered.Expiry == nil {
+		return nil, errors.New("missing exp claim")
+	}
+	expected := jwt.Expected{Issuer: j.issuer, Time: j.now()}
+	if j.audience != "" {
+		expected.AnyAudience = jwt.Audience{j.audience}
+	}
+	if err := registered.ValidateWithLeeway(expected, jwtLeeway); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
@@ -0,0 +1,110 @@
+package authn
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	_ "crypto/sha512" // hash of ES384
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// signEc signs the claims with the key as per alg, even if the curve of the key isn't the one of alg
+func signEc(t *testing.T, key *ecdsa.PrivateKey, alg string, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hash := crypto.SHA256
+	if alg == "ES384" {
+		hash = crypto.SHA384
+	}
+	h := hash.New()
+	h.Write([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, key, h.Sum(nil))
+	assert.Nil(t, err)
+	size := (key.Curve.Params().BitSize + 7) / 8
+	sig := make([]byte, 2*size)
+	r.FillBytes(sig[:size])
+	s.FillBytes(sig[size:])
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func signEs256(t *testing.T, key *ecdsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	return signEc(t, key, "ES256", kid, claims)
+}
+
+func ecJwk(key *ecdsa.PrivateKey, kid string) map[string]string {
+	size := (key.Curve.Params().BitSize + 7) / 8
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": key.Curve.Params().Name,
+		"x":   base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size))),
+		"y":   base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size))),
+	}
+}
+
+func Test_jwtAuthenticate(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	key384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.Nil(t, err)
+	jwks, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{ecJwk(key, "k1"), ecJwk(key384, "k384")}})
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	assert.Nil(t, os.WriteFile(file, jwks, 0o600))
+
+	j, err := NewJwt(file, "https://idp.example.com", "trino", "")
+	assert.Nil(t, err)
+	now := time.Now()
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"sub": "alice",
+			"iss": "https://idp.example.com",
+			"aud": []string{"trino", "superset"},
+			"exp": now.Add(time.Hour).Unix(),
+		}
+		for k, v := range overrides {
+			c[k] = v
+		}
+		return c
+	}
+	ctx := context.Background()
+
+	principal, err := j.Authenticate(ctx, Credentials{BearerToken: signEs256(t, key, "k1", claims(nil))})
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", principal)
+	principal, err = j.Authenticate(ctx, Credentials{BearerToken: signEc(t, key384, "ES384", "k384", claims(nil))})
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", principal)
+
+	_, err = j.Authenticate(ctx, Credentials{User: "alice", Password: "secret"})
+	assert.ErrorIs(t, err, ErrUnsupported)
+
+	other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	invalid := map[string]string{
+		"expired":        signEs256(t, key, "k1", claims(map[string]interface{}{"exp": now.Add(-time.Hour).Unix()})),
+		"not valid yet":  signEs256(t, key, "k1", claims(map[string]interface{}{"nbf": now.Add(time.Hour).Unix()})),
+		"other issuer":   signEs256(t, key, "k1", claims(map[string]interface{}{"iss": "https://other.example.com"})),
+		"other audience": signEs256(t, key, "k1", claims(map[string]interface{}{"aud": "superset"})),
+		"no principal":   signEs256(t, key, "k1", claims(map[string]interface{}{"sub": ""})),
+		"unknown key":    signEs256(t, key, "k2", claims(nil)),
+		"other signer":   signEs256(t, other, "k1", claims(nil)),
+		"no expiry":      signEs256(t, key, "k1", claims(map[string]interface{}{"exp": nil})),
+		// algorithms are tied to the curve of the key
+		"es256 of p-384": signEc(t, key384, "ES256", "k384", claims(nil)),
+		"es384 of p-256": signEc(t, key, "ES384", "k1", claims(nil)),
+		"malformed":      "not.a-token",
+	}
+	for name, token := range invalid {
+		_, err = j.Authenticate(ctx, Credentials{BearerToken: token})
+		assert.ErrorIs(t, err, ErrInvalidCredentials, name)
+	}
+}
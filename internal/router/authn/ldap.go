@@ -0,0 +1,91 @@
+package authn
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Ldap authenticates users & passwords by a simple bind as the dn of the user
+type Ldap struct {
+	url            string
+	tlsConfig      *tls.Config
+	userDnTemplate string
+	timeout        time.Duration
+}
+
+func NewLdap(rawUrl string, userDnTemplate string, caFile string, timeout time.Duration) (*Ldap, error) {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(userDnTemplate, "{user}") {
+		return nil, errors.New("user dn template must contain {user}")
+	}
+	l := &Ldap{url: rawUrl, userDnTemplate: userDnTemplate, timeout: timeout}
+	switch u.Scheme {
+	case "ldap":
+	case "ldaps":
+		l.tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12, ServerName: u.Hostname()}
+		if caFile != "" {
+			pem, err := os.ReadFile(caFile)
+			if err != nil {
+				return nil, err
+			}
+			l.tlsConfig.RootCAs = x509.NewCertPool()
+			if !l.tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in ca file %s", caFile)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported scheme of ldap url %s", rawUrl)
+	}
+	if l.timeout <= 0 {
+		l.timeout = 10 * time.Second
+	}
+	return l, nil
+}
+
+func (l *Ldap) Authenticate(ctx context.Context, c Credentials) (string, error) {
+	// binds without password are unauthenticated binds, which servers accept
+	if c.Password == "" || c.User == "" {
+		return "", ErrUnsupported
+	}
+	dn := strings.ReplaceAll(l.userDnTemplate, "{user}", ldap.EscapeDN(c.User))
+	err := l.bind(ctx, dn, c.Password)
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+		return "", ErrInvalidCredentials
+	}
+	if err != nil {
+		return "", fmt.Errorf("ldap bind failed: %w", err)
+	}
+	return c.User, nil
+}
+
+// bind does a simple bind on a new connection, closed once done
+func (l *Ldap) bind(ctx context.Context, dn string, password string) error {
+	opts := []ldap.DialOpt{ldap.DialWithDialer(&net.Dialer{Timeout: l.timeout})}
+	if l.tlsConfig != nil {
+		opts = append(opts, ldap.DialWithTLSConfig(l.tlsConfig))
+	}
+	conn, err := ldap.DialURL(l.url, opts...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetTimeout(l.timeout)
+
+	// the connection is closed if the client request is done meanwhile, failing the bind
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	return conn.Bind(dn, password)
+}
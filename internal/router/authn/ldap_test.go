@@ -0,0 +1,77 @@
+package authn
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// serveLdapBinds accepts binds of the dn with the password, responding invalid credentials otherwise.
+// Responses are preceded by one of another message id, which clients have to skip.
+func serveLdapBinds(t *testing.T, dn string, password string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				req, err := ber.ReadPacket(conn)
+				if err != nil || len(req.Children) < 2 || len(req.Children[1].Children) < 3 {
+					return
+				}
+				id := req.Children[0].Value.(int64)
+				bind := req.Children[1]
+				code := int64(ldap.LDAPResultInvalidCredentials)
+				if bind.Children[1].Value == dn && bind.Children[2].Data.String() == password {
+					code = ldap.LDAPResultSuccess
+				}
+				for _, msgId := range []int64{id + 1, id} {
+					resp := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+					resp.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, msgId, "MessageID"))
+					op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationBindResponse, nil, "Bind Response")
+					op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "resultCode"))
+					op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+					op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "diagnostic", "diagnosticMessage"))
+					resp.AppendChild(op)
+					if _, err := conn.Write(resp.Bytes()); err != nil {
+						return
+					}
+				}
+				// wait for the unbind of the client
+				_, _ = ber.ReadPacket(conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func Test_ldapAuthenticate(t *testing.T) {
+	addr := serveLdapBinds(t, `uid=alice\,admin,ou=people,dc=example,dc=com`, "secret")
+	l, err := NewLdap("ldap://"+addr, "uid={user},ou=people,dc=example,dc=com", "", time.Second)
+	assert.Nil(t, err)
+	ctx := context.Background()
+
+	principal, err := l.Authenticate(ctx, Credentials{User: "alice,admin", Password: "secret"})
+	assert.Nil(t, err)
+	assert.Equal(t, "alice,admin", principal)
+	_, err = l.Authenticate(ctx, Credentials{User: "alice,admin", Password: "wrong"})
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	// unauthenticated binds are never attempted
+	_, err = l.Authenticate(ctx, Credentials{User: "alice,admin"})
+	assert.ErrorIs(t, err, ErrUnsupported)
+
+	_, err = NewLdap("ldap://"+addr, "uid=alice,dc=example,dc=com", "", time.Second)
+	assert.NotNil(t, err)
+	_, err = NewLdap("http://"+addr, "uid={user}", "", time.Second)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,24 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/razorpay/trino-gateway/internal/router/queryresults"
+)
+
+// Errors of request processing mapped to errors other than GATEWAY_REQUEST_INVALID
+var (
+	errBackendUnresolvable = errors.New("no backend available for the query")
+	errQueryUnresolvable   = errors.New("query not found")
+)
+
+// writeGatewayError fails the client request with the gateway error, returning the HTTP status sent
+func (r *RouterServer) writeGatewayError(w http.ResponseWriter, req *http.Request, e *queryresults.GatewayError, msg string) int {
+	var queryId string
+	if strings.HasPrefix(req.URL.Path, "/v1/statement/") || strings.HasPrefix(req.URL.Path, "/v1/stage/") {
+		queryId, _ = extractQueryIdFromNextUri(req.URL.Path)
+	}
+	return queryresults.WriteGatewayError(w, req, r.gatewayBaseUrl(req), queryId, e, msg)
+}
@@ -13,6 +13,25 @@ type Metrics struct {
 	requestPostRoutingDelays *prometheus.HistogramVec
 	responsesSentTotal       *prometheus.CounterVec
 	responseDurations        *prometheus.HistogramVec
+	backendEjectionsTotal    *prometheus.CounterVec
+
+	routingSnapshotRefreshesTotal *prometheus.CounterVec
+
+	admissionOutcomesTotal  *prometheus.CounterVec
+	admissionRunningQueries *prometheus.GaugeVec
+	admissionQueuedQueries  *prometheus.GaugeVec
+
+	quotaRejectionsTotal *prometheus.CounterVec
+	quotaRunningQueries  *prometheus.GaugeVec
+
+	submissionRetriesTotal *prometheus.CounterVec
+
+	backendOpenConnections          *prometheus.GaugeVec
+	backendInFlightRequests         *prometheus.GaugeVec
+	backendConnectionsAcquiredTotal *prometheus.CounterVec
+
+	authExemptionsUsedTotal *prometheus.CounterVec
+	authCacheLookupsTotal   *prometheus.CounterVec
 }
 
 var metrics *Metrics
@@ -70,4 +89,108 @@ func initMetrics() {
 		},
 		[]string{"env", "method", "code"},
 	).MustCurryWith(prometheus.Labels{"env": env}).(*prometheus.HistogramVec)
+
+	metrics.backendEjectionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "trino_gateway_router_backend_ejections_total",
+			Help: "Number of times backends were marked unhealthy due to errors in proxied requests.",
+		},
+		[]string{"env", "backend"},
+	).MustCurryWith(prometheus.Labels{"env": env})
+
+	metrics.routingSnapshotRefreshesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "trino_gateway_router_routing_snapshot_refreshes_total",
+			Help: "Number of refreshes of the in-memory routing snapshot.",
+		},
+		[]string{"env", "status"},
+	).MustCurryWith(prometheus.Labels{"env": env})
+
+	metrics.admissionOutcomesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "trino_gateway_router_admission_outcomes_total",
+			Help: "Number of admission decisions for queries of groups having admission control.",
+		},
+		[]string{"env", "group", "outcome"},
+	).MustCurryWith(prometheus.Labels{"env": env})
+
+	metrics.admissionRunningQueries = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trino_gateway_router_admission_running_queries",
+			Help: "Queries of the group admitted by this gateway instance and not completed yet.",
+		},
+		[]string{"env", "group"},
+	).MustCurryWith(prometheus.Labels{"env": env})
+
+	metrics.admissionQueuedQueries = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trino_gateway_router_admission_queued_queries",
+			Help: "Queries of the group queued at this gateway instance.",
+		},
+		[]string{"env", "group"},
+	).MustCurryWith(prometheus.Labels{"env": env})
+
+	metrics.quotaRejectionsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "trino_gateway_router_quota_rejections_total",
+			Help: "Number of queries rejected for exceeding a quota, by the exceeded limit.",
+		},
+		[]string{"env", "quota", "reason"},
+	).MustCurryWith(prometheus.Labels{"env": env})
+
+	metrics.quotaRunningQueries = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trino_gateway_router_quota_running_queries",
+			Help: "Queries counted against the quota by this gateway instance and not completed yet.",
+		},
+		[]string{"env", "quota"},
+	).MustCurryWith(prometheus.Labels{"env": env})
+
+	metrics.submissionRetriesTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "trino_gateway_router_submission_retries_total",
+			Help: "Number of queries resubmitted to another backend after the backend failed them before starting.",
+		},
+		[]string{"env", "group", "backend", "reason"},
+	).MustCurryWith(prometheus.Labels{"env": env})
+
+	metrics.backendOpenConnections = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trino_gateway_router_backend_open_connections",
+			Help: "Connections open to the backend in its pool, idle or in use.",
+		},
+		[]string{"env", "backend"},
+	).MustCurryWith(prometheus.Labels{"env": env})
+
+	metrics.backendInFlightRequests = promauto.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "trino_gateway_router_backend_in_flight_requests",
+			Help: "Requests sent to the backend whose responses aren't fully read yet.",
+		},
+		[]string{"env", "backend"},
+	).MustCurryWith(prometheus.Labels{"env": env})
+
+	metrics.backendConnectionsAcquiredTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "trino_gateway_router_backend_connections_acquired_total",
+			Help: "Number of connections acquired from the pool of the backend for requests, by whether an idle connection was reused.",
+		},
+		[]string{"env", "backend", "reused"},
+	).MustCurryWith(prometheus.Labels{"env": env})
+
+	metrics.authExemptionsUsedTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "trino_gateway_router_auth_exemptions_used_total",
+			Help: "Number of client requests which bypassed authentication, by the auth exemption they matched.",
+		},
+		[]string{"env", "exemption", "port"},
+	).MustCurryWith(prometheus.Labels{"env": env})
+
+	metrics.authCacheLookupsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "trino_gateway_router_auth_cache_lookups_total",
+			Help: "Number of lookups of client credentials in the auth cache, by outcome: hit, negative_hit or miss.",
+		},
+		[]string{"env", "authenticator", "outcome"},
+	).MustCurryWith(prometheus.Labels{"env": env})
 }
@@ -0,0 +1,131 @@
+// Package passivehealth detects unhealthy backends from the outcome of requests
+// proxied to them, complementing the periodic probes of the monitor.
+package passivehealth
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Number of buckets the sliding window is split into
+const windowBuckets = 10
+
+type Config struct {
+	// period over which request outcomes are considered
+	Window time.Duration
+	// minimum requests in window before error rate is evaluated
+	MinRequests int
+	// fraction of requests failing with connection errors or 5xx, in (0, 1]
+	ErrorRateThreshold float64
+	// connection errors in window which eject the backend irrespective of the error rate
+	ConnErrorThreshold int
+}
+
+type bucket struct {
+	start      time.Time
+	total      int
+	errors     int
+	connErrors int
+}
+
+type backendWindow struct {
+	buckets   [windowBuckets]bucket
+	ejectedAt time.Time
+}
+
+// Tracker tracks outcome of requests per backend over a sliding window
+type Tracker struct {
+	cfg Config
+	mu  sync.Mutex
+	// keyed by backend id
+	windows map[string]*backendWindow
+}
+
+// NewTracker returns a tracker of the config, a window of 0 disables it. Windows shorter than
+// windowBuckets nanoseconds are raised to it, so that buckets are at least 1ns long.
+func NewTracker(cfg Config) *Tracker {
+	if cfg.Window > 0 && cfg.Window < windowBuckets {
+		cfg.Window = windowBuckets
+	}
+	return &Tracker{cfg: cfg, windows: make(map[string]*backendWindow)}
+}
+
+// RecordResponse records the status of response sent by backend, returns the reason
+// if the backend is to be ejected from routing, empty otherwise.
+func (t *Tracker) RecordResponse(backendId string, status int, now time.Time) string {
+	return t.record(backendId, status >= http.StatusInternalServerError, false, now)
+}
+
+// RecordConnError records failure in connecting to the backend or reading its response,
+// returns the reason if the backend is to be ejected from routing, empty otherwise.
+func (t *Tracker) RecordConnError(backendId string, now time.Time) string {
+	return t.record(backendId, true, true, now)
+}
+
+func (t *Tracker) record(backendId string, isErr bool, isConnErr bool, now time.Time) string {
+	if backendId == "" || t.cfg.Window <= 0 {
+		return ""
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	w, ok := t.windows[backendId]
+	if !ok {
+		w = &backendWindow{}
+		t.windows[backendId] = w
+	}
+
+	bucketSize := t.cfg.Window / windowBuckets
+	start := now.Truncate(bucketSize)
+	b := &w.buckets[(start.UnixNano()/int64(bucketSize))%windowBuckets]
+	if !b.start.Equal(start) {
+		*b = bucket{start: start}
+	}
+	b.total++
+	if isErr {
+		b.errors++
+	}
+	if isConnErr {
+		b.connErrors++
+	}
+
+	// backend already ejected, recovery is up to the monitor
+	if now.Sub(w.ejectedAt) < t.cfg.Window {
+		return ""
+	}
+
+	var total, errors, connErrors int
+	for _, b := range w.buckets {
+		if now.Sub(b.start) < t.cfg.Window {
+			total += b.total
+			errors += b.errors
+			connErrors += b.connErrors
+		}
+	}
+
+	reason := ""
+	if t.cfg.ConnErrorThreshold > 0 && connErrors >= t.cfg.ConnErrorThreshold {
+		reason = fmt.Sprintf("%d connection errors in last %s", connErrors, t.cfg.Window)
+	} else if t.cfg.ErrorRateThreshold > 0 && total >= t.cfg.MinRequests &&
+		float64(errors) >= t.cfg.ErrorRateThreshold*float64(total) {
+		reason = fmt.Sprintf("%d of %d requests failed in last %s", errors, total, t.cfg.Window)
+	}
+	if reason != "" {
+		w.ejectedAt = now
+		w.buckets = [windowBuckets]bucket{}
+	}
+	return reason
+}
+
+// Retain removes windows of backends other than the given ones, e.g. of deleted backends
+func (t *Tracker) Retain(backendIds map[string]bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id := range t.windows {
+		if !backendIds[id] {
+			delete(t.windows, id)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package passivehealth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_trackerErrorRate(t *testing.T) {
+	tr := NewTracker(Config{Window: time.Minute, MinRequests: 4, ErrorRateThreshold: 0.5})
+	now := time.Unix(1700000000, 0)
+
+	assert.Empty(t, tr.RecordResponse("b1", 200, now))
+	assert.Empty(t, tr.RecordResponse("b1", 503, now))
+	// below min requests
+	assert.Empty(t, tr.RecordResponse("b1", 502, now.Add(time.Second)))
+	// other backends are tracked separately
+	assert.Empty(t, tr.RecordResponse("b2", 500, now))
+	assert.Equal(t, "3 of 4 requests failed in last 1m0s", tr.RecordResponse("b1", 500, now.Add(2*time.Second)))
+
+	// not ejected again till the window elapses
+	for i := 0; i < 4; i++ {
+		assert.Empty(t, tr.RecordResponse("b1", 500, now.Add(10*time.Second)))
+	}
+	assert.NotEmpty(t, tr.RecordResponse("b1", 500, now.Add(63*time.Second)))
+}
+
+func Test_trackerSlidingWindow(t *testing.T) {
+	tr := NewTracker(Config{Window: time.Minute, MinRequests: 2, ErrorRateThreshold: 0.5})
+	now := time.Unix(1700000000, 0)
+
+	assert.Empty(t, tr.RecordResponse("b1", 500, now))
+	// earlier failure has slid out of the window
+	assert.Empty(t, tr.RecordResponse("b1", 200, now.Add(90*time.Second)))
+	assert.Empty(t, tr.RecordResponse("b1", 200, now.Add(91*time.Second)))
+	assert.Empty(t, tr.RecordResponse("b1", 200, now.Add(92*time.Second)))
+	assert.Empty(t, tr.RecordResponse("b1", 500, now.Add(93*time.Second)))
+}
+
+func Test_trackerConnErrors(t *testing.T) {
+	tr := NewTracker(Config{Window: time.Minute, MinRequests: 100, ErrorRateThreshold: 0.5, ConnErrorThreshold: 2})
+	now := time.Unix(1700000000, 0)
+
+	assert.Empty(t, tr.RecordConnError("b1", now))
+	assert.Empty(t, tr.RecordResponse("b1", 200, now))
+	assert.Equal(t, "2 connection errors in last 1m0s", tr.RecordConnError("b1", now.Add(time.Second)))
+
+	// disabled
+	assert.Empty(t, NewTracker(Config{}).RecordConnError("b1", now))
+}
+
+func Test_trackerTinyWindow(t *testing.T) {
+	// windows too short to be split into buckets are raised to 1ns buckets instead of panicking
+	tr := NewTracker(Config{Window: 5 * time.Nanosecond, ConnErrorThreshold: 1})
+	now := time.Unix(1700000000, 0)
+	assert.NotEmpty(t, tr.RecordConnError("b1", now))
+
+	// disabled windows don't track anything
+	tr = NewTracker(Config{Window: 0, ConnErrorThreshold: 1})
+	assert.Empty(t, tr.RecordConnError("b1", now))
+}
+
+func Test_trackerRetain(t *testing.T) {
+	tr := NewTracker(Config{Window: time.Minute, ConnErrorThreshold: 2})
+	now := time.Unix(1700000000, 0)
+
+	assert.Empty(t, tr.RecordConnError("b1", now))
+	assert.Empty(t, tr.RecordConnError("b2", now))
+	tr.Retain(map[string]bool{"b2": true})
+	assert.Len(t, tr.windows, 1)
+
+	// window of the removed backend starts afresh if it is added again
+	assert.Empty(t, tr.RecordConnError("b1", now))
+	assert.NotEmpty(t, tr.RecordConnError("b2", now))
+}
@@ -0,0 +1,63 @@
+package queryresults
+
+import (
+	"net/http"
+)
+
+// GatewayError is an error of the gateway sent to clients as a failed Trino QueryResults document,
+// so clients surface it like errors of Trino & can decide on retrying as per its type.
+type GatewayError struct {
+	// codes start at 0x0700_0000 so they don't clash with codes of Trino & its connectors
+	Code int
+	Name string
+	// Trino error type - USER_ERROR, INTERNAL_ERROR, INSUFFICIENT_RESOURCES or EXTERNAL
+	ErrorType string
+	// status of responses to requests other than query submissions
+	HttpStatus int
+}
+
+var (
+	ErrGatewayRequestInvalid = &GatewayError{
+		Code: 0x0700_0000, Name: "GATEWAY_REQUEST_INVALID", ErrorType: "USER_ERROR", HttpStatus: http.StatusBadRequest,
+	}
+	ErrGatewayAuthFailed = &GatewayError{
+		Code: 0x0700_0001, Name: "GATEWAY_AUTH_FAILED", ErrorType: "USER_ERROR", HttpStatus: http.StatusUnauthorized,
+	}
+	ErrGatewayAuthUnavailable = &GatewayError{
+		Code: 0x0700_0002, Name: "GATEWAY_AUTH_UNAVAILABLE", ErrorType: "EXTERNAL", HttpStatus: http.StatusServiceUnavailable,
+	}
+	ErrGatewayQueryNotFound = &GatewayError{
+		Code: 0x0700_0003, Name: "GATEWAY_QUERY_NOT_FOUND", ErrorType: "USER_ERROR", HttpStatus: http.StatusNotFound,
+	}
+	ErrGatewayNoBackend = &GatewayError{
+		Code: 0x0700_0004, Name: "GATEWAY_NO_BACKEND", ErrorType: "INSUFFICIENT_RESOURCES", HttpStatus: http.StatusServiceUnavailable,
+	}
+	ErrGatewayBackendUnreachable = &GatewayError{
+		Code: 0x0700_0005, Name: "GATEWAY_BACKEND_UNREACHABLE", ErrorType: "EXTERNAL", HttpStatus: http.StatusBadGateway,
+	}
+	ErrGatewayInvalidBackendResponse = &GatewayError{
+		Code: 0x0700_0006, Name: "GATEWAY_INVALID_BACKEND_RESPONSE", ErrorType: "INTERNAL_ERROR", HttpStatus: http.StatusBadGateway,
+	}
+)
+
+// WriteGatewayError fails the client request with the gateway error, returning the HTTP status sent.
+// Query submissions are failed with HTTP 200 as done by Trino, so clients show the error. Other
+// requests are failed with status of the error, so clients retry polling queries on transient errors.
+func WriteGatewayError(w http.ResponseWriter, req *http.Request, baseUrl string, queryId string, e *GatewayError, msg string) int {
+	status := e.HttpStatus
+	if req.Method == http.MethodPost && req.URL.Path == "/v1/statement" {
+		status = http.StatusOK
+	}
+	Write(w, status, &Results{
+		Id:      queryId,
+		InfoUri: InfoUri(baseUrl, queryId),
+		Stats:   Stats{State: "FAILED"},
+		Error: &Error{
+			Message:   msg,
+			ErrorCode: e.Code,
+			ErrorName: e.Name,
+			ErrorType: e.ErrorType,
+		},
+	})
+	return status
+}
@@ -0,0 +1,41 @@
+package queryresults
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WriteGatewayError(t *testing.T) {
+	// submissions are failed as done by Trino
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "http://gateway:8080/v1/statement", nil)
+	assert.Equal(t, http.StatusOK, WriteGatewayError(w, req, "http://gateway:8080", "", ErrGatewayNoBackend, "no backend"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var res Results
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, "FAILED", res.Stats.State)
+	assert.Equal(t, "", res.NextUri)
+	assert.Equal(t, &Error{
+		Message:   "no backend",
+		ErrorCode: 0x0700_0004,
+		ErrorName: "GATEWAY_NO_BACKEND",
+		ErrorType: "INSUFFICIENT_RESOURCES",
+	}, res.Error)
+
+	// polls retain status of the error
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "http://gateway:8080/v1/statement/executing/20230101_000000_00001_abcde/y1234/2", nil)
+	assert.Equal(t, http.StatusBadGateway, WriteGatewayError(w, req, "http://gateway:8080", "20230101_000000_00001_abcde", ErrGatewayBackendUnreachable, "unreachable"))
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	res = Results{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, "20230101_000000_00001_abcde", res.Id)
+	assert.Equal(t, "http://gateway:8080/ui/query.html?20230101_000000_00001_abcde", res.InfoUri)
+	assert.Equal(t, "GATEWAY_BACKEND_UNREACHABLE", res.Error.ErrorName)
+}
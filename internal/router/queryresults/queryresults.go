@@ -0,0 +1,55 @@
+// Package queryresults writes Trino QueryResults documents for queries served by the gateway,
+// i.e. queries queued at the gateway & requests failed by it.
+package queryresults
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Results is the Trino QueryResults document for queries served by the gateway
+type Results struct {
+	Id      string `json:"id"`
+	InfoUri string `json:"infoUri"`
+	NextUri string `json:"nextUri,omitempty"`
+	Stats   Stats  `json:"stats"`
+	Error   *Error `json:"error,omitempty"`
+}
+
+type Stats struct {
+	State             string `json:"state"`
+	Queued            bool   `json:"queued"`
+	Scheduled         bool   `json:"scheduled"`
+	Nodes             int    `json:"nodes"`
+	TotalSplits       int    `json:"totalSplits"`
+	QueuedSplits      int    `json:"queuedSplits"`
+	RunningSplits     int    `json:"runningSplits"`
+	CompletedSplits   int    `json:"completedSplits"`
+	CpuTimeMillis     int64  `json:"cpuTimeMillis"`
+	WallTimeMillis    int64  `json:"wallTimeMillis"`
+	QueuedTimeMillis  int64  `json:"queuedTimeMillis"`
+	ElapsedTimeMillis int64  `json:"elapsedTimeMillis"`
+	ProcessedRows     int64  `json:"processedRows"`
+	ProcessedBytes    int64  `json:"processedBytes"`
+	PeakMemoryBytes   int64  `json:"peakMemoryBytes"`
+}
+
+type Error struct {
+	Message   string `json:"message"`
+	ErrorCode int    `json:"errorCode"`
+	ErrorName string `json:"errorName"`
+	ErrorType string `json:"errorType"`
+}
+
+// InfoUri is the Trino UI page of the query as linked by results of the gateway
+func InfoUri(baseUrl string, queryId string) string {
+	return fmt.Sprintf("%s/ui/query.html?%s", baseUrl, queryId)
+}
+
+func Write(w http.ResponseWriter, status int, res *Results) {
+	b, _ := json.Marshal(res)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(b)
+}
@@ -0,0 +1,124 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/razorpay/trino-gateway/internal/provider"
+	"github.com/razorpay/trino-gateway/internal/router/queryresults"
+	"github.com/razorpay/trino-gateway/internal/router/quota"
+	"github.com/razorpay/trino-gateway/internal/router/trinoheaders"
+)
+
+// Trino error code for queries rejected by quotas of the gateway
+const errorCodeQueryRejected = 31
+
+type quotaLeaseKey struct{}
+
+func withQuotaLease(req *http.Request, lease *quota.Lease) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), quotaLeaseKey{}, lease))
+}
+
+func quotaLeaseFromRequest(req *http.Request) *quota.Lease {
+	lease, _ := req.Context().Value(quotaLeaseKey{}).(*quota.Lease)
+	return lease
+}
+
+// QuotaHandler rejects query submissions exceeding the quotas applicable to the client,
+// before they are routed or queued at the gateway.
+func (r *RouterServer) QuotaHandler(ctx *context.Context, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if r.quota == nil || req.Method != http.MethodPost || req.URL.Path != "/v1/statement" {
+			h.ServeHTTP(w, req)
+			return
+		}
+		quotas := r.routingSnapshot.getQuotas()
+		if len(quotas) == 0 {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		client := r.quotaClient(req)
+		lease, violation := r.quota.Acquire(quotas, client, time.Now())
+		if violation != nil {
+			metrics.quotaRejectionsTotal.WithLabelValues(violation.QuotaId, violation.Reason.String()).Inc()
+			provider.Logger(*ctx).Infow(fmt.Sprint(LOG_TAG, "query rejected by quota"), map[string]interface{}{
+				"quota_id": violation.QuotaId,
+				"reason":   violation.Reason.String(),
+				"user":     client.User,
+				"source":   client.Source,
+			})
+			r.writeFailedResults(w, req, "", &queryresults.Error{
+				Message:   violation.Error(),
+				ErrorCode: errorCodeQueryRejected,
+				ErrorName: "QUERY_REJECTED",
+				ErrorType: "USER_ERROR",
+			})
+			return
+		}
+		r.observeQuotaRunning()
+		h.ServeHTTP(w, withQuotaLease(req, lease))
+	})
+}
+
+// quotaClient returns attributes of the client request quotas are keyed on, source set by
+// the policy of the listening port takes precedence over the one sent by the client.
+func (r *RouterServer) quotaClient(req *http.Request) *quota.Client {
+	source := trinoheaders.Get(trinoheaders.Source, req)
+	if snapshot := r.routingSnapshot.get(); snapshot != nil {
+		if s := snapshot.RequestSource(int32(r.port)); s != "" {
+			source = s
+		}
+	}
+	return &quota.Client{
+		User:          trinoheaders.Get(trinoheaders.User, req),
+		Source:        source,
+		ClientTags:    quota.ParseClientTags(trinoheaders.Get(trinoheaders.ClientTags, req)),
+		ListeningPort: int32(r.port),
+	}
+}
+
+// bindQuotaLease associates lease of the query with its id on the backend,
+// releasing it if the backend didn't accept the query.
+func (r *RouterServer) bindQuotaLease(req *http.Request, cReq ClientRequest, status int) {
+	lease := quotaLeaseFromRequest(req)
+	if lease == nil {
+		return
+	}
+	if q, ok := cReq.(*QueryRequest); ok && status == http.StatusOK && q.Query.GetId() != "" && !isTerminalQueryState(q.Query.GetState()) {
+		r.quota.Bind(lease, q.Query.GetId(), time.Now())
+		return
+	}
+	r.releaseQuotaLease(lease)
+}
+
+func (r *RouterServer) releaseQuotaLease(lease *quota.Lease) {
+	if lease == nil {
+		return
+	}
+	r.quota.Release(lease)
+	r.observeQuotaRunning()
+}
+
+// trackQuotaQuery releases lease of the query once it completes, queries without
+// activity release their leases on `gateway.quota.clientTimeout`.
+func (r *RouterServer) trackQuotaQuery(queryId string, terminal bool) {
+	if r.quota == nil {
+		return
+	}
+	if !terminal {
+		r.quota.Touch(queryId, time.Now())
+		return
+	}
+	if r.quota.ReleaseQuery(queryId) {
+		r.observeQuotaRunning()
+	}
+}
+
+func (r *RouterServer) observeQuotaRunning() {
+	for quotaId, running := range r.quota.Running() {
+		metrics.quotaRunningQueries.WithLabelValues(quotaId).Set(float64(running))
+	}
+}
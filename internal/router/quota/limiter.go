@@ -0,0 +1,301 @@
+// Package quota limits the rate of query submissions & queries running concurrently
+// for users, sources, client tags & listening ports of client requests.
+package quota
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Key types of quotas, as in the key_type enum of quotas table
+const (
+	KeyUser          = "user"
+	KeySource        = "source"
+	KeyClientTag     = "client_tag"
+	KeyListeningPort = "listening_port"
+)
+
+// EachValue as key value applies the limits of the quota to each distinct value of the key separately
+const EachValue = "*"
+
+// Interval at which abandoned leases & idle counters are cleaned up
+const cleanupInterval = time.Second
+
+type Quota struct {
+	ID       string
+	KeyType  string
+	KeyValue string
+	// 0 is unlimited
+	QueriesPerMinute int
+	// 0 is unlimited
+	MaxConcurrentQueries int
+}
+
+// Client has the attributes of the client request quotas are keyed on
+type Client struct {
+	User          string
+	Source        string
+	ClientTags    []string
+	ListeningPort int32
+}
+
+// values returns values of the key of quota for the client request
+func (c *Client) values(keyType string) []string {
+	switch keyType {
+	case KeyUser:
+		return []string{c.User}
+	case KeySource:
+		return []string{c.Source}
+	case KeyClientTag:
+		return c.ClientTags
+	case KeyListeningPort:
+		return []string{strconv.Itoa(int(c.ListeningPort))}
+	}
+	return nil
+}
+
+// counterKeys returns distinct keys of counters of the quota applicable to the client request,
+// none if it doesn't apply. A value repeated by the client, e.g. client tags `etl,etl`, counts once.
+func (q *Quota) counterKeys(c *Client) []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, v := range c.values(q.KeyType) {
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		if q.KeyValue == EachValue || q.KeyValue == v {
+			keys = append(keys, fmt.Sprintf("%s\x00%s", q.ID, v))
+		}
+	}
+	return keys
+}
+
+type Reason int
+
+const (
+	RateExceeded Reason = iota
+	ConcurrencyExceeded
+)
+
+func (r Reason) String() string {
+	if r == RateExceeded {
+		return "rate"
+	}
+	return "concurrency"
+}
+
+// Violation is the quota the client request exceeded
+type Violation struct {
+	QuotaId string
+	Reason  Reason
+	Limit   int
+}
+
+func (v *Violation) Error() string {
+	if v.Reason == RateExceeded {
+		return fmt.Sprintf("Query rate limit of quota %s exceeded, max %d queries per minute", v.QuotaId, v.Limit)
+	}
+	return fmt.Sprintf("Concurrency limit of quota %s exceeded, max %d running queries", v.QuotaId, v.Limit)
+}
+
+// counter is the token bucket & running queries for a value of the key of a quota
+type counter struct {
+	quotaId    string
+	tokens     float64
+	lastRefill time.Time
+	running    int
+}
+
+func (c *counter) refill(queriesPerMinute int, now time.Time) {
+	if queriesPerMinute <= 0 {
+		return
+	}
+	capacity := float64(queriesPerMinute)
+	c.tokens += now.Sub(c.lastRefill).Seconds() * capacity / 60
+	if c.tokens > capacity {
+		c.tokens = capacity
+	}
+	c.lastRefill = now
+}
+
+// Lease is held by a query admitted by the limiter till it completes
+type Lease struct {
+	counters []*counter
+	queryId  string
+	lastSeen time.Time
+	released bool
+}
+
+// Limiter tracks counters of all quotas
+type Limiter struct {
+	// leases not seen for this long are considered abandoned by the client
+	clientTimeout time.Duration
+
+	mu           sync.Mutex
+	counters     map[string]*counter
+	leases       map[*Lease]struct{}
+	leaseByQuery map[string]*Lease
+	lastCleanup  time.Time
+}
+
+func NewLimiter(clientTimeout time.Duration) *Limiter {
+	return &Limiter{
+		clientTimeout: clientTimeout,
+		counters:      make(map[string]*counter),
+		leases:        make(map[*Lease]struct{}),
+		leaseByQuery:  make(map[string]*Lease),
+	}
+}
+
+// Acquire admits the query if none of the quotas applicable to the client request are exceeded,
+// consuming a query from the rate of each of them. Admitted queries hold the lease till they complete.
+func (l *Limiter) Acquire(quotas []Quota, c *Client, now time.Time) (*Lease, *Violation) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cleanup(now)
+
+	type applicable struct {
+		quota   *Quota
+		counter *counter
+	}
+	var matched []applicable
+	for i := range quotas {
+		q := &quotas[i]
+		for _, key := range q.counterKeys(c) {
+			ctr, ok := l.counters[key]
+			if !ok {
+				ctr = &counter{quotaId: q.ID, tokens: float64(q.QueriesPerMinute), lastRefill: now}
+				l.counters[key] = ctr
+			}
+			ctr.refill(q.QueriesPerMinute, now)
+			if q.QueriesPerMinute > 0 && ctr.tokens < 1 {
+				return nil, &Violation{QuotaId: q.ID, Reason: RateExceeded, Limit: q.QueriesPerMinute}
+			}
+			if q.MaxConcurrentQueries > 0 && ctr.running >= q.MaxConcurrentQueries {
+				return nil, &Violation{QuotaId: q.ID, Reason: ConcurrencyExceeded, Limit: q.MaxConcurrentQueries}
+			}
+			matched = append(matched, applicable{quota: q, counter: ctr})
+		}
+	}
+
+	lease := &Lease{lastSeen: now}
+	for _, m := range matched {
+		if m.quota.QueriesPerMinute > 0 {
+			m.counter.tokens--
+		}
+		m.counter.running++
+		lease.counters = append(lease.counters, m.counter)
+	}
+	l.leases[lease] = struct{}{}
+	return lease, nil
+}
+
+// Bind associates the lease with id of the query on the backend, for releasing it by query id
+func (l *Limiter) Bind(lease *Lease, queryId string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if lease.released {
+		return
+	}
+	lease.queryId = queryId
+	lease.lastSeen = now
+	l.leaseByQuery[queryId] = lease
+}
+
+// Touch records activity of the query, leases without activity are released
+// once the client timeout elapses.
+func (l *Limiter) Touch(queryId string, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if lease, ok := l.leaseByQuery[queryId]; ok {
+		lease.lastSeen = now
+	}
+}
+
+// TouchLease records activity of a query not bound to a query id yet, e.g. queued at the gateway
+func (l *Limiter) TouchLease(lease *Lease, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lease.lastSeen = now
+}
+
+func (l *Limiter) Release(lease *Lease) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.release(lease)
+}
+
+// ReleaseQuery releases lease held by the query, returns false if it doesn't hold one
+func (l *Limiter) ReleaseQuery(queryId string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lease, ok := l.leaseByQuery[queryId]
+	if ok {
+		l.release(lease)
+	}
+	return ok
+}
+
+// Running returns number of running queries of each quota, summed across values of its key
+func (l *Limiter) Running() map[string]int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	res := make(map[string]int)
+	for _, c := range l.counters {
+		res[c.quotaId] += c.running
+	}
+	return res
+}
+
+func (l *Limiter) release(lease *Lease) {
+	if lease.released {
+		return
+	}
+	lease.released = true
+	for _, c := range lease.counters {
+		c.running--
+	}
+	delete(l.leases, lease)
+	if lease.queryId != "" {
+		delete(l.leaseByQuery, lease.queryId)
+	}
+}
+
+// cleanup releases leases abandoned by clients & removes counters which don't limit any query
+func (l *Limiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < cleanupInterval {
+		return
+	}
+	l.lastCleanup = now
+
+	if l.clientTimeout > 0 {
+		for lease := range l.leases {
+			if now.Sub(lease.lastSeen) > l.clientTimeout {
+				l.release(lease)
+			}
+		}
+	}
+	// buckets refill completely within a minute, counters idle for longer are same as new ones
+	for key, c := range l.counters {
+		if c.running == 0 && now.Sub(c.lastRefill) > time.Minute {
+			delete(l.counters, key)
+		}
+	}
+}
+
+// ParseClientTags splits the comma separated client tags header into distinct tags
+func ParseClientTags(header string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, t := range strings.Split(header, ",") {
+		if t = strings.TrimSpace(t); t != "" && !seen[t] {
+			seen[t] = true
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,84 @@
+package quota
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_limiterRate(t *testing.T) {
+	l := NewLimiter(time.Minute)
+	now := time.Unix(1700000000, 0)
+	quotas := []Quota{{ID: "per-user", KeyType: KeyUser, KeyValue: EachValue, QueriesPerMinute: 2}}
+	alice := &Client{User: "alice"}
+
+	_, v := l.Acquire(quotas, alice, now)
+	assert.Nil(t, v)
+	_, v = l.Acquire(quotas, alice, now)
+	assert.Nil(t, v)
+	_, v = l.Acquire(quotas, alice, now)
+	assert.Equal(t, &Violation{QuotaId: "per-user", Reason: RateExceeded, Limit: 2}, v)
+
+	// each user is limited separately
+	_, v = l.Acquire(quotas, &Client{User: "bob"}, now)
+	assert.Nil(t, v)
+
+	// a query is refilled every 30s
+	_, v = l.Acquire(quotas, alice, now.Add(30*time.Second))
+	assert.Nil(t, v)
+	_, v = l.Acquire(quotas, alice, now.Add(31*time.Second))
+	assert.NotNil(t, v)
+}
+
+func Test_limiterConcurrency(t *testing.T) {
+	l := NewLimiter(time.Minute)
+	now := time.Unix(1700000000, 0)
+	quotas := []Quota{
+		{ID: "notebooks", KeyType: KeySource, KeyValue: "jupyter", MaxConcurrentQueries: 1},
+		{ID: "bi-tag", KeyType: KeyClientTag, KeyValue: "bi", MaxConcurrentQueries: 5},
+	}
+	client := &Client{User: "alice", Source: "jupyter", ClientTags: ParseClientTags("adhoc, bi")}
+
+	lease, v := l.Acquire(quotas, client, now)
+	assert.Nil(t, v)
+	assert.Equal(t, map[string]int{"notebooks": 1, "bi-tag": 1}, l.Running())
+
+	_, v = l.Acquire(quotas, client, now)
+	assert.Equal(t, ConcurrencyExceeded, v.Reason)
+	assert.Equal(t, "notebooks", v.QuotaId)
+	// rejected queries don't count against other quotas
+	assert.Equal(t, map[string]int{"notebooks": 1, "bi-tag": 1}, l.Running())
+
+	// quotas not matching the client request don't apply
+	_, v = l.Acquire(quotas, &Client{Source: "cli"}, now)
+	assert.Nil(t, v)
+
+	l.Bind(lease, "q1", now)
+	assert.True(t, l.ReleaseQuery("q1"))
+	assert.False(t, l.ReleaseQuery("q1"))
+	lease, v = l.Acquire(quotas, client, now)
+	assert.Nil(t, v)
+
+	// leases of queries abandoned by clients are released
+	l.TouchLease(lease, now.Add(30*time.Second))
+	_, v = l.Acquire(quotas, client, now.Add(80*time.Second))
+	assert.NotNil(t, v)
+	_, v = l.Acquire(quotas, client, now.Add(100*time.Second))
+	assert.Nil(t, v)
+}
+
+func Test_limiterDuplicateClientTags(t *testing.T) {
+	l := NewLimiter(time.Minute)
+	now := time.Unix(1700000000, 0)
+	quotas := []Quota{{ID: "etl-tag", KeyType: KeyClientTag, KeyValue: EachValue, QueriesPerMinute: 2, MaxConcurrentQueries: 2}}
+	assert.Equal(t, []string{"etl", "bi"}, ParseClientTags("etl, bi,etl"))
+
+	// repeated tags count once, even if not parsed from the header
+	_, v := l.Acquire(quotas, &Client{ClientTags: []string{"etl", "etl"}}, now)
+	assert.Nil(t, v)
+	assert.Equal(t, map[string]int{"etl-tag": 1}, l.Running())
+	_, v = l.Acquire(quotas, &Client{ClientTags: []string{"etl", "etl"}}, now)
+	assert.Nil(t, v)
+	assert.Equal(t, map[string]int{"etl-tag": 2}, l.Running())
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"regexp"
 	"strings"
 
 	"github.com/razorpay/trino-gateway/internal/provider"
+	"github.com/razorpay/trino-gateway/internal/router/session"
+	"github.com/razorpay/trino-gateway/internal/router/sqlinspect"
 	"github.com/razorpay/trino-gateway/internal/router/trinoheaders"
+	"github.com/razorpay/trino-gateway/internal/routing"
 	"github.com/razorpay/trino-gateway/internal/utils"
 	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
 )
@@ -70,9 +74,6 @@ func constructQueryFromReq(ctx *context.Context, req *http.Request) (string, err
 			map[string]interface{}{
 				"body": body,
 			})
-		preparedStmt := trinoheaders.Get(trinoheaders.PreparedStatement, req)
-		// TODO
-		_ = preparedStmt
 		return body, nil
 
 	}
@@ -86,6 +87,61 @@ func constructQueryFromReq(ctx *context.Context, req *http.Request) (string, err
 	)
 }
 
+// Extracts queryId from the path of uris returned by trino for a query, supported formats -
+// /v1/statement/queued/{queryId}/{slug}/{token}
+// /v1/statement/executing/{queryId}/{slug}/{token}
+// /v1/statement/executing/partialCancel/{queryId}/{stage}/{slug}/{token}
+// /v1/statement/{queryId}/{token}
+// /v1/stage/{queryId}.{stage}
+func extractQueryIdFromNextUri(path string) (queryId string, isPartialCancel bool) {
+	if strings.HasPrefix(path, "/v1/stage/") {
+		stageId := strings.TrimPrefix(path, "/v1/stage/")
+		if i := strings.LastIndex(stageId, "."); i > 0 {
+			return stageId[:i], true
+		}
+		return stageId, true
+	}
+	parts := strings.Split(strings.TrimPrefix(path, "/v1/statement/"), "/")
+	if len(parts) > 2 && parts[0] == "executing" && parts[1] == "partialCancel" {
+		return parts[2], true
+	}
+	if len(parts) > 1 && (parts[0] == "queued" || parts[0] == "executing") {
+		return parts[1], false
+	}
+	return parts[0], false
+}
+
+func newNextUriRequest(req *http.Request) *NextUriRequest {
+	queryId, isPartialCancel := extractQueryIdFromNextUri(req.URL.Path)
+	return &NextUriRequest{
+		isCancel:        req.Method == "DELETE" && !isPartialCancel,
+		isPartialCancel: isPartialCancel,
+		sessionKey:      sessionKeyFromRequest(req),
+		Query: &gatewayv1.Query{
+			Id:       queryId,
+			Username: trinoheaders.Get(trinoheaders.User, req),
+			ClientIp: req.RemoteAddr,
+		},
+	}
+}
+
+// serverName returns the server name sent by the client in the TLS handshake, empty for plain HTTP
+func serverName(req *http.Request) string {
+	if req.TLS == nil {
+		return ""
+	}
+	return req.TLS.ServerName
+}
+
+// clientIp returns the ip of the client connected to the router
+func clientIp(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
 func (r *RouterServer) ParseClientRequest(ctx *context.Context, req *http.Request) (cReq ClientRequest, err error) {
 	if req.Method == "GET" {
 		if strings.Contains(req.URL.Path, "ui/") {
@@ -95,6 +151,8 @@ func (r *RouterServer) ParseClientRequest(ctx *context.Context, req *http.Reques
 		} else if strings.Contains(req.URL.Path, "v1/info") ||
 			strings.Contains(req.URL.Path, "v1/status") {
 			return &ApiRequest{}, nil
+		} else if strings.HasPrefix(req.URL.Path, "/v1/statement/") {
+			return newNextUriRequest(req), nil
 		}
 	} else if req.Method == "POST" {
 
@@ -108,7 +166,25 @@ func (r *RouterServer) ParseClientRequest(ctx *context.Context, req *http.Reques
 			Id:       queryId,
 			Text:     qText,
 			Username: trinoheaders.Get(trinoheaders.User, req),
-			ClientIp: req.RemoteAddr,
+			// user the query is submitted by if it impersonates the Username
+			Principal: principalFromRequest(req),
+			ClientIp:  req.RemoteAddr,
+		}
+
+		// statements executing a prepared statement are routed as per the prepared statement
+		sessionKey := sessionKeyFromRequest(req)
+		inspectedText := qText
+		var replayState *session.State
+		var preparedRouting *session.Routing
+		if name, ok := sqlinspect.PreparedStatementName(qText); ok {
+			if stmt, state, routing, ok := r.resolvePreparedStatement(req, sessionKey, name); ok {
+				inspectedText, replayState, preparedRouting = stmt, state, routing
+			}
+		}
+		catalog := trinoheaders.Get(trinoheaders.Catalog, req)
+		schema := trinoheaders.Get(trinoheaders.Schema, req)
+		if catalog == "" && replayState != nil {
+			catalog, schema = replayState.Catalog, replayState.Schema
 		}
 
 		return &QueryRequest{
@@ -118,6 +194,11 @@ func (r *RouterServer) ParseClientRequest(ctx *context.Context, req *http.Reques
 			transactionId:              trinoheaders.Get(trinoheaders.TransactionId, req),
 			Query:                      query,
 			clientHost:                 req.Host,
+			sni:                        serverName(req),
+			inspectedSql:               sqlinspect.Inspect(inspectedText, catalog, schema),
+			sessionKey:                 sessionKey,
+			replayState:                replayState,
+			preparedRouting:            preparedRouting,
 		}, nil
 	} else if req.Method == "DELETE" && strings.HasPrefix(req.URL.Path, "/v1/query") {
 		queryId := strings.TrimPrefix(req.URL.Path, "/v1/query/")
@@ -135,13 +216,18 @@ func (r *RouterServer) ParseClientRequest(ctx *context.Context, req *http.Reques
 			Query:                      query,
 			clientHost:                 req.Host,
 		}, nil
+	} else if req.Method == "DELETE" && (strings.HasPrefix(req.URL.Path, "/v1/statement/") ||
+		strings.HasPrefix(req.URL.Path, "/v1/stage/")) {
+		return newNextUriRequest(req), nil
 	}
 	return nil, errors.New("client request type not supported by gateway")
 }
 
 func (r *RouterServer) ProcessRequest(ctx *context.Context, req *http.Request) (cReq ClientRequest, err error) {
-	cReq, err = r.ParseClientRequest(ctx, req)
-	if err != nil {
+	if a := admittedRoutingFromRequest(req); a != nil && a.request != nil {
+		// parsed by the admission handler
+		cReq = a.request
+	} else if cReq, err = r.ParseClientRequest(ctx, req); err != nil {
 		return nil, errors.New(fmt.Sprint("unable to parse Trino Request - ", err.Error()))
 	}
 	provider.Logger(*ctx).Infow(
@@ -171,7 +257,7 @@ func (r *RouterServer) ProcessRequest(ctx *context.Context, req *http.Request) (
 			provider.Logger(*ctx).WithError(err).
 				Errorw("Backend Unresolvable for queryId extracted for Ui Request.",
 					map[string]interface{}{"queryId": nt.queryId})
-			return nil, err
+			return nil, fmt.Errorf("%w: %w", errQueryUnresolvable, err)
 		}
 		bId := findBackendIdResp.GetBackendId()
 		err = r.prepareReqForRouting(ctx, req, bId, nt)
@@ -182,6 +268,47 @@ func (r *RouterServer) ProcessRequest(ctx *context.Context, req *http.Request) (
 		return nt, nil
 
 	case *QueryRequest:
+		// statements of a transaction can only be executed on the backend it was started on,
+		// transactions are bound to backends only if clients poll queries via the gateway
+		if r.rewriteResponseUris && nt.inTransaction() {
+			findBackendIdResp, err := r.gatewayApiClient.Query.FindBackendForTransaction(
+				*ctx,
+				&gatewayv1.FindBackendForTransactionRequest{TransactionId: nt.transactionId},
+			)
+			if err != nil {
+				provider.Logger(*ctx).WithError(err).
+					Errorw("Backend Unresolvable for transaction of query.",
+						map[string]interface{}{"transactionId": nt.transactionId})
+				return nil, fmt.Errorf("%w: %w", errQueryUnresolvable, err)
+			}
+			// a transaction id doesn't authorize statements of other users on the transaction
+			if u := findBackendIdResp.GetUsername(); u != "" && u != nt.Query.GetUsername() {
+				provider.Logger(*ctx).Warnw("Transaction of query started by another user.",
+					map[string]interface{}{"transactionId": nt.transactionId, "username": nt.Query.GetUsername()})
+				return nil, fmt.Errorf("transaction %s wasn't started by user %s", nt.transactionId, nt.Query.GetUsername())
+			}
+			nt.Query.BackendId = findBackendIdResp.GetBackendId()
+			nt.Query.GroupId = findBackendIdResp.GetGroupId()
+			err = r.prepareReqForRouting(ctx, req, nt.Query.GetBackendId(), nt)
+			if err != nil {
+				return nil, err
+			}
+
+			return nt, nil
+		}
+
+		// statements executing a prepared statement the client didn't send back are executed where it was added
+		if nt.preparedRouting != nil {
+			nt.Query.BackendId = nt.preparedRouting.BackendId
+			nt.Query.GroupId = nt.preparedRouting.GroupId
+			err = r.prepareReqForRouting(ctx, req, nt.Query.GetBackendId(), nt)
+			if err != nil {
+				return nil, err
+			}
+
+			return nt, nil
+		}
+
 		if nt.Query.GetId() != "" {
 			findBackendIdResp, err := r.gatewayApiClient.Query.FindBackendForQuery(
 				*ctx,
@@ -202,12 +329,17 @@ func (r *RouterServer) ProcessRequest(ctx *context.Context, req *http.Request) (
 			return nt, nil
 		}
 
-		provider.Logger(*ctx).Debug(fmt.Sprint(LOG_TAG, "invoking routing backend evaluation"))
-
-		bId, gId, err := r.evaluateRoutingBackend(ctx, *nt)
+		var bId, gId string
+		if a := admittedRoutingFromRequest(req); a != nil {
+			// evaluated by the admission handler
+			bId, gId = a.backendId, a.groupId
+		} else {
+			provider.Logger(*ctx).Debug(fmt.Sprint(LOG_TAG, "invoking routing backend evaluation"))
+			bId, gId, err = r.evaluateRoutingBackend(ctx, *nt)
+		}
 		r.prepareReqForRouting(ctx, req, bId, nt)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %w", errBackendUnresolvable, err)
 		}
 		nt.Query.GroupId = gId
 		nt.Query.BackendId = bId
@@ -233,6 +365,24 @@ func (r *RouterServer) ProcessRequest(ctx *context.Context, req *http.Request) (
 			return nil, err
 		}
 		return nt, nil
+	case *NextUriRequest:
+		findBackendIdResp, err := r.gatewayApiClient.Query.FindBackendForQuery(
+			*ctx,
+			&gatewayv1.FindBackendForQueryRequest{QueryId: nt.Query.GetId()},
+		)
+		if err != nil {
+			provider.Logger(*ctx).WithError(err).
+				Errorw("Backend Unresolvable for nextUri of query.",
+					map[string]interface{}{"queryId": nt.Query.GetId()})
+			return nil, fmt.Errorf("%w: %w", errQueryUnresolvable, err)
+		}
+		nt.Query.BackendId = findBackendIdResp.GetBackendId()
+		nt.Query.GroupId = findBackendIdResp.GetGroupId()
+		err = r.prepareReqForRouting(ctx, req, nt.Query.GetBackendId(), nt)
+		if err != nil {
+			return nil, err
+		}
+		return nt, nil
 
 	default:
 		return nil, fmt.Errorf("unexpected type %T", nt)
@@ -243,8 +393,19 @@ func (r *RouterServer) evaluateRoutingBackend(ctx *context.Context, clientReq Qu
 	evalGrpReq := &gatewayv1.EvaluateGroupsRequest{
 		IncomingPort:               clientReq.incomingPort,
 		Host:                       clientReq.clientHost,
+		Sni:                        clientReq.sni,
 		HeaderConnectionProperties: clientReq.headerConnectionProperties,
 		HeaderClientTags:           clientReq.headerClientTags,
+		User:                       clientReq.Query.GetUsername(),
+	}
+	if clientReq.inspectedSql != nil {
+		evalGrpReq.StatementType = clientReq.inspectedSql.StatementType
+		evalGrpReq.Catalogs = clientReq.inspectedSql.Catalogs
+		evalGrpReq.Schemas = clientReq.inspectedSql.Schemas
+		evalGrpReq.Tables = clientReq.inspectedSql.Tables
+	}
+	if snapshot := r.routingSnapshot.get(); snapshot != nil {
+		return r.evaluateRoutingBackendFromSnapshot(ctx, snapshot, evalGrpReq)
 	}
 	provider.Logger(*ctx).Debug(fmt.Sprint(LOG_TAG, "evaluating groups for client"))
 
@@ -281,13 +442,70 @@ func (r *RouterServer) evaluateRoutingBackend(ctx *context.Context, clientReq Qu
 	return backendId, groupId, nil
 }
 
+func (r *RouterServer) evaluateRoutingBackendFromSnapshot(ctx *context.Context, snapshot *routing.Snapshot, evalGrpReq *gatewayv1.EvaluateGroupsRequest) (backendId string, groupId string, err error) {
+	backendId, groupId, err = r.routingSnapshot.evaluateBackend(snapshot, &routing.ClientParams{
+		ListeningPort:              evalGrpReq.GetIncomingPort(),
+		Hostname:                   evalGrpReq.GetHost(),
+		Sni:                        evalGrpReq.GetSni(),
+		HeaderConnectionProperties: evalGrpReq.GetHeaderConnectionProperties(),
+		HeaderClientTags:           evalGrpReq.GetHeaderClientTags(),
+		User:                       evalGrpReq.GetUser(),
+		StatementType:              evalGrpReq.GetStatementType(),
+		Catalogs:                   evalGrpReq.GetCatalogs(),
+		Schemas:                    evalGrpReq.GetSchemas(),
+		Tables:                     evalGrpReq.GetTables(),
+	})
+	if err == nil && backendId == "" {
+		// admission is bypassed when the query isn't submitted via the admission handler
+		err = fmt.Errorf("no eligible backend for group %s", groupId)
+	}
+	if err != nil {
+		provider.Logger(*ctx).WithError(err).
+			Errorw("Backend Unresolvable for client from routing snapshot", map[string]interface{}{"req": evalGrpReq})
+		return "", "", err
+	}
+
+	r.routingSnapshot.commitRouted(groupId, backendId)
+
+	provider.Logger(*ctx).Debugw(fmt.Sprint(LOG_TAG, "backend resolved from routing snapshot"), map[string]interface{}{
+		"backend_id": backendId,
+		"group_id":   groupId,
+	})
+	return backendId, groupId, nil
+}
+
+// getBackend returns the backend from routing snapshot if loaded, from the gateway apis otherwise
+func (r *RouterServer) getBackend(ctx *context.Context, backendId string) (*gatewayv1.Backend, error) {
+	if r.routingSnapshot.get() != nil {
+		if b, ok := r.routingSnapshot.getBackend(backendId); ok {
+			return b, nil
+		}
+	}
+	resp, err := r.gatewayApiClient.Backend.GetBackend(*ctx, &gatewayv1.BackendGetRequest{Id: backendId})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetBackend(), nil
+}
+
+// evaluateRequestSource returns the source to be set on requests received on this port, empty if none
+func (r *RouterServer) evaluateRequestSource(ctx *context.Context) (string, error) {
+	if snapshot := r.routingSnapshot.get(); snapshot != nil {
+		return snapshot.RequestSource(int32(r.port)), nil
+	}
+	resp, err := r.gatewayApiClient.Policy.EvaluateRequestSourceForClient(*ctx, &gatewayv1.EvaluateRequestSourceRequest{
+		IncomingPort: int32(r.port),
+	})
+	if err != nil {
+		return "", err
+	}
+	return resp.GetSetRequestSource(), nil
+}
+
 // modifies http.req for preparing it for routing
 func (r *RouterServer) prepareReqForRouting(ctx *context.Context, req *http.Request, backend_id string, cReq ClientRequest) error {
 	provider.Logger(*ctx).Debug(fmt.Sprint(LOG_TAG, "fetching details of resolved backend"))
-	findBackendResp, err := r.gatewayApiClient.Backend.GetBackend(
-		*ctx,
-		&gatewayv1.BackendGetRequest{Id: backend_id},
-	)
+	backend, err := r.getBackend(ctx, backend_id)
 	if err != nil {
 		provider.Logger(*ctx).WithError(err).Error(
 			fmt.Sprint("Cannot find backend for backend id:",
@@ -295,7 +513,6 @@ func (r *RouterServer) prepareReqForRouting(ctx *context.Context, req *http.Requ
 		)
 		return err
 	}
-	backend := findBackendResp.GetBackend()
 
 	var host, scheme string
 	// TODO: clean it
@@ -312,8 +529,14 @@ func (r *RouterServer) prepareReqForRouting(ctx *context.Context, req *http.Requ
 	case *QueryRequest:
 		host = backend.GetHostname()
 		scheme = backend.GetScheme().Enum().String()
+		if cr.replayState != nil {
+			replaySessionState(req, cr.replayState)
+		}
 		cr.Query.ServerHost = fmt.
 			Sprintf("%s://%s", backend.GetScheme().Enum().String(), backend.GetExternalUrl())
+	case *NextUriRequest:
+		host = backend.GetHostname()
+		scheme = backend.GetScheme().Enum().String()
 	default:
 		return fmt.Errorf("unexpected type %T", cr)
 	}
@@ -321,14 +544,12 @@ func (r *RouterServer) prepareReqForRouting(ctx *context.Context, req *http.Requ
 	req.URL.Host = host
 	req.URL.Scheme = scheme
 	req.Host = host
-	sourceHeader, err := r.gatewayApiClient.Policy.EvaluateRequestSourceForClient(*ctx, &gatewayv1.EvaluateRequestSourceRequest{
-		IncomingPort: int32(r.port),
-	})
+	source, err := r.evaluateRequestSource(ctx)
 	if err != nil {
 		return err
 	}
-	if s := sourceHeader.GetSetRequestSource(); s != "" {
-		req.Header.Set("X-Trino-Source", s)
+	if source != "" {
+		req.Header.Set("X-Trino-Source", source)
 	}
 	// TODO - validate and refine parsing of X-Forwarded headers
 	req.Header.Set("X-Forwarded-Host", host)
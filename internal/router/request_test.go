@@ -2,9 +2,15 @@ package router
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"github.com/razorpay/trino-gateway/internal/routing"
 	"github.com/razorpay/trino-gateway/pkg/logger"
+	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -37,6 +43,122 @@ func (suite *HelpersSuite) Test_isValidRequest() {
 func (suite *HelpersSuite) Test_constructQueryFromReq() {
 }
 
+func (suite *HelpersSuite) Test_extractQueryIdFromNextUri() {
+	tests := []struct {
+		path            string
+		queryId         string
+		isPartialCancel bool
+	}{
+		{"/v1/statement/queued/20230101_000000_00001_abcde/y1234/1", "20230101_000000_00001_abcde", false},
+		{"/v1/statement/executing/20230101_000000_00001_abcde/y1234/2", "20230101_000000_00001_abcde", false},
+		{"/v1/statement/executing/partialCancel/20230101_000000_00001_abcde/0/y1234/2", "20230101_000000_00001_abcde", true},
+		{"/v1/statement/20230101_000000_00001_abcde/3", "20230101_000000_00001_abcde", false},
+		{"/v1/stage/20230101_000000_00001_abcde.1", "20230101_000000_00001_abcde", true},
+	}
+	for _, tt := range tests {
+		queryId, isPartialCancel := extractQueryIdFromNextUri(tt.path)
+		suite.Equal(tt.queryId, queryId, tt.path)
+		suite.Equal(tt.isPartialCancel, isPartialCancel, tt.path)
+	}
+}
+
+// transactionQueryApi holds bindings of transactions in memory
+type transactionQueryApi struct {
+	gatewayv1.QueryApi
+	bindings map[string]*gatewayv1.Transaction
+}
+
+func (a *transactionQueryApi) BindTransaction(_ context.Context, req *gatewayv1.Transaction) (*gatewayv1.Empty, error) {
+	a.bindings[req.GetId()] = req
+	return &gatewayv1.Empty{}, nil
+}
+
+func (a *transactionQueryApi) FindBackendForTransaction(_ context.Context, req *gatewayv1.FindBackendForTransactionRequest) (*gatewayv1.FindBackendForTransactionResponse, error) {
+	t, ok := a.bindings[req.GetTransactionId()]
+	if !ok {
+		return nil, errors.New("record_not_found")
+	}
+	return &gatewayv1.FindBackendForTransactionResponse{BackendId: t.GetBackendId(), GroupId: t.GetGroupId(), Username: t.GetUsername()}, nil
+}
+
+func (a *transactionQueryApi) UnbindTransaction(_ context.Context, req *gatewayv1.TransactionUnbindRequest) (*gatewayv1.Empty, error) {
+	delete(a.bindings, req.GetId())
+	return &gatewayv1.Empty{}, nil
+}
+
+func (suite *HelpersSuite) Test_ProcessRequest_Transaction() {
+	queryApi := &transactionQueryApi{bindings: make(map[string]*gatewayv1.Transaction)}
+	r := &RouterServer{
+		port:                8080,
+		gatewayApiClient:    &GatewayApiClient{Query: queryApi},
+		rewriteResponseUris: true,
+		routingSnapshot: &routingSnapshotStore{
+			snapshot: routing.NewSnapshot(nil, nil, nil, nil, "adhoc"),
+			backends: map[string]*gatewayv1.Backend{
+				"trino-1": {Id: "trino-1", IsEnabled: true, IsHealthy: true, Hostname: "trino-1:8080"},
+				"trino-2": {Id: "trino-2", IsEnabled: true, IsHealthy: true, Hostname: "trino-2:8080"},
+			},
+		},
+	}
+	statement := func(user string) *http.Request {
+		req := httptest.NewRequest("POST", "/v1/statement", strings.NewReader("INSERT INTO t VALUES (1)"))
+		req.Header.Set("X-Trino-User", user)
+		req.Header.Set("X-Trino-Transaction-Id", "txn-1")
+		return req
+	}
+
+	// bind
+	started := &http.Response{Header: http.Header{"X-Trino-Started-Transaction-Id": []string{"txn-1"}}}
+	r.trackTransaction(suite.ctx, started, &gatewayv1.Query{BackendId: "trino-2", GroupId: "adhoc", Username: "alice"})
+
+	// route
+	req := statement("alice")
+	cReq, err := r.ProcessRequest(suite.ctx, req)
+	suite.Nil(err)
+	suite.Equal("trino-2", cReq.(*QueryRequest).Query.GetBackendId())
+	suite.Equal("trino-2:8080", req.URL.Host)
+
+	// statements of other users on the transaction are rejected
+	_, err = r.ProcessRequest(suite.ctx, statement("mallory"))
+	suite.NotNil(err)
+
+	// unbind
+	committed := &http.Response{Header: http.Header{"X-Trino-Clear-Transaction-Id": []string{"txn-1"}}}
+	r.trackTransaction(suite.ctx, committed, &gatewayv1.Query{BackendId: "trino-2", GroupId: "adhoc", Username: "alice"})
+	suite.Empty(queryApi.bindings)
+	_, err = r.ProcessRequest(suite.ctx, statement("alice"))
+	suite.ErrorIs(err, errQueryUnresolvable)
+
+	// transactions aren't bound without rewriting of response uris
+	r.rewriteResponseUris = false
+	r.trackTransaction(suite.ctx, started, &gatewayv1.Query{BackendId: "trino-2", GroupId: "adhoc", Username: "alice"})
+	suite.Empty(queryApi.bindings)
+}
+
+func (suite *HelpersSuite) Test_ProcessRequest_Admitted() {
+	r := &RouterServer{
+		port: 8080,
+		routingSnapshot: &routingSnapshotStore{
+			snapshot: routing.NewSnapshot(nil, nil, nil, nil, "adhoc"),
+			backends: map[string]*gatewayv1.Backend{
+				"trino-1": {Id: "trino-1", IsEnabled: true, IsHealthy: true, Hostname: "trino-1:8080"},
+			},
+		},
+	}
+	req := httptest.NewRequest("POST", "/v1/statement", strings.NewReader("SELECT 1"))
+	req.Header.Set("X-Trino-User", "alice")
+	parsed, err := r.ParseClientRequest(suite.ctx, req)
+	suite.Nil(err)
+
+	// submissions parsed by the admission handler aren't parsed again
+	req = withAdmittedRouting(req, &admittedRouting{backendId: "trino-1", groupId: "adhoc", request: parsed.(*QueryRequest)})
+	cReq, err := r.ProcessRequest(suite.ctx, req)
+	suite.Nil(err)
+	suite.Same(parsed, cReq)
+	suite.Equal("trino-1", cReq.(*QueryRequest).Query.GetBackendId())
+	suite.Equal("trino-1:8080", req.URL.Host)
+}
+
 func TestSuite(t *testing.T) {
 	suite.Run(t, new(HelpersSuite))
 }
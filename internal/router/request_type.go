@@ -3,6 +3,8 @@ package router
 import (
 	"fmt"
 
+	"github.com/razorpay/trino-gateway/internal/router/session"
+	"github.com/razorpay/trino-gateway/internal/router/sqlinspect"
 	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
 )
 
@@ -45,12 +47,24 @@ func (r QueryApiRequest) Validate() error {
 	if r.Query.GetId() == "" {
 		return fmt.Errorf("%s: %s", tag, "Missing Query Id")
 	}
+	return nil
+}
 
-	// TODO: remove it once transaction support is added
-	// Looker's Presto client sends `X-Presto-Transaction-Id: NONE`
-	// whereas trino client doesnt send it if its not set
-	if !(r.transactionId == "" || r.transactionId == "NONE") {
-		return fmt.Errorf("%s: %s", tag, "Transactions are not supported in gateway.")
+// NextUriRequest is a follow up request for an already submitted query,
+// sent by clients polling the nextUri of the query or cancelling it.
+type NextUriRequest struct {
+	ClientRequest
+	isCancel        bool
+	isPartialCancel bool
+	sessionKey      session.Key
+	Query           *gatewayv1.Query
+}
+
+func (NextUriRequest) isClientRequest() {}
+func (r NextUriRequest) Validate() error {
+	tag := "query polling"
+	if r.Query.GetId() == "" {
+		return fmt.Errorf("%s: %s", tag, "Missing Query Id")
 	}
 	return nil
 }
@@ -62,10 +76,23 @@ type QueryRequest struct {
 	incomingPort               int32
 	transactionId              string
 	clientHost                 string
-	Query                      *gatewayv1.Query
+	sni                        string
+	inspectedSql               *sqlinspect.Result
+	sessionKey                 session.Key
+	// state of the session tracked by the gateway, to be replayed as the client didn't send it
+	replayState *session.State
+	// routing of the prepared statement executed by the query, if the client didn't send it & its
+	// session can't be told apart from other sessions of the client
+	preparedRouting *session.Routing
+	Query           *gatewayv1.Query
 }
 
 func (QueryRequest) isClientRequest() {}
+
+// inTransaction returns whether the query is a statement of a transaction started by the client
+func (r QueryRequest) inTransaction() bool {
+	return isTransactionId(r.transactionId)
+}
 func (r QueryRequest) Validate() error {
 	tag := "query submission"
 	if r.Query.GetUsername() == "" {
@@ -74,13 +101,6 @@ func (r QueryRequest) Validate() error {
 	if r.Query.GetText() == "" {
 		return fmt.Errorf("%s: %s", tag, "Missing Query text")
 	}
-
-	// TODO: remove it once transaction support is added
-	// Looker's Presto client sends `X-Presto-Transaction-Id: NONE`
-	// whereas trino client doesnt send it if its not set
-	if !(r.transactionId == "" || r.transactionId == "NONE") {
-		return fmt.Errorf("%s: %s", tag, "Transactions are not supported in gateway.")
-	}
 	return nil
 }
 
@@ -92,3 +112,9 @@ func (ApiRequest) isClientRequest() {}
 func (r ApiRequest) Validate() error {
 	return nil
 }
+
+// Looker's Presto client sends `X-Presto-Transaction-Id: NONE`
+// whereas trino client doesnt send it if its not set
+func isTransactionId(id string) bool {
+	return id != "" && id != "NONE"
+}
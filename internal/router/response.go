@@ -1,29 +1,151 @@
 package router
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
-	"regexp"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/razorpay/trino-gateway/internal/provider"
+	"github.com/razorpay/trino-gateway/internal/router/trinoheaders"
 	"github.com/razorpay/trino-gateway/internal/utils"
+	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
 )
 
-func (r *RouterServer) handleRedirect(ctx *context.Context, resp *http.Response) error {
-	// This needs more testing with looker
+// trinoQueryResults holds the subset of Trino QueryResults document
+// the gateway needs for tracking lifecycle of a query.
+type trinoQueryResults struct {
+	Id      string `json:"id"`
+	NextUri string `json:"nextUri"`
+	Stats   struct {
+		State             string `json:"state"`
+		ElapsedTimeMillis int64  `json:"elapsedTimeMillis"`
+	} `json:"stats"`
+	Error *struct {
+		ErrorCode int32  `json:"errorCode"`
+		ErrorName string `json:"errorName"`
+	} `json:"error"`
+	Data []json.RawMessage `json:"data"`
+}
+
+func parseTrinoQueryResults(body string) (*trinoQueryResults, error) {
+	var res trinoQueryResults
+	if err := json.Unmarshal([]byte(body), &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Maps state reported by trino to lifecycle state of the query stored by gateway
+func (res *trinoQueryResults) queryState() gatewayv1.Query_State {
+	switch res.Stats.State {
+	case "PLANNING", "STARTING", "RUNNING", "FINISHING":
+		return gatewayv1.Query_RUNNING
+	case "FINISHED":
+		return gatewayv1.Query_FINISHED
+	case "FAILED":
+		if res.Error != nil && res.Error.ErrorName == "USER_CANCELED" {
+			return gatewayv1.Query_CANCELED
+		}
+		return gatewayv1.Query_FAILED
+	default:
+		// QUEUED, WAITING_FOR_RESOURCES, DISPATCHING
+		return gatewayv1.Query_QUEUED
+	}
+}
+
+// Fields of Trino QueryResults document which hold URIs of the coordinator,
+// clients follow these for all subsequent communication for the query.
+var trinoResultsUriFields = []string{"nextUri", "infoUri", "partialCancelUri"}
+
+// Returns base url of the gateway as seen by the client, it needs to be
+// evaluated before the request is modified for routing to the backend.
+func (r *RouterServer) gatewayBaseUrl(req *http.Request) string {
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	if proto := req.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	host := req.Host
+	if host == "" {
+		host = r.routerHostname
+	}
+	return fmt.Sprintf("%s://%s", scheme, host)
+}
+
+// Replaces scheme and host of an absolute uri with the ones of baseUrl,
+// uri is returned as is if either of them can't be parsed.
+func rewriteUri(uri string, baseUrl string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Host == "" {
+		return uri
+	}
+	base, err := url.Parse(baseUrl)
+	if err != nil || base.Host == "" {
+		return uri
+	}
+	u.Scheme = base.Scheme
+	u.Host = base.Host
+	return u.String()
+}
 
-	// Handle Redirects
-	// TODO: Clean it up
-	// TODO - validate its working for all use cases
-	regex := regexp.MustCompile(`\w+\:\/\/[^\/]*(.*)`)
-	if resp.Header.Get("Location") != ("") {
-		oldLoc := resp.Header.Get("Location")
-		newLoc := fmt.Sprint("http://", r.routerHostname, regex.ReplaceAllString(oldLoc, "$1"))
-		resp.Header.Set("Location", newLoc)
+// Rewrites coordinator URIs in Trino QueryResults document to point to the gateway.
+// Returns the body as is if there is nothing to be rewritten.
+func rewriteQueryResultsUris(body []byte, baseUrl string) ([]byte, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
 	}
+
+	modified := false
+	for _, field := range trinoResultsUriFields {
+		raw, ok := doc[field]
+		if !ok {
+			continue
+		}
+		var uri string
+		if err := json.Unmarshal(raw, &uri); err != nil {
+			return nil, fmt.Errorf("invalid value of %s: %w", field, err)
+		}
+		b, err := json.Marshal(rewriteUri(uri, baseUrl))
+		if err != nil {
+			return nil, err
+		}
+		doc[field] = b
+		modified = true
+	}
+	if !modified {
+		return body, nil
+	}
+	return json.Marshal(doc)
+}
+
+func (r *RouterServer) handleRedirect(ctx *context.Context, resp *http.Response, gatewayBaseUrl string) error {
+	if loc := resp.Header.Get("Location"); loc != "" {
+		resp.Header.Set("Location", rewriteUri(loc, gatewayBaseUrl))
+	}
+	return nil
+}
+
+// Rewrites coordinator URIs in the server response body so follow up requests
+// of the query are sent to the gateway instead.
+// Body is sent uncompressed to the client once rewritten.
+func (r *RouterServer) rewriteResponseBody(ctx *context.Context, resp *http.Response, body string, gatewayBaseUrl string) error {
+	newBody, err := rewriteQueryResultsUris([]byte(body), gatewayBaseUrl)
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(newBody))
+	resp.ContentLength = int64(len(newBody))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(newBody)))
+	resp.Header.Del("Content-Encoding")
 	return nil
 }
 
@@ -31,11 +153,11 @@ func (r *RouterServer) ProcessResponse(
 	ctx *context.Context,
 	resp *http.Response,
 	cReq ClientRequest,
+	gatewayBaseUrl string,
 ) error {
 	switch stCode := resp.StatusCode; true {
 	case stCode >= 200 && stCode < 300:
-		// TODO - fix redirect
-		_ = r.handleRedirect(ctx, resp)
+		_ = r.handleRedirect(ctx, resp, gatewayBaseUrl)
 	case stCode >= 300 && stCode < 400:
 		// http3xx -> server sent redirection, gateway doesn't need to modify anything here
 		// Assuming Clients can directly connect to redirected Uri
@@ -62,11 +184,29 @@ func (r *RouterServer) ProcessResponse(
 			provider.Logger(*ctx).WithError(err).Error(fmt.Sprint(LOG_TAG, "unable to parse body of server response"))
 		}
 
-		go func() {
+		if r.rewriteResponseUris {
+			if err := r.rewriteResponseBody(ctx, resp, body, gatewayBaseUrl); err != nil {
+				provider.Logger(*ctx).WithError(err).Error(fmt.Sprint(LOG_TAG, "unable to rewrite uris in server response"))
+			}
+		}
+
+		saveQuery := func() {
 			req.Id = extractQueryIdFromServerResponse(ctx, body)
 			req.SubmittedAt = time.Now().Unix()
+			// QUEUED is the zero value, queries are stored without a state unless it's reported
+			req.State = gatewayv1.Query_STATE_UNSPECIFIED
+			if res, err := parseTrinoQueryResults(body); err == nil {
+				req.State = res.queryState()
+				req.ElapsedTimeMs = res.Stats.ElapsedTimeMillis
+				req.RowsReturned = int64(len(res.Data))
+				req.BytesReturned = int64(len(body))
+				if res.Error != nil {
+					req.ErrorCode = res.Error.ErrorCode
+					req.ErrorName = res.Error.ErrorName
+				}
+			}
 
-			_, err = r.gatewayApiClient.Query.CreateOrUpdateQuery(*ctx, req)
+			_, err := r.gatewayApiClient.Query.CreateOrUpdateQuery(*ctx, req)
 			if err != nil {
 				provider.Logger(
 					*ctx).WithError(err).Errorw(
@@ -75,18 +215,119 @@ func (r *RouterServer) ProcessResponse(
 						"query_id": req.Id,
 					})
 			}
-		}()
+		}
+		// Follow up requests of the query are routed via the saved query,
+		// so it must exist before the client receives the nextUri.
+		if r.rewriteResponseUris {
+			saveQuery()
+		} else {
+			go saveQuery()
+		}
+		r.trackTransaction(ctx, resp, req)
+		r.trackSessionState(nt.sessionKey, req, resp)
 
 		provider.Logger(*ctx).Debugw("Server Response Processed", map[string]interface{}{
 			"resp": utils.StringifyHttpRequestOrResponse(ctx, resp),
 		})
 
+		return nil
+	case *NextUriRequest:
+		// Partial cancellation only stops a stage of the query, it continues to run
+		if nt.isPartialCancel {
+			return nil
+		}
+		r.trackTransaction(ctx, resp, nt.Query)
+		r.trackSessionState(nt.sessionKey, nt.Query, resp)
+
+		stateReq := &gatewayv1.QueryStateUpdateRequest{Id: nt.Query.GetId()}
+		if nt.isCancel {
+			stateReq.State = gatewayv1.Query_CANCELED
+		} else {
+			body, err := utils.ParseHttpPayloadBody(ctx, &resp.Body, utils.GetHttpBodyEncoding(ctx, resp))
+			if err != nil {
+				provider.Logger(*ctx).WithError(err).Error(fmt.Sprint(LOG_TAG, "unable to parse body of server response"))
+				return nil
+			}
+			if r.rewriteResponseUris {
+				if err := r.rewriteResponseBody(ctx, resp, body, gatewayBaseUrl); err != nil {
+					provider.Logger(*ctx).WithError(err).Error(fmt.Sprint(LOG_TAG, "unable to rewrite uris in server response"))
+				}
+			}
+			res, err := parseTrinoQueryResults(body)
+			if err != nil {
+				provider.Logger(*ctx).WithError(err).Errorw(
+					fmt.Sprint(LOG_TAG, "unable to parse query results from server response"),
+					map[string]interface{}{
+						"query_id": nt.Query.GetId(),
+					})
+				return nil
+			}
+			stateReq.State = res.queryState()
+			stateReq.ElapsedTimeMs = res.Stats.ElapsedTimeMillis
+			stateReq.RowsReturned = int64(len(res.Data))
+			stateReq.BytesReturned = int64(len(body))
+			if res.Error != nil {
+				stateReq.ErrorCode = res.Error.ErrorCode
+				stateReq.ErrorName = res.Error.ErrorName
+			}
+		}
+
+		r.trackAdmittedQuery(stateReq.GetId(), stateReq.GetState())
+		r.trackQuotaQuery(stateReq.GetId(), isTerminalQueryState(stateReq.GetState()))
+
+		go func() {
+			_, err := r.gatewayApiClient.Query.UpdateQueryState(*ctx, stateReq)
+			if err != nil {
+				provider.Logger(
+					*ctx).WithError(err).Errorw(
+					fmt.Sprint(LOG_TAG, "Unable to update state of query"),
+					map[string]interface{}{
+						"query_id": stateReq.GetId(),
+					})
+			}
+		}()
 		return nil
 	default:
 		return nil
 	}
 }
 
+// trackTransaction binds transactions started by the query to its backend, so following
+// statements of the transaction are routed to it, & unbinds them once committed or rolled back.
+// Bindings are saved before the client receives the transaction id. Transactions are committed
+// or rolled back by statements the client polls, so they are tracked only with rewriting of response uris.
+func (r *RouterServer) trackTransaction(ctx *context.Context, resp *http.Response, query *gatewayv1.Query) {
+	if !r.rewriteResponseUris {
+		return
+	}
+	if id := trinoheaders.GetResponse(trinoheaders.StartedTransactionId, resp); isTransactionId(id) {
+		_, err := r.gatewayApiClient.Query.BindTransaction(*ctx, &gatewayv1.Transaction{
+			Id:        id,
+			BackendId: query.GetBackendId(),
+			GroupId:   query.GetGroupId(),
+			Username:  query.GetUsername(),
+		})
+		if err != nil {
+			provider.Logger(*ctx).WithError(err).Errorw(
+				fmt.Sprint(LOG_TAG, "Unable to bind transaction to backend"),
+				map[string]interface{}{
+					"transaction_id": id,
+					"backend_id":     query.GetBackendId(),
+				})
+		}
+	}
+	if id := trinoheaders.GetResponse(trinoheaders.ClearTransactionId, resp); isTransactionId(id) {
+		_, err := r.gatewayApiClient.Query.UnbindTransaction(*ctx, &gatewayv1.TransactionUnbindRequest{Id: id})
+		if err != nil {
+			provider.Logger(*ctx).WithError(err).Errorw(
+				fmt.Sprint(LOG_TAG, "Unable to unbind transaction"),
+				map[string]interface{}{
+					"transaction_id": id,
+				})
+		}
+	}
+}
+
 func extractQueryIdFromServerResponse(ctx *context.Context, body string) string {
 	provider.Logger(*ctx).Debugw(fmt.Sprint(LOG_TAG, "extracting queryId from server response"),
 		map[string]interface{}{
@@ -0,0 +1,36 @@
+package router
+
+import (
+	"encoding/json"
+)
+
+func (suite *HelpersSuite) Test_rewriteUri() {
+	suite.Equal(
+		"https://gateway:8080/v1/statement/queued/q1/y1/1?a=b",
+		rewriteUri("http://trino-coordinator:8080/v1/statement/queued/q1/y1/1?a=b", "https://gateway:8080"),
+	)
+	// relative uris are left as is
+	suite.Equal("/v1/statement/queued/q1/y1/1", rewriteUri("/v1/statement/queued/q1/y1/1", "https://gateway:8080"))
+}
+
+func (suite *HelpersSuite) Test_rewriteQueryResultsUris() {
+	body := `{"id":"q1","infoUri":"http://coordinator:8080/ui/query.html?q1","nextUri":"http://coordinator:8080/v1/statement/queued/q1/y1/1","stats":{"state":"QUEUED"}}`
+
+	res, err := rewriteQueryResultsUris([]byte(body), "http://gateway:8080")
+	suite.Nil(err)
+
+	var doc map[string]interface{}
+	suite.Nil(json.Unmarshal(res, &doc))
+	suite.Equal("http://gateway:8080/ui/query.html?q1", doc["infoUri"])
+	suite.Equal("http://gateway:8080/v1/statement/queued/q1/y1/1", doc["nextUri"])
+	suite.Equal("q1", doc["id"])
+
+	// body without uris is returned unmodified
+	final := `{"id":"q1","stats":{"state":"FINISHED"}}`
+	res, err = rewriteQueryResultsUris([]byte(final), "http://gateway:8080")
+	suite.Nil(err)
+	suite.Equal(final, string(res))
+
+	_, err = rewriteQueryResultsUris([]byte("not json"), "http://gateway:8080")
+	suite.NotNil(err)
+}
@@ -0,0 +1,131 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/razorpay/trino-gateway/internal/provider"
+	"github.com/razorpay/trino-gateway/internal/router/retry"
+	"github.com/razorpay/trino-gateway/internal/utils"
+	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
+)
+
+// submissionRetryTransport resubmits queries failed by a backend before they were started to the
+// next eligible backend, so clients don't see errors of a single bad coordinator. Only the initial
+// submission of a query is retried, never once a backend has assigned it a query id.
+type submissionRetryTransport struct {
+	ctx        *context.Context
+	router     *RouterServer
+	base       http.RoundTripper
+	maxRetries int
+}
+
+func (t *submissionRetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctxSharedObj, err := t.router.extractSharedRequestCtxObject(t.ctx, req)
+	if err != nil {
+		return t.base.RoundTrip(req)
+	}
+	cReq, ok := retryableSubmission(req, ctxSharedObj)
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+
+	// statement is buffered for resending it to other backends
+	var body []byte
+	if req.Body != nil {
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tried := make([]string, 0, t.maxRetries+1)
+	outReq := req.Clone(req.Context())
+	for attempt := 0; ; attempt++ {
+		if req.Body != nil {
+			outReq.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		resp, err := t.base.RoundTrip(outReq)
+		reason := retry.SubmissionFailureReason(t.ctx, resp, err)
+		if reason == "" || attempt >= t.maxRetries {
+			return resp, err
+		}
+
+		failedBackendId := ctxSharedObj.backendId
+		tried = append(tried, failedBackendId)
+		backendId, groupId := t.router.evaluateRetryBackend(t.ctx, cReq.Query.GetGroupId(), tried)
+		if backendId == "" {
+			// failure is recorded for passive health once handled by the reverse proxy
+			return resp, err
+		}
+		retryReq := req.Clone(req.Context())
+		if prepErr := t.router.prepareReqForRouting(t.ctx, retryReq, backendId, cReq); prepErr != nil {
+			return resp, err
+		}
+
+		if err != nil {
+			t.router.recordBackendConnError(t.ctx, failedBackendId)
+		} else {
+			t.router.recordBackendResponse(t.ctx, failedBackendId, resp.StatusCode)
+			resp.Body.Close()
+		}
+		provider.Logger(*t.ctx).Warnw(
+			fmt.Sprint(LOG_TAG, "Query failed by backend before starting, resubmitting to another backend"),
+			map[string]interface{}{
+				"failed_backend_id": failedBackendId,
+				"backend_id":        backendId,
+				"group_id":          groupId,
+				"reason":            reason,
+			})
+		metrics.submissionRetriesTotal.WithLabelValues(cReq.Query.GetGroupId(), failedBackendId, reason).Inc()
+
+		ctxSharedObj.backendId = backendId
+		cReq.Query.BackendId = backendId
+		cReq.Query.GroupId = groupId
+		outReq = retryReq
+	}
+}
+
+// retryableSubmission returns the query of the request if it submits a new query, statements of
+// transactions & queries already known to the gateway are bound to their backend.
+func retryableSubmission(req *http.Request, ctxSharedObj *ContextSharedObject) (*QueryRequest, bool) {
+	if req.Method != http.MethodPost || ctxSharedObj.backendId == "" {
+		return nil, false
+	}
+	if ctxSharedObj.preRoutingErr != nil && *ctxSharedObj.preRoutingErr != nil {
+		return nil, false
+	}
+	cReq, ok := ctxSharedObj.clientRequest.(*QueryRequest)
+	if !ok || cReq.Query.GetId() != "" || cReq.inTransaction() {
+		return nil, false
+	}
+	return cReq, true
+}
+
+// evaluateRetryBackend chooses a backend for resubmitting the query of the group, excluding the
+// backends it was already submitted to. Backend is empty if there are none.
+func (r *RouterServer) evaluateRetryBackend(ctx *context.Context, groupId string, tried []string) (backendId string, evaluatedGroupId string) {
+	if snapshot := r.routingSnapshot.get(); snapshot != nil {
+		for _, b := range tried {
+			snapshot = snapshot.WithBackendUnhealthy(b)
+		}
+		return r.routingSnapshot.evaluateGroupBackend(snapshot, groupId)
+	}
+
+	evalBackendReq := &gatewayv1.EvaluateBackendRequest{GroupIds: []string{groupId}}
+	evalBackendResp, err := r.gatewayApiClient.Group.EvaluateBackendForGroups(*ctx, evalBackendReq)
+	if err != nil {
+		provider.Logger(*ctx).WithError(err).
+			Errorw("Backend Unresolvable for resubmitting query", map[string]interface{}{"req": evalBackendReq})
+		return "", ""
+	}
+	// backends aren't excluded by the gateway apis, resubmitting is given up on if a tried one is chosen
+	if utils.SliceContains(tried, evalBackendResp.GetBackendId()) {
+		return "", ""
+	}
+	return evalBackendResp.GetBackendId(), evalBackendResp.GetGroupId()
+}
@@ -0,0 +1,51 @@
+// Package retry decides whether query submissions failed by a backend can be resubmitted to another backend.
+package retry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/razorpay/trino-gateway/internal/utils"
+)
+
+// Trino error of queries submitted to a coordinator which hasn't completed its startup
+const errorNameServerStartingUp = "SERVER_STARTING_UP"
+
+// SubmissionFailureReason returns why the backend failed the query before starting it, empty if
+// it didn't. Bodies of responses are restored after inspecting them.
+func SubmissionFailureReason(ctx *context.Context, resp *http.Response, err error) string {
+	if err != nil {
+		// statement may have reached the backend for other errors
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == "dial" {
+			return "connection_error"
+		}
+		return ""
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return fmt.Sprint("status_", resp.StatusCode)
+	case http.StatusOK:
+		body, err := utils.ParseHttpPayloadBody(ctx, &resp.Body, utils.GetHttpBodyEncoding(ctx, resp))
+		if err != nil {
+			return ""
+		}
+		var res struct {
+			Error *struct {
+				ErrorName string `json:"errorName"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(body), &res); err != nil {
+			return ""
+		}
+		// the query id assigned to such queries is never started
+		if res.Error != nil && res.Error.ErrorName == errorNameServerStartingUp {
+			return "server_starting_up"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/razorpay/trino-gateway/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SubmissionFailureReason(t *testing.T) {
+	l, err := logger.NewLogger(logger.Config{LogLevel: logger.Warn})
+	assert.Nil(t, err)
+	ctx := context.WithValue(context.Background(), logger.LoggerCtxKey, l)
+
+	response := func(status int, body string) *http.Response {
+		return &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}
+	}
+
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+	assert.Equal(t, "connection_error", SubmissionFailureReason(&ctx, nil, dialErr))
+	// statement may have been received by the backend
+	assert.Equal(t, "", SubmissionFailureReason(&ctx, nil, &net.OpError{Op: "read", Err: io.ErrUnexpectedEOF}))
+
+	assert.Equal(t, "status_502", SubmissionFailureReason(&ctx, response(http.StatusBadGateway, ""), nil))
+	assert.Equal(t, "status_503", SubmissionFailureReason(&ctx, response(http.StatusServiceUnavailable, ""), nil))
+	assert.Equal(t, "", SubmissionFailureReason(&ctx, response(http.StatusBadRequest, ""), nil))
+
+	startingUp := `{"id":"20230101_000000_00001_abcde","stats":{"state":"FAILED"},"error":{"errorName":"SERVER_STARTING_UP"}}`
+	resp := response(http.StatusOK, startingUp)
+	assert.Equal(t, "server_starting_up", SubmissionFailureReason(&ctx, resp, nil))
+	// body is restored for the client
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, startingUp, string(body))
+
+	queued := `{"id":"20230101_000000_00001_abcde","nextUri":"http://localhost/v1/statement/queued/20230101_000000_00001_abcde/y1/1","stats":{"state":"QUEUED"}}`
+	assert.Equal(t, "", SubmissionFailureReason(&ctx, response(http.StatusOK, queued), nil))
+}
@@ -10,6 +10,12 @@ import (
 
 	"github.com/razorpay/trino-gateway/internal/boot"
 	"github.com/razorpay/trino-gateway/internal/provider"
+	"github.com/razorpay/trino-gateway/internal/router/admission"
+	"github.com/razorpay/trino-gateway/internal/router/authn"
+	"github.com/razorpay/trino-gateway/internal/router/passivehealth"
+	"github.com/razorpay/trino-gateway/internal/router/queryresults"
+	"github.com/razorpay/trino-gateway/internal/router/quota"
+	"github.com/razorpay/trino-gateway/internal/router/session"
 	"github.com/razorpay/trino-gateway/internal/utils"
 	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
 )
@@ -22,13 +28,29 @@ type GatewayApiClient struct {
 	Backend gatewayv1.BackendApi
 	Group   gatewayv1.GroupApi
 	Query   gatewayv1.QueryApi
+	Quota   gatewayv1.QuotaApi
+
+	AuthExemption gatewayv1.AuthExemptionApi
 }
 
 type RouterServer struct {
-	gatewayApiClient *GatewayApiClient
-	port             int
-	routerHostname   string
-	authService      IAuthService
+	gatewayApiClient    *GatewayApiClient
+	port                int
+	routerHostname      string
+	authenticators      *authn.Chain
+	rewriteResponseUris bool
+	passiveHealth       *passivehealth.Tracker
+	// nil if principals can act only as themselves
+	impersonation *authn.ImpersonationRules
+	// nil if disabled
+	routingSnapshot *routingSnapshotStore
+	// nil if disabled, admission control requires the routing snapshot & rewriting of response uris
+	admission         *admission.Controller
+	admissionPollWait time.Duration
+	// nil if disabled, quotas are loaded with the routing snapshot
+	quota *quota.Limiter
+	// nil if disabled
+	sessions *session.Tracker
 }
 
 type key int
@@ -50,25 +72,46 @@ type ContextSharedObject struct {
 	timerStart     *time.Time
 	preRoutingErr  *error
 	postRoutingErr *error
+	gatewayBaseUrl string
+	// backend the request is routed to, empty if not routed to a backend
+	backendId string
 }
 
 func init() {
 	initMetrics()
 }
 
-func Server(ctx *context.Context, port int, apiClient *GatewayApiClient, routerHostname string) *http.Server {
+func Server(ctx *context.Context, port int, apiClient *GatewayApiClient, routerHostname string, authenticators *authn.Chain, impersonation *authn.ImpersonationRules, shared *SharedState) *http.Server {
 	routerServer := RouterServer{
-		port:             port,
-		gatewayApiClient: apiClient,
-		routerHostname:   routerHostname,
-		authService: &AuthService{
-			ValidationProviderURL:   boot.Config.Auth.Router.DelegatedAuth.ValidationProviderURL,
-			ValidationProviderToken: boot.Config.Auth.Router.DelegatedAuth.ValidationProviderToken,
-		},
+		port:                port,
+		gatewayApiClient:    apiClient,
+		routerHostname:      routerHostname,
+		authenticators:      authenticators,
+		impersonation:       impersonation,
+		rewriteResponseUris: boot.Config.Gateway.RewriteResponseUris,
+		passiveHealth:       shared.passiveHealth,
+		routingSnapshot:     shared.routingSnapshot,
+		quota:               shared.quota,
+		admission:           shared.admission,
+		admissionPollWait:   shared.admissionPollWait,
+		sessions:            shared.sessions,
+	}
+	var transport http.RoundTripper = &backendTransport{
+		ctx:        ctx,
+		router:     &routerServer,
+		transports: shared.transports,
+	}
+	if maxRetries := boot.Config.Gateway.SubmissionRetry.MaxRetries; maxRetries > 0 {
+		transport = &submissionRetryTransport{
+			ctx:        ctx,
+			router:     &routerServer,
+			base:       transport,
+			maxRetries: maxRetries,
+		}
 	}
 	reverseProxy := httputil.ReverseProxy{
 		Director:  func(req *http.Request) { routerServer.handleClientRequest(ctx, req) },
-		Transport: nil,
+		Transport: transport,
 		ModifyResponse: func(resp *http.Response) error {
 			return routerServer.handleServerResponse(ctx, resp)
 		},
@@ -85,8 +128,7 @@ func Server(ctx *context.Context, port int, apiClient *GatewayApiClient, routerH
 				return
 			}
 
-			status := http.StatusBadGateway
-			var msg string
+			var status int
 			defer func(st time.Time) {
 				post_d := time.Since(st).Milliseconds()
 				tot_d := time.Since(*ctxSharedObj.timerStart).Milliseconds()
@@ -101,21 +143,27 @@ func Server(ctx *context.Context, port int, apiClient *GatewayApiClient, routerH
 					Observe(float64(tot_d))
 			}(time.Now())
 
+			routerServer.releaseAdmissionSlot(req)
+			routerServer.releaseQuotaLease(quotaLeaseFromRequest(req))
+
 			// Check whether preRouting & postRouting error pointers are initialized & then check their value
+			var gatewayErr *queryresults.GatewayError
+			var msg string
 			if ctxSharedObj.preRoutingErr != nil && *ctxSharedObj.preRoutingErr != nil {
-				status, msg = routerServer.handlePreRoutingError(ctx, *ctxSharedObj.preRoutingErr)
+				gatewayErr, msg = routerServer.handlePreRoutingError(ctx, *ctxSharedObj.preRoutingErr)
 			} else if ctxSharedObj.postRoutingErr != nil && *ctxSharedObj.postRoutingErr != nil {
-				status, msg = routerServer.handlePostRoutingError(ctx, *ctxSharedObj.postRoutingErr)
+				gatewayErr, msg = routerServer.handlePostRoutingError(ctx, *ctxSharedObj.postRoutingErr)
 			} else {
-				status, msg = routerServer.handleServerError(ctx, err)
+				routerServer.recordBackendConnError(ctx, ctxSharedObj.backendId)
+				gatewayErr, msg = routerServer.handleServerError(ctx, err)
 			}
-			resp.WriteHeader(status)
-			resp.Write([]byte(msg))
+			status = routerServer.writeGatewayError(resp, req, gatewayErr, msg)
 		},
 	}
 
 	return &http.Server{
-		Handler: routerServer.AuthHandler(ctx, &reverseProxy),
+		Handler: routerServer.AuthHandler(ctx,
+			routerServer.QuotaHandler(ctx, routerServer.AdmissionHandler(ctx, &reverseProxy))),
 	}
 }
 
@@ -142,6 +190,10 @@ func (r *RouterServer) handleClientRequest(ctx *context.Context, req *http.Reque
 			Observe(float64(duration))
 	}(st)
 
+	// Evaluate before the request gets modified for routing
+	gatewayBaseUrl := r.gatewayBaseUrl(req)
+
+	var backendId string
 	cReq, err := r.ProcessRequest(ctx, req)
 	if err != nil {
 		r.handleClientRequestRoutingError(ctx, req, err)
@@ -150,6 +202,7 @@ func (r *RouterServer) handleClientRequest(ctx *context.Context, req *http.Reque
 		case *ApiRequest:
 		case *UiRequest:
 		case *QueryRequest:
+			backendId = nt.Query.GetBackendId()
 			metrics.requestsRoutedTotal.
 				WithLabelValues(
 					req.Method,
@@ -159,6 +212,17 @@ func (r *RouterServer) handleClientRequest(ctx *context.Context, req *http.Reque
 				).
 				Inc()
 		case *QueryApiRequest:
+			backendId = nt.Query.GetBackendId()
+			metrics.requestsRoutedTotal.
+				WithLabelValues(
+					req.Method,
+					fmt.Sprint(r.port),
+					nt.Query.GetGroupId(),
+					nt.Query.GetBackendId(),
+				).
+				Inc()
+		case *NextUriRequest:
+			backendId = nt.Query.GetBackendId()
 			metrics.requestsRoutedTotal.
 				WithLabelValues(
 					req.Method,
@@ -178,9 +242,11 @@ func (r *RouterServer) handleClientRequest(ctx *context.Context, req *http.Reque
 		})
 
 	c := &ContextSharedObject{
-		clientRequest: cReq,
-		timerStart:    &st,
-		preRoutingErr: &err,
+		clientRequest:  cReq,
+		timerStart:     &st,
+		preRoutingErr:  &err,
+		gatewayBaseUrl: gatewayBaseUrl,
+		backendId:      backendId,
 	}
 	reqCtx := context.WithValue(req.Context(), keyCtxSharedObj, c)
 	*req = *req.WithContext(reqCtx)
@@ -195,16 +261,23 @@ func (r *RouterServer) handleClientRequestRoutingError(ctx *context.Context, req
 	req.URL.Host = "http://invalid:8080"
 }
 
-func (r *RouterServer) handlePreRoutingError(ctx *context.Context, err error) (status int, msg string) {
-	return http.StatusBadRequest, "Gateway couldn't process this request"
+func (r *RouterServer) handlePreRoutingError(ctx *context.Context, err error) (*queryresults.GatewayError, string) {
+	switch {
+	case errors.Is(err, errBackendUnresolvable):
+		return queryresults.ErrGatewayNoBackend, fmt.Sprint("Gateway couldn't find a Trino server for this query - ", err.Error())
+	case errors.Is(err, errQueryUnresolvable):
+		return queryresults.ErrGatewayQueryNotFound, fmt.Sprint("Gateway couldn't find the Trino server of this query - ", err.Error())
+	default:
+		return queryresults.ErrGatewayRequestInvalid, fmt.Sprint("Gateway couldn't process this request - ", err.Error())
+	}
 }
 
-func (r *RouterServer) handlePostRoutingError(ctx *context.Context, err error) (status int, msg string) {
-	return http.StatusBadGateway, "Gateway encountered an error parsing server response"
+func (r *RouterServer) handlePostRoutingError(ctx *context.Context, err error) (*queryresults.GatewayError, string) {
+	return queryresults.ErrGatewayInvalidBackendResponse, "Gateway encountered an error parsing server response"
 }
 
-func (r *RouterServer) handleServerError(ctx *context.Context, err error) (status int, msg string) {
-	return http.StatusBadGateway, "Trino Server unreachable"
+func (r *RouterServer) handleServerError(ctx *context.Context, err error) (*queryresults.GatewayError, string) {
+	return queryresults.ErrGatewayBackendUnreachable, "Trino Server unreachable"
 }
 
 func (r *RouterServer) handleServerResponse(ctx *context.Context, resp *http.Response) error {
@@ -213,7 +286,10 @@ func (r *RouterServer) handleServerResponse(ctx *context.Context, resp *http.Res
 		provider.Logger(*ctx).WithError(err).Error("unable to cast shared object from context")
 		return err
 	}
-	err = r.ProcessResponse(ctx, resp, ctxSharedObj.clientRequest)
+	r.recordBackendResponse(ctx, ctxSharedObj.backendId, resp.StatusCode)
+	err = r.ProcessResponse(ctx, resp, ctxSharedObj.clientRequest, ctxSharedObj.gatewayBaseUrl)
+	r.bindAdmissionSlot(resp.Request, ctxSharedObj.clientRequest, resp.StatusCode)
+	r.bindQuotaLease(resp.Request, ctxSharedObj.clientRequest, resp.StatusCode)
 	if err != nil {
 		provider.Logger(*ctx).Errorw(
 			fmt.Sprint(LOG_TAG, "Unable to process server response"),
@@ -238,3 +314,35 @@ func (r *RouterServer) handleServerResponse(ctx *context.Context, resp *http.Res
 	ctxSharedObj.postRoutingErr = &err
 	return err
 }
+
+func (r *RouterServer) recordBackendResponse(ctx *context.Context, backendId string, status int) {
+	if reason := r.passiveHealth.RecordResponse(backendId, status, time.Now()); reason != "" {
+		go r.ejectBackend(ctx, backendId, reason)
+	}
+}
+
+func (r *RouterServer) recordBackendConnError(ctx *context.Context, backendId string) {
+	if reason := r.passiveHealth.RecordConnError(backendId, time.Now()); reason != "" {
+		go r.ejectBackend(ctx, backendId, reason)
+	}
+}
+
+// ejectBackend marks the backend unhealthy so it is not routed to, monitor marks it
+// healthy again once its probes succeed.
+func (r *RouterServer) ejectBackend(ctx *context.Context, backendId string, reason string) {
+	provider.Logger(*ctx).Warnw(
+		fmt.Sprint(LOG_TAG, "Ejecting backend from routing on crossing error thresholds"),
+		map[string]interface{}{"backend_id": backendId, "reason": reason})
+	metrics.backendEjectionsTotal.WithLabelValues(backendId).Inc()
+	r.routingSnapshot.markUnhealthy(backendId)
+
+	_, err := r.gatewayApiClient.Backend.MarkUnhealthyBackend(*ctx, &gatewayv1.BackendMarkUnhealthyRequest{
+		Id:     backendId,
+		Reason: fmt.Sprint("passive health check: ", reason),
+	})
+	if err != nil {
+		provider.Logger(*ctx).WithError(err).Errorw(
+			fmt.Sprint(LOG_TAG, "Unable to mark backend unhealthy"),
+			map[string]interface{}{"backend_id": backendId})
+	}
+}
@@ -0,0 +1,103 @@
+package router
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/razorpay/trino-gateway/internal/boot"
+	"github.com/razorpay/trino-gateway/internal/router/session"
+	"github.com/razorpay/trino-gateway/internal/router/trinoheaders"
+	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
+)
+
+// newSessionTrackerFromConfig returns the tracker of `gateway.sessionState`, nil if disabled
+func newSessionTrackerFromConfig() *session.Tracker {
+	ttl, _ := time.ParseDuration(boot.Config.Gateway.SessionState.Ttl)
+	if ttl <= 0 {
+		return nil
+	}
+	return session.NewTracker(ttl, boot.Config.Gateway.SessionState.MaxEntries)
+}
+
+func sessionKeyFromRequest(req *http.Request) session.Key {
+	key := session.Key{
+		User:     trinoheaders.Get(trinoheaders.User, req),
+		ClientIp: clientIp(req),
+		Source:   trinoheaders.Get(trinoheaders.Source, req),
+	}
+	if h := boot.Config.Gateway.SessionState.SessionIdHeader; h != "" {
+		key.SessionId = req.Header.Get(h)
+	}
+	return key
+}
+
+// resolvePreparedStatement returns text of the prepared statement sent by the client, or tracked for
+// its session if the client didn't send it. Tracked state is returned for replaying it to the backend
+// if the client identifies its session, as state of other connections of the client mustn't be merged.
+// Otherwise the routing of the statement adding the prepared statement is returned.
+func (r *RouterServer) resolvePreparedStatement(req *http.Request, key session.Key, name string) (stmt string, replay *session.State, routing *session.Routing, ok bool) {
+	sent := session.ParseProperties(trinoheaders.Values(trinoheaders.PreparedStatement, req.Header))
+	if stmt, ok := session.Lookup(sent, name); ok {
+		return stmt, nil, nil, true
+	}
+	if r.sessions == nil {
+		return "", nil, nil, false
+	}
+	state, ok := r.sessions.Get(key, time.Now())
+	if !ok {
+		return "", nil, nil, false
+	}
+	tracked, added, found := state.Prepared(name)
+	switch {
+	case !found:
+		return "", nil, nil, false
+	case key.IsUnambiguous():
+		return tracked, state, nil, true
+	case added.BackendId == "":
+		return "", nil, nil, false
+	default:
+		return tracked, nil, &added, true
+	}
+}
+
+// replaySessionState adds state of the session missing in the client request, so the statement
+// is valid on whichever backend it is routed to. State sent by the client takes precedence.
+func replaySessionState(req *http.Request, state *session.State) {
+	prepared := session.ParseProperties(trinoheaders.Values(trinoheaders.PreparedStatement, req.Header))
+	for name, stmt := range state.PreparedStatements {
+		if _, ok := prepared[name]; !ok {
+			prepared[name] = stmt
+		}
+	}
+	trinoheaders.Set(trinoheaders.PreparedStatement, session.FormatProperties(prepared), req)
+
+	if len(state.Properties) > 0 && trinoheaders.Get(trinoheaders.Session, req) == "" {
+		trinoheaders.Set(trinoheaders.Session, session.FormatProperties(state.Properties), req)
+	}
+	if state.Catalog != "" && trinoheaders.Get(trinoheaders.Catalog, req) == "" {
+		trinoheaders.Set(trinoheaders.Catalog, state.Catalog, req)
+		if state.Schema != "" && trinoheaders.Get(trinoheaders.Schema, req) == "" {
+			trinoheaders.Set(trinoheaders.Schema, state.Schema, req)
+		}
+	}
+}
+
+// trackSessionState records changes to state of the client session sent by the backend of the query
+func (r *RouterServer) trackSessionState(key session.Key, query *gatewayv1.Query, resp *http.Response) {
+	if r.sessions == nil {
+		return
+	}
+	c := &session.Changes{
+		AddedPrepare:       session.ParseProperties(trinoheaders.Values(trinoheaders.AddedPrepare, resp.Header)),
+		DeallocatedPrepare: session.ParseNames(trinoheaders.Values(trinoheaders.DeallocatedPrepare, resp.Header)),
+		SetSession:         session.ParseProperties(trinoheaders.Values(trinoheaders.SetSession, resp.Header)),
+		ClearSession:       session.ParseNames(trinoheaders.Values(trinoheaders.ClearSession, resp.Header)),
+		SetCatalog:         trinoheaders.GetResponse(trinoheaders.SetCatalog, resp),
+		SetSchema:          trinoheaders.GetResponse(trinoheaders.SetSchema, resp),
+	}
+	if c.IsEmpty() {
+		return
+	}
+	routing := session.Routing{BackendId: query.GetBackendId(), GroupId: query.GetGroupId()}
+	r.sessions.Apply(key, c, routing, time.Now())
+}
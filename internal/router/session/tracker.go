@@ -0,0 +1,269 @@
+// Package session tracks state set on client sessions by Trino servers via response headers,
+// i.e. prepared statements, session properties, catalog & schema. Trino clients are expected to
+// send the state back on each request, tracked state is replayed for clients which didn't, if
+// they identify their session. Statements of other clients are routed to the backend the
+// prepared statement was added on.
+package session
+
+import (
+	"container/list"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Interval at which idle sessions are cleaned up
+const cleanupInterval = time.Minute
+
+// Key identifies a client session. Trino protocol doesn't have session ids, so connections of
+// a client sharing user, ip & source are the same session unless the client sends an id.
+type Key struct {
+	User     string
+	ClientIp string
+	Source   string
+	// sent by the client, empty if it doesn't identify its session
+	SessionId string
+}
+
+// IsUnambiguous returns whether the key identifies a single session of the client
+func (k Key) IsUnambiguous() bool {
+	return k.SessionId != ""
+}
+
+// Routing is the backend & group a statement was executed on
+type Routing struct {
+	BackendId string
+	GroupId   string
+}
+
+type State struct {
+	PreparedStatements map[string]string
+	Properties         map[string]string
+	Catalog            string
+	Schema             string
+	// routing of the statement adding each of the prepared statements
+	PreparedRoutings map[string]Routing
+
+	key      Key
+	lastSeen time.Time
+}
+
+// Prepared returns the prepared statement & the routing of the statement adding it, names are
+// matched case insensitively if there isn't an exact match
+func (s *State) Prepared(name string) (string, Routing, bool) {
+	if stmt, ok := s.PreparedStatements[name]; ok {
+		return stmt, s.PreparedRoutings[name], true
+	}
+	for n, stmt := range s.PreparedStatements {
+		if strings.EqualFold(n, name) {
+			return stmt, s.PreparedRoutings[n], true
+		}
+	}
+	return "", Routing{}, false
+}
+
+// Changes are the changes to the session state sent by a server in response to a statement
+type Changes struct {
+	AddedPrepare       map[string]string
+	DeallocatedPrepare []string
+	SetSession         map[string]string
+	ClearSession       []string
+	SetCatalog         string
+	SetSchema          string
+}
+
+func (c *Changes) IsEmpty() bool {
+	return len(c.AddedPrepare) == 0 && len(c.DeallocatedPrepare) == 0 &&
+		len(c.SetSession) == 0 && len(c.ClearSession) == 0 &&
+		c.SetCatalog == "" && c.SetSchema == ""
+}
+
+// Tracker holds state of client sessions in memory, sessions idle for ttl are forgotten.
+// Least recently seen sessions are forgotten beyond maxEntries sessions.
+type Tracker struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu       sync.Mutex
+	sessions map[Key]*list.Element
+	// most recently seen first
+	lru         *list.List
+	lastCleanup time.Time
+}
+
+func NewTracker(ttl time.Duration, maxEntries int) *Tracker {
+	return &Tracker{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		sessions:   make(map[Key]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// Apply records changes to state of the session made by a statement routed as per routing
+func (t *Tracker) Apply(key Key, c *Changes, routing Routing, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cleanup(now)
+
+	var s *State
+	if el, ok := t.sessions[key]; ok {
+		s = el.Value.(*State)
+		t.lru.MoveToFront(el)
+	} else {
+		s = &State{
+			PreparedStatements: make(map[string]string),
+			Properties:         make(map[string]string),
+			PreparedRoutings:   make(map[string]Routing),
+			key:                key,
+		}
+		t.sessions[key] = t.lru.PushFront(s)
+		for t.maxEntries > 0 && t.lru.Len() > t.maxEntries {
+			t.remove(t.lru.Back())
+		}
+	}
+	s.lastSeen = now
+	for name, stmt := range c.AddedPrepare {
+		s.PreparedStatements[name] = stmt
+		s.PreparedRoutings[name] = routing
+	}
+	for _, name := range c.DeallocatedPrepare {
+		delete(s.PreparedStatements, name)
+		delete(s.PreparedRoutings, name)
+	}
+	for name, value := range c.SetSession {
+		s.Properties[name] = value
+	}
+	for _, name := range c.ClearSession {
+		delete(s.Properties, name)
+	}
+	if c.SetCatalog != "" {
+		s.Catalog = c.SetCatalog
+	}
+	if c.SetSchema != "" {
+		s.Schema = c.SetSchema
+	}
+}
+
+// Get returns a copy of state of the session
+func (t *Tracker) Get(key Key, now time.Time) (*State, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cleanup(now)
+
+	el, ok := t.sessions[key]
+	if !ok {
+		return nil, false
+	}
+	s := el.Value.(*State)
+	s.lastSeen = now
+	t.lru.MoveToFront(el)
+	res := &State{
+		PreparedStatements: make(map[string]string, len(s.PreparedStatements)),
+		Properties:         make(map[string]string, len(s.Properties)),
+		Catalog:            s.Catalog,
+		Schema:             s.Schema,
+		PreparedRoutings:   make(map[string]Routing, len(s.PreparedRoutings)),
+	}
+	for k, v := range s.PreparedStatements {
+		res.PreparedStatements[k] = v
+	}
+	for k, v := range s.Properties {
+		res.Properties[k] = v
+	}
+	for k, v := range s.PreparedRoutings {
+		res.PreparedRoutings[k] = v
+	}
+	return res, true
+}
+
+// Len returns the number of tracked sessions
+func (t *Tracker) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.lru.Len()
+}
+
+func (t *Tracker) cleanup(now time.Time) {
+	if now.Sub(t.lastCleanup) < cleanupInterval {
+		return
+	}
+	t.lastCleanup = now
+	// least recently seen sessions are at the back
+	for el := t.lru.Back(); el != nil && now.Sub(el.Value.(*State).lastSeen) > t.ttl; el = t.lru.Back() {
+		t.remove(el)
+	}
+}
+
+func (t *Tracker) remove(el *list.Element) {
+	t.lru.Remove(el)
+	delete(t.sessions, el.Value.(*State).key)
+}
+
+// ParseProperties parses values of headers having `name=value` pairs separated by commas,
+// e.g. X-Trino-Session & X-Trino-Prepared-Statement. Values are url encoded.
+func ParseProperties(headers []string) map[string]string {
+	res := make(map[string]string)
+	for _, h := range headers {
+		for _, pair := range strings.Split(h, ",") {
+			name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || name == "" {
+				continue
+			}
+			if n, err := url.QueryUnescape(name); err == nil {
+				name = n
+			}
+			if v, err := url.QueryUnescape(value); err == nil {
+				value = v
+			}
+			res[name] = value
+		}
+	}
+	return res
+}
+
+// FormatProperties formats properties as value of headers having `name=value` pairs
+func FormatProperties(props map[string]string) string {
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = url.QueryEscape(name) + "=" + url.QueryEscape(props[name])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// ParseNames parses values of headers having url encoded names, e.g. X-Trino-Clear-Session
+func ParseNames(headers []string) []string {
+	var res []string
+	for _, h := range headers {
+		for _, name := range strings.Split(h, ",") {
+			name = strings.TrimSpace(name)
+			if n, err := url.QueryUnescape(name); err == nil {
+				name = n
+			}
+			if name != "" {
+				res = append(res, name)
+			}
+		}
+	}
+	return res
+}
+
+// Lookup returns the property, names are matched case insensitively if there isn't an exact match
+func Lookup(props map[string]string, name string) (string, bool) {
+	if v, ok := props[name]; ok {
+		return v, true
+	}
+	for k, v := range props {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return "", false
+}
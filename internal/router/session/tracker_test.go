@@ -0,0 +1,85 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_trackerApply(t *testing.T) {
+	tr := NewTracker(time.Hour, 100)
+	now := time.Unix(1700000000, 0)
+	key := Key{User: "alice", ClientIp: "10.0.0.1"}
+
+	_, ok := tr.Get(key, now)
+	assert.False(t, ok)
+
+	tr.Apply(key, &Changes{
+		AddedPrepare: map[string]string{"stmt1": "SELECT * FROM orders WHERE id = ?"},
+		SetSession:   map[string]string{"query_max_run_time": "1h"},
+		SetCatalog:   "hive",
+	}, Routing{BackendId: "trino-1", GroupId: "adhoc"}, now)
+	tr.Apply(key, &Changes{
+		AddedPrepare: map[string]string{"stmt2": "SELECT 1"},
+		ClearSession: []string{"query_max_run_time"},
+		SetSchema:    "prod",
+	}, Routing{BackendId: "trino-2", GroupId: "adhoc"}, now)
+
+	s, ok := tr.Get(key, now)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"stmt1": "SELECT * FROM orders WHERE id = ?", "stmt2": "SELECT 1"}, s.PreparedStatements)
+	assert.Empty(t, s.Properties)
+	assert.Equal(t, "hive", s.Catalog)
+	assert.Equal(t, "prod", s.Schema)
+	assert.Equal(t, map[string]Routing{
+		"stmt1": {BackendId: "trino-1", GroupId: "adhoc"},
+		"stmt2": {BackendId: "trino-2", GroupId: "adhoc"},
+	}, s.PreparedRoutings)
+
+	// copies are returned
+	delete(s.PreparedStatements, "stmt1")
+	tr.Apply(key, &Changes{DeallocatedPrepare: []string{"stmt2"}}, Routing{}, now)
+	s, _ = tr.Get(key, now)
+	assert.Equal(t, map[string]string{"stmt1": "SELECT * FROM orders WHERE id = ?"}, s.PreparedStatements)
+	assert.Equal(t, map[string]Routing{"stmt1": {BackendId: "trino-1", GroupId: "adhoc"}}, s.PreparedRoutings)
+
+	// other sessions of the user are tracked separately
+	_, ok = tr.Get(Key{User: "alice", ClientIp: "10.0.0.2"}, now)
+	assert.False(t, ok)
+
+	// idle sessions are forgotten
+	_, ok = tr.Get(key, now.Add(2*time.Hour))
+	assert.False(t, ok)
+}
+
+func Test_trackerMaxEntries(t *testing.T) {
+	tr := NewTracker(time.Hour, 2)
+	now := time.Unix(1700000000, 0)
+	changes := &Changes{SetCatalog: "hive"}
+
+	tr.Apply(Key{User: "alice"}, changes, Routing{}, now)
+	tr.Apply(Key{User: "bob"}, changes, Routing{}, now.Add(time.Second))
+	// alice is seen more recently than bob
+	_, ok := tr.Get(Key{User: "alice"}, now.Add(2*time.Second))
+	assert.True(t, ok)
+	tr.Apply(Key{User: "carol"}, changes, Routing{}, now.Add(3*time.Second))
+
+	assert.Equal(t, 2, tr.Len())
+	_, ok = tr.Get(Key{User: "bob"}, now.Add(4*time.Second))
+	assert.False(t, ok, "least recently seen session isn't evicted")
+	_, ok = tr.Get(Key{User: "alice"}, now.Add(4*time.Second))
+	assert.True(t, ok)
+}
+
+func Test_properties(t *testing.T) {
+	props := ParseProperties([]string{"stmt1=SELECT+*+FROM+t+WHERE+a+%3D+%3F, My%20Stmt=SELECT+1", "invalid"})
+	assert.Equal(t, map[string]string{"stmt1": "SELECT * FROM t WHERE a = ?", "My Stmt": "SELECT 1"}, props)
+	assert.Equal(t, props, ParseProperties([]string{FormatProperties(props)}))
+
+	v, ok := Lookup(props, "STMT1")
+	assert.True(t, ok)
+	assert.Equal(t, "SELECT * FROM t WHERE a = ?", v)
+
+	assert.Equal(t, []string{"a", "b c"}, ParseNames([]string{"a, b%20c"}))
+}
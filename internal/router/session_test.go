@@ -0,0 +1,53 @@
+package router
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/razorpay/trino-gateway/internal/router/session"
+)
+
+func Test_resolvePreparedStatement(t *testing.T) {
+	r := &RouterServer{sessions: session.NewTracker(time.Hour, 100)}
+	shared := session.Key{User: "etl", ClientIp: "10.0.0.1", Source: "trino-jdbc"}
+	identified := session.Key{User: "etl", ClientIp: "10.0.0.1", Source: "trino-jdbc", SessionId: "conn-1"}
+	changes := &session.Changes{
+		AddedPrepare: map[string]string{"stmt1": "SELECT * FROM orders WHERE id = ?"},
+		SetSession:   map[string]string{"query_max_run_time": "1h"},
+		SetCatalog:   "hive",
+	}
+	routing := session.Routing{BackendId: "trino-1", GroupId: "adhoc"}
+	r.sessions.Apply(shared, changes, routing, time.Now())
+	r.sessions.Apply(identified, changes, routing, time.Now())
+
+	// sent by the client
+	req := httptest.NewRequest("POST", "/v1/statement", nil)
+	req.Header.Set("X-Trino-Prepared-Statement", "stmt1=SELECT+1")
+	stmt, replay, pinned, ok := r.resolvePreparedStatement(req, shared, "stmt1")
+	assert.True(t, ok)
+	assert.Equal(t, "SELECT 1", stmt)
+	assert.Nil(t, replay)
+	assert.Nil(t, pinned)
+
+	// state of connections sharing the key isn't replayed, the statement is routed where it was prepared
+	req = httptest.NewRequest("POST", "/v1/statement", nil)
+	stmt, replay, pinned, ok = r.resolvePreparedStatement(req, shared, "STMT1")
+	assert.True(t, ok)
+	assert.Equal(t, "SELECT * FROM orders WHERE id = ?", stmt)
+	assert.Nil(t, replay)
+	assert.Equal(t, &routing, pinned)
+
+	// state of sessions identified by the client is replayed
+	stmt, replay, pinned, ok = r.resolvePreparedStatement(req, identified, "stmt1")
+	assert.True(t, ok)
+	assert.Equal(t, "SELECT * FROM orders WHERE id = ?", stmt)
+	assert.Nil(t, pinned)
+	assert.Equal(t, "hive", replay.Catalog)
+	assert.Equal(t, map[string]string{"query_max_run_time": "1h"}, replay.Properties)
+
+	_, _, _, ok = r.resolvePreparedStatement(req, identified, "stmt2")
+	assert.False(t, ok)
+}
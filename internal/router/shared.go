@@ -0,0 +1,96 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/razorpay/trino-gateway/internal/boot"
+	"github.com/razorpay/trino-gateway/internal/provider"
+	"github.com/razorpay/trino-gateway/internal/router/admission"
+	"github.com/razorpay/trino-gateway/internal/router/passivehealth"
+	"github.com/razorpay/trino-gateway/internal/router/quota"
+	"github.com/razorpay/trino-gateway/internal/router/session"
+	"github.com/razorpay/trino-gateway/internal/utils"
+)
+
+// SharedState is the state of the router shared by router servers of all ports, so outcome of
+// requests to backends, connection pools & limits of queries apply per gateway instance.
+type SharedState struct {
+	passiveHealth *passivehealth.Tracker
+	transports    *backendTransports
+	// nil if disabled
+	routingSnapshot *routingSnapshotStore
+	// nil if disabled, quotas are loaded with the routing snapshot
+	quota *quota.Limiter
+	// nil if disabled, admission control requires the routing snapshot & rewriting of response uris
+	admission         *admission.Controller
+	admissionPollWait time.Duration
+	// nil if disabled
+	sessions *session.Tracker
+}
+
+// NewSharedState builds the shared state as per the `gateway` config, refreshing the routing
+// snapshot till ctx is done.
+func NewSharedState(ctx *context.Context, apiClient *GatewayApiClient) (*SharedState, error) {
+	cfg := boot.Config.Gateway
+	window, err := time.ParseDuration(cfg.PassiveHealth.Window)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gateway.passiveHealth.window: %w", err)
+	}
+	transportOpts, err := transportOptionsFromConfig()
+	if err != nil {
+		return nil, err
+	}
+	s := &SharedState{
+		passiveHealth: passivehealth.NewTracker(passivehealth.Config{
+			Window:             window,
+			MinRequests:        cfg.PassiveHealth.MinRequests,
+			ErrorRateThreshold: cfg.PassiveHealth.ErrorRateThreshold,
+			ConnErrorThreshold: cfg.PassiveHealth.ConnErrorThreshold,
+		}),
+		transports: newBackendTransports(transportOpts),
+		sessions:   newSessionTrackerFromConfig(),
+	}
+	if !cfg.RewriteResponseUris {
+		provider.Logger(*ctx).Warn(fmt.Sprint(LOG_TAG,
+			"transactions aren't bound to backends as gateway.rewriteResponseUris is disabled"))
+	}
+
+	interval, err := utils.ParseOptionalDuration(cfg.RoutingSnapshot.RefreshInterval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gateway.routingSnapshot.refreshInterval: %w", err)
+	}
+	if interval < 0 {
+		return nil, errors.New("gateway.routingSnapshot.refreshInterval must not be negative, empty or 0 disables the routing snapshot")
+	}
+	if interval == 0 {
+		provider.Logger(*ctx).Info(fmt.Sprint(LOG_TAG,
+			"routing snapshot is disabled, requests are routed via the gateway apis"))
+		return s, nil
+	}
+	s.routingSnapshot = newRoutingSnapshotStore(apiClient, s.transports, s.passiveHealth)
+
+	quotaClientTimeout, err := time.ParseDuration(cfg.Quota.ClientTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gateway.quota.clientTimeout: %w", err)
+	}
+	if quotaClientTimeout <= 0 {
+		return nil, errors.New("gateway.quota.clientTimeout must be positive, leases of abandoned queries are never released otherwise")
+	}
+	s.quota = quota.NewLimiter(quotaClientTimeout)
+	// queries are seen completing only if clients poll them via the gateway
+	s.routingSnapshot.concurrencyQuotas = cfg.RewriteResponseUris
+	if !cfg.RewriteResponseUris {
+		provider.Logger(*ctx).Warn(fmt.Sprint(LOG_TAG,
+			"max concurrent queries of quotas aren't enforced as gateway.rewriteResponseUris is disabled"))
+	}
+	go s.routingSnapshot.run(*ctx, interval)
+	if cfg.RewriteResponseUris {
+		admissionClientTimeout, _ := time.ParseDuration(cfg.Admission.ClientTimeout)
+		s.admission = admission.NewController(admissionClientTimeout)
+		s.admissionPollWait, _ = time.ParseDuration(cfg.Admission.PollWait)
+	}
+	return s, nil
+}
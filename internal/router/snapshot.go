@@ -0,0 +1,285 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/razorpay/trino-gateway/internal/boot"
+	"github.com/razorpay/trino-gateway/internal/provider"
+	"github.com/razorpay/trino-gateway/internal/router/authn"
+	"github.com/razorpay/trino-gateway/internal/router/passivehealth"
+	"github.com/razorpay/trino-gateway/internal/router/quota"
+	"github.com/razorpay/trino-gateway/internal/routing"
+	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
+)
+
+// routingSnapshotStore holds the routing snapshot in memory, so client requests are routed
+// without round trips to the gateway apis. It is refreshed on changes made via the gateway apis
+// of this instance & periodically for changes made via other instances.
+type routingSnapshotStore struct {
+	apiClient *GatewayApiClient
+	// connection pools & request outcomes of backends no longer in the snapshot are removed on refresh
+	transports    *backendTransports
+	passiveHealth *passivehealth.Tracker
+
+	mu       sync.RWMutex
+	snapshot *routing.Snapshot
+	// keyed by backend id, for forwarding requests to the evaluated backend
+	backends map[string]*gatewayv1.Backend
+	// enabled quotas, enforced by the router before routing
+	quotas []quota.Quota
+	// whether max concurrent queries of quotas are loaded, rates of quotas are loaded regardless
+	concurrencyQuotas bool
+	// enabled auth exemptions, loaded independently of the snapshot
+	authExemptions       []authn.Exemption
+	authExemptionsLoaded bool
+
+	// backend last routed to for each group, round robin is tracked in memory
+	// instead of persisting it for every request
+	lastRoutedMu sync.Mutex
+	lastRouted   map[string]string
+}
+
+func newRoutingSnapshotStore(apiClient *GatewayApiClient, transports *backendTransports, passiveHealth *passivehealth.Tracker) *routingSnapshotStore {
+	return &routingSnapshotStore{
+		apiClient:     apiClient,
+		transports:    transports,
+		passiveHealth: passiveHealth,
+		lastRouted:    make(map[string]string),
+	}
+}
+
+func (s *routingSnapshotStore) run(ctx context.Context, interval time.Duration) {
+	changes := routing.SubscribeChanges()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.refresh(ctx); err != nil {
+			// requests are routed via the gateway apis till the first successful refresh of routing data,
+			// the error has each of the failed calls
+			provider.Logger(ctx).WithError(err).Error(
+				fmt.Sprint(LOG_TAG, "Unable to refresh routing snapshot, retaining the last loaded data of failed calls"))
+			metrics.routingSnapshotRefreshesTotal.WithLabelValues("failure").Inc()
+		} else {
+			metrics.routingSnapshotRefreshesTotal.WithLabelValues("success").Inc()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-changes:
+		}
+	}
+}
+
+// refresh reloads routing data, quotas & auth exemptions independently of each other, so a failure
+// listing one of them retains its last loaded state without holding back refreshes of the others.
+func (s *routingSnapshotStore) refresh(ctx context.Context) error {
+	return errors.Join(s.refreshRouting(ctx), s.refreshQuotas(ctx), s.refreshAuthExemptions(ctx))
+}
+
+func (s *routingSnapshotStore) refreshRouting(ctx context.Context) error {
+	policies, err := s.apiClient.Policy.ListAllPolicies(ctx, &gatewayv1.Empty{})
+	if err != nil {
+		return fmt.Errorf("unable to list policies: %w", err)
+	}
+	groups, err := s.apiClient.Group.ListAllGroups(ctx, &gatewayv1.Empty{})
+	if err != nil {
+		return fmt.Errorf("unable to list groups: %w", err)
+	}
+	backends, err := s.apiClient.Backend.ListAllBackends(ctx, &gatewayv1.Empty{})
+	if err != nil {
+		return fmt.Errorf("unable to list backends: %w", err)
+	}
+	memberships, err := s.apiClient.Policy.ListUserGroupMemberships(ctx, &gatewayv1.Empty{})
+	if err != nil {
+		return fmt.Errorf("unable to list user group memberships: %w", err)
+	}
+
+	userGroups := make(map[string][]string, len(memberships.GetUserGroups()))
+	for user, g := range memberships.GetUserGroups() {
+		userGroups[user] = g.GetGroups()
+	}
+	backendsById := make(map[string]*gatewayv1.Backend, len(backends.GetItems()))
+	backendIds := make(map[string]bool, len(backends.GetItems()))
+	for _, b := range backends.GetItems() {
+		backendsById[b.GetId()] = b
+		backendIds[b.GetId()] = true
+	}
+	snapshot := routing.NewSnapshot(
+		policies.GetItems(),
+		groups.GetItems(),
+		backends.GetItems(),
+		userGroups,
+		boot.Config.Gateway.DefaultRoutingGroup,
+	)
+
+	if s.transports != nil {
+		s.transports.retain(backendsById)
+	}
+	if s.passiveHealth != nil {
+		s.passiveHealth.Retain(backendIds)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshot = snapshot
+	s.backends = backendsById
+	return nil
+}
+
+func (s *routingSnapshotStore) refreshQuotas(ctx context.Context) error {
+	quotasRes, err := s.apiClient.Quota.ListAllQuotas(ctx, &gatewayv1.Empty{})
+	if err != nil {
+		return fmt.Errorf("unable to list quotas: %w", err)
+	}
+
+	var quotas []quota.Quota
+	for _, q := range quotasRes.GetItems() {
+		if !q.GetIsEnabled() {
+			continue
+		}
+		qt := quota.Quota{
+			ID:               q.GetId(),
+			KeyType:          q.GetKeyType().String(),
+			KeyValue:         q.GetKeyValue(),
+			QueriesPerMinute: int(q.GetQueriesPerMinute()),
+		}
+		if s.concurrencyQuotas {
+			qt.MaxConcurrentQueries = int(q.GetMaxConcurrentQueries())
+		}
+		quotas = append(quotas, qt)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.quotas = quotas
+	return nil
+}
+
+func (s *routingSnapshotStore) refreshAuthExemptions(ctx context.Context) error {
+	exemptionsRes, err := s.apiClient.AuthExemption.ListAllAuthExemptions(ctx, &gatewayv1.Empty{})
+	if err != nil {
+		return fmt.Errorf("unable to list auth exemptions: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.authExemptions = enabledAuthExemptions(exemptionsRes.GetItems())
+	s.authExemptionsLoaded = true
+	return nil
+}
+
+// get returns the current snapshot, nil if not loaded yet
+func (s *routingSnapshotStore) get() *routing.Snapshot {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.snapshot
+}
+
+// getQuotas returns the enabled quotas, empty if the snapshot isn't loaded yet
+func (s *routingSnapshotStore) getQuotas() []quota.Quota {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.quotas
+}
+
+// getAuthExemptions returns the enabled auth exemptions, false if they aren't loaded yet
+func (s *routingSnapshotStore) getAuthExemptions() ([]authn.Exemption, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.authExemptions, s.authExemptionsLoaded
+}
+
+func (s *routingSnapshotStore) getBackend(id string) (*gatewayv1.Backend, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	b, ok := s.backends[id]
+	return b, ok
+}
+
+// evaluateBackend chooses the backend for the client request. Backend is empty if the query
+// is to be queued for a group having admission control, see routing.Snapshot.EvaluateBackend.
+// Routing to groups having admission control is committed by the caller once the query is admitted.
+func (s *routingSnapshotStore) evaluateBackend(snapshot *routing.Snapshot, params *routing.ClientParams) (backendId string, groupId string, err error) {
+	groups := snapshot.EvaluateGroups(params)
+
+	s.lastRoutedMu.Lock()
+	defer s.lastRoutedMu.Unlock()
+	backendId, groupId, err = snapshot.EvaluateBackend(groups, s.lastRoutedBackend, selectOptions())
+	if err != nil {
+		return "", "", err
+	}
+	if snapshot.Groups[groupId].Admission == nil {
+		s.lastRouted[groupId] = backendId
+	}
+	return backendId, groupId, nil
+}
+
+// selectGroupBackend chooses an eligible backend of the group, empty if there are none
+func (s *routingSnapshotStore) selectGroupBackend(snapshot *routing.Snapshot, groupId string) string {
+	s.lastRoutedMu.Lock()
+	defer s.lastRoutedMu.Unlock()
+	if b := snapshot.SelectGroupBackend(groupId, s.lastRoutedBackend, selectOptions()); b != nil {
+		return *b
+	}
+	return ""
+}
+
+// evaluateGroupBackend chooses the backend for a query of the group, falling back to the default
+// routing group if none of the backends of the group are eligible. Backend is empty if there are none.
+func (s *routingSnapshotStore) evaluateGroupBackend(snapshot *routing.Snapshot, groupId string) (backendId string, evaluatedGroupId string) {
+	s.lastRoutedMu.Lock()
+	defer s.lastRoutedMu.Unlock()
+	backendId, evaluatedGroupId, err := snapshot.EvaluateBackend([]string{groupId}, s.lastRoutedBackend, selectOptions())
+	if err != nil || backendId == "" {
+		return "", ""
+	}
+	s.lastRouted[evaluatedGroupId] = backendId
+	return backendId, evaluatedGroupId
+}
+
+// commitRouted records the backend routed to for round robin strategy
+func (s *routingSnapshotStore) commitRouted(groupId string, backendId string) {
+	s.lastRoutedMu.Lock()
+	defer s.lastRoutedMu.Unlock()
+	s.lastRouted[groupId] = backendId
+}
+
+// callers must hold lastRoutedMu
+func (s *routingSnapshotStore) lastRoutedBackend(groupId string) string {
+	return s.lastRouted[groupId]
+}
+
+func selectOptions() routing.SelectOptions {
+	return routing.SelectOptions{
+		Now:               time.Now().Unix(),
+		StatsValiditySecs: boot.Config.Monitor.StatsValiditySecs,
+		RandInt63n:        rand.Int63n,
+	}
+}
+
+// markUnhealthy stops routing to the backend without waiting for the next refresh
+func (s *routingSnapshotStore) markUnhealthy(backendId string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.snapshot != nil {
+		s.snapshot = s.snapshot.WithBackendUnhealthy(backendId)
+	}
+}
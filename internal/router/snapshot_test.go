@@ -0,0 +1,106 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/razorpay/trino-gateway/internal/router/passivehealth"
+	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
+	"github.com/stretchr/testify/assert"
+)
+
+// snapshotApis serves the lists of the routing snapshot, failing quotas if quotasErr is set
+type snapshotApis struct {
+	gatewayv1.PolicyApi
+	gatewayv1.GroupApi
+	gatewayv1.BackendApi
+	gatewayv1.QuotaApi
+	gatewayv1.AuthExemptionApi
+	quotasErr error
+}
+
+func (a *snapshotApis) ListAllPolicies(context.Context, *gatewayv1.Empty) (*gatewayv1.PolicyListAllResponse, error) {
+	return &gatewayv1.PolicyListAllResponse{}, nil
+}
+
+func (a *snapshotApis) ListUserGroupMemberships(context.Context, *gatewayv1.Empty) (*gatewayv1.UserGroupMembershipsListResponse, error) {
+	return &gatewayv1.UserGroupMembershipsListResponse{}, nil
+}
+
+func (a *snapshotApis) ListAllGroups(context.Context, *gatewayv1.Empty) (*gatewayv1.GroupListAllResponse, error) {
+	return &gatewayv1.GroupListAllResponse{}, nil
+}
+
+func (a *snapshotApis) ListAllBackends(context.Context, *gatewayv1.Empty) (*gatewayv1.BackendListAllResponse, error) {
+	return &gatewayv1.BackendListAllResponse{Items: []*gatewayv1.Backend{{Id: "trino-1"}}}, nil
+}
+
+func (a *snapshotApis) ListAllQuotas(context.Context, *gatewayv1.Empty) (*gatewayv1.QuotaListAllResponse, error) {
+	if a.quotasErr != nil {
+		return nil, a.quotasErr
+	}
+	return &gatewayv1.QuotaListAllResponse{Items: []*gatewayv1.Quota{{Id: "per-user", IsEnabled: true, QueriesPerMinute: 10, MaxConcurrentQueries: 2}}}, nil
+}
+
+func (a *snapshotApis) ListAllAuthExemptions(context.Context, *gatewayv1.Empty) (*gatewayv1.AuthExemptionListAllResponse, error) {
+	return &gatewayv1.AuthExemptionListAllResponse{}, nil
+}
+
+func Test_routingSnapshotStoreRefresh(t *testing.T) {
+	apis := &snapshotApis{quotasErr: errors.New("quotas table missing")}
+	s := &routingSnapshotStore{apiClient: &GatewayApiClient{
+		Policy:        apis,
+		Group:         apis,
+		Backend:       apis,
+		Quota:         apis,
+		AuthExemption: apis,
+	}}
+
+	// routing data & exemptions are loaded even though listing quotas failed
+	err := s.refresh(context.Background())
+	assert.ErrorContains(t, err, "unable to list quotas")
+	assert.NotNil(t, s.get())
+	_, ok := s.getBackend("trino-1")
+	assert.True(t, ok)
+	_, ok = s.getAuthExemptions()
+	assert.True(t, ok)
+	assert.Empty(t, s.getQuotas())
+
+	apis.quotasErr = nil
+	assert.Nil(t, s.refresh(context.Background()))
+	assert.Len(t, s.getQuotas(), 1)
+	// concurrency isn't limited unless queries are seen completing
+	assert.Equal(t, 10, s.getQuotas()[0].QueriesPerMinute)
+	assert.Equal(t, 0, s.getQuotas()[0].MaxConcurrentQueries)
+	s.concurrencyQuotas = true
+	assert.Nil(t, s.refresh(context.Background()))
+	assert.Equal(t, 2, s.getQuotas()[0].MaxConcurrentQueries)
+
+	// last loaded quotas are retained while listing them fails
+	apis.quotasErr = errors.New("db unavailable")
+	assert.NotNil(t, s.refresh(context.Background()))
+	assert.Len(t, s.getQuotas(), 1)
+}
+
+func Test_routingSnapshotStoreRetainBackends(t *testing.T) {
+	apis := &snapshotApis{}
+	tracker := passivehealth.NewTracker(passivehealth.Config{Window: time.Minute, ConnErrorThreshold: 2})
+	s := newRoutingSnapshotStore(&GatewayApiClient{
+		Policy:        apis,
+		Group:         apis,
+		Backend:       apis,
+		Quota:         apis,
+		AuthExemption: apis,
+	}, newBackendTransports(transportOptions{}), tracker)
+
+	now := time.Now()
+	assert.Empty(t, tracker.RecordConnError("trino-1", now))
+	assert.Empty(t, tracker.RecordConnError("trino-2", now))
+	assert.Nil(t, s.refresh(context.Background()))
+
+	// outcomes of backends removed from the snapshot are dropped
+	assert.NotEmpty(t, tracker.RecordConnError("trino-1", now))
+	assert.Empty(t, tracker.RecordConnError("trino-2", now))
+}
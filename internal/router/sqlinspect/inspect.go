@@ -0,0 +1,474 @@
+// Package sqlinspect extracts routing relevant details from the text of a sql statement.
+//
+// It is a lightweight tokenizer based inspection, not a sql parser. Statements it doesn't
+// understand are reported with statement type OTHER, references it can't resolve are skipped.
+package sqlinspect
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Statement types
+const (
+	Select  = "SELECT"
+	Insert  = "INSERT"
+	Update  = "UPDATE"
+	Delete  = "DELETE"
+	Merge   = "MERGE"
+	Ddl     = "DDL"
+	Show    = "SHOW"
+	Explain = "EXPLAIN"
+	Other   = "OTHER"
+)
+
+var statementTypes = map[string]string{
+	"SELECT":   Select,
+	"VALUES":   Select,
+	"TABLE":    Select,
+	"INSERT":   Insert,
+	"UPDATE":   Update,
+	"DELETE":   Delete,
+	"MERGE":    Merge,
+	"CREATE":   Ddl,
+	"DROP":     Ddl,
+	"ALTER":    Ddl,
+	"TRUNCATE": Ddl,
+	"COMMENT":  Ddl,
+	"SHOW":     Show,
+	"DESCRIBE": Show,
+	"DESC":     Show,
+	"EXPLAIN":  Explain,
+}
+
+// Keywords after which a table reference is expected
+var tableKeywords = map[string]struct{}{
+	"FROM":     {},
+	"JOIN":     {},
+	"INTO":     {},
+	"UPDATE":   {},
+	"TABLE":    {},
+	"DESCRIBE": {},
+	"DESC":     {},
+	"USING":    {},
+}
+
+// Keywords which may follow FROM/JOIN etc but aren't table names
+var nonTableKeywords = map[string]struct{}{
+	"UNNEST":  {},
+	"LATERAL": {},
+	"SELECT":  {},
+	"VALUES":  {},
+	"TABLE":   {},
+	"IF":      {},
+	"EXISTS":  {},
+	"NOT":     {},
+}
+
+// Result of inspecting a statement. Catalogs, Schemas & Tables are sorted,
+// schemas are qualified as catalog.schema & tables as catalog.schema.table.
+type Result struct {
+	StatementType string
+	Catalogs      []string
+	Schemas       []string
+	Tables        []string
+}
+
+type token struct {
+	text string
+	// true for identifiers & keywords, false for punctuation
+	isWord bool
+	// true for double quoted identifiers, which are never keywords
+	isQuoted bool
+}
+
+func (t token) keyword() string {
+	if !t.isWord || t.isQuoted {
+		return ""
+	}
+	return strings.ToUpper(t.text)
+}
+
+// Inspect returns details of the statement, unqualified references are resolved using
+// defaultCatalog & defaultSchema, i.e. values of X-Trino-Catalog & X-Trino-Schema headers.
+func Inspect(sql string, defaultCatalog string, defaultSchema string) *Result {
+	tokens := tokenize(sql)
+	res := &Result{StatementType: statementType(tokens)}
+
+	catalogs := make(map[string]struct{})
+	schemas := make(map[string]struct{})
+	tables := make(map[string]struct{})
+	addSchema := func(catalog, schema string) {
+		if catalog != "" {
+			catalogs[catalog] = struct{}{}
+		}
+		if catalog != "" && schema != "" {
+			schemas[catalog+"."+schema] = struct{}{}
+		}
+	}
+
+	cteNames := commonTableExpressionNames(tokens)
+	inQuery := queryContexts(tokens)
+
+	for i := 0; i < len(tokens); i++ {
+		kw := tokens[i].keyword()
+		switch {
+		case kw == "USE" && i == 0:
+			// USE catalog.schema
+			parts, _ := qualifiedName(tokens, i+1)
+			switch len(parts) {
+			case 1:
+				addSchema(defaultCatalog, normalize(parts[0]))
+			case 2:
+				addSchema(normalize(parts[0]), normalize(parts[1]))
+			}
+			i = len(tokens)
+		case kw == "SHOW" && i+1 < len(tokens) && (tokens[i+1].keyword() == "SCHEMAS" || tokens[i+1].keyword() == "TABLES"):
+			// SHOW SCHEMAS [FROM catalog], SHOW TABLES [FROM [catalog.]schema]
+			// rest of SHOW statements, e.g. SHOW COLUMNS FROM table, are covered by table keywords
+			isSchemas := tokens[i+1].keyword() == "SCHEMAS"
+			j := i + 2
+			if j < len(tokens) && (tokens[j].keyword() == "FROM" || tokens[j].keyword() == "IN") {
+				parts, _ := qualifiedName(tokens, j+1)
+				switch {
+				case len(parts) == 1 && isSchemas:
+					addSchema(normalize(parts[0]), "")
+				case len(parts) == 1:
+					addSchema(defaultCatalog, normalize(parts[0]))
+				case len(parts) == 2:
+					addSchema(normalize(parts[0]), normalize(parts[1]))
+				}
+			} else if isSchemas {
+				addSchema(defaultCatalog, "")
+			} else {
+				addSchema(defaultCatalog, defaultSchema)
+			}
+			i = len(tokens)
+		case kw == "SCHEMA" && i > 0 && isDdlKeyword(tokens[i-1].keyword()):
+			// CREATE/DROP/ALTER SCHEMA [IF [NOT] EXISTS] catalog.schema
+			j := skipIfExists(tokens, i+1)
+			parts, _ := qualifiedName(tokens, j)
+			switch len(parts) {
+			case 1:
+				addSchema(defaultCatalog, normalize(parts[0]))
+			case 2:
+				addSchema(normalize(parts[0]), normalize(parts[1]))
+			}
+		case isTableKeyword(kw) && inQuery[i] && !isDistinctFrom(tokens, i) && ((kw != "DESCRIBE" && kw != "DESC") || i == 0):
+			j := skipIfExists(tokens, i+1)
+			for j < len(tokens) {
+				if _, ok := nonTableKeywords[tokens[j].keyword()]; ok {
+					break
+				}
+				parts, next := qualifiedName(tokens, j)
+				if len(parts) == 0 || len(parts) > 3 {
+					break
+				}
+				if len(parts) == 1 {
+					if _, ok := cteNames[normalize(parts[0])]; ok {
+						j = skipAlias(tokens, next)
+						if j < len(tokens) && tokens[j].text == "," && kw == "FROM" {
+							j++
+							continue
+						}
+						break
+					}
+				}
+				// identifier followed by parenthesis is a function call, e.g. FROM TABLE(...)
+				if next < len(tokens) && tokens[next].text == "(" && kw != "INTO" && kw != "TABLE" {
+					break
+				}
+				catalog, schema, table := resolve(parts, defaultCatalog, defaultSchema)
+				addSchema(catalog, schema)
+				if catalog != "" && schema != "" {
+					tables[catalog+"."+schema+"."+table] = struct{}{}
+				}
+				j = skipAlias(tokens, next)
+				// comma separated list of tables, e.g. FROM a, b
+				if j < len(tokens) && tokens[j].text == "," && kw == "FROM" {
+					j++
+					continue
+				}
+				break
+			}
+		}
+	}
+
+	res.Catalogs = sortedKeys(catalogs)
+	res.Schemas = sortedKeys(schemas)
+	res.Tables = sortedKeys(tables)
+	return res
+}
+
+func isDdlKeyword(kw string) bool {
+	return kw == "CREATE" || kw == "DROP" || kw == "ALTER"
+}
+
+func isTableKeyword(kw string) bool {
+	_, ok := tableKeywords[kw]
+	return ok
+}
+
+// FROM of `a IS [NOT] DISTINCT FROM b` isn't followed by a table
+func isDistinctFrom(tokens []token, i int) bool {
+	return i > 1 && tokens[i-1].keyword() == "DISTINCT" &&
+		(tokens[i-2].keyword() == "IS" || tokens[i-2].keyword() == "NOT")
+}
+
+// Returns for each token whether it is part of a statement or a subquery, as against
+// arguments of a function call, e.g. FROM of EXTRACT(YEAR FROM ts) isn't followed by a table.
+func queryContexts(tokens []token) []bool {
+	res := make([]bool, len(tokens))
+	var stack []bool
+	for i := range tokens {
+		switch tokens[i].text {
+		case "(":
+			isQuery := false
+			if i+1 < len(tokens) {
+				switch tokens[i+1].keyword() {
+				case "SELECT", "WITH", "VALUES", "TABLE":
+					isQuery = true
+				}
+				if tokens[i+1].text == "(" {
+					isQuery = true
+				}
+			}
+			stack = append(stack, isQuery)
+		case ")":
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+		res[i] = len(stack) == 0 || stack[len(stack)-1]
+	}
+	return res
+}
+
+// PreparedStatementName returns name of the prepared statement executed or described by the
+// statement, i.e. `EXECUTE name [USING ...]`, `DESCRIBE INPUT name` & `DESCRIBE OUTPUT name`.
+// Such statements are to be inspected as per the text of the prepared statement.
+func PreparedStatementName(sql string) (string, bool) {
+	tokens := tokenize(sql)
+	i := 0
+	switch {
+	case len(tokens) > 1 && tokens[0].keyword() == "EXECUTE" && tokens[1].keyword() != "IMMEDIATE":
+		i = 1
+	case len(tokens) > 2 && tokens[0].keyword() == "DESCRIBE" &&
+		(tokens[1].keyword() == "INPUT" || tokens[1].keyword() == "OUTPUT"):
+		i = 2
+	default:
+		return "", false
+	}
+	if !tokens[i].isWord {
+		return "", false
+	}
+	return tokens[i].text, true
+}
+
+// Returns statement type based on the first keyword of the statement,
+// for WITH queries it is the first keyword following the common table expressions.
+func statementType(tokens []token) string {
+	i := 0
+	for i < len(tokens) && tokens[i].text == "(" {
+		i++
+	}
+	if i < len(tokens) && tokens[i].keyword() == "WITH" {
+		depth := 0
+		for i++; i < len(tokens); i++ {
+			switch tokens[i].text {
+			case "(":
+				depth++
+			case ")":
+				depth--
+			default:
+				if depth == 0 && tokens[i].keyword() != "AS" {
+					if t, ok := statementTypes[tokens[i].keyword()]; ok {
+						return t
+					}
+				}
+			}
+		}
+		return Other
+	}
+	if i < len(tokens) {
+		if t, ok := statementTypes[tokens[i].keyword()]; ok {
+			return t
+		}
+	}
+	return Other
+}
+
+// Names of common table expressions, i.e. `name AS (` or `name (columns) AS (`
+func commonTableExpressionNames(tokens []token) map[string]struct{} {
+	names := make(map[string]struct{})
+	for i := 0; i+2 < len(tokens); i++ {
+		if !tokens[i].isWord {
+			continue
+		}
+		j := i + 1
+		if tokens[j].text == "(" {
+			// skip column list
+			for j < len(tokens) && tokens[j].text != ")" {
+				j++
+			}
+			j++
+		}
+		if j+1 < len(tokens) && tokens[j].keyword() == "AS" && tokens[j+1].text == "(" {
+			prev := ""
+			if i > 0 {
+				prev = tokens[i-1].text
+			}
+			// preceded by WITH or a comma separating common table expressions
+			if strings.EqualFold(prev, "WITH") || strings.EqualFold(prev, "RECURSIVE") || prev == "," {
+				names[normalize(tokens[i].text)] = struct{}{}
+			}
+		}
+	}
+	return names
+}
+
+func skipIfExists(tokens []token, i int) int {
+	for i < len(tokens) {
+		switch tokens[i].keyword() {
+		case "IF", "NOT", "EXISTS":
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// Skips optional alias of a table reference, i.e. `[AS] alias [(columns)]`
+func skipAlias(tokens []token, i int) int {
+	if i < len(tokens) && tokens[i].keyword() == "AS" {
+		i++
+	}
+	if i < len(tokens) && tokens[i].isWord && !isReserved(tokens[i].keyword()) {
+		i++
+		if i < len(tokens) && tokens[i].text == "(" {
+			for i < len(tokens) && tokens[i].text != ")" {
+				i++
+			}
+			i++
+		}
+	}
+	return i
+}
+
+var reservedKeywords = map[string]struct{}{
+	"WHERE": {}, "JOIN": {}, "INNER": {}, "LEFT": {}, "RIGHT": {}, "FULL": {}, "CROSS": {},
+	"OUTER": {}, "NATURAL": {}, "ON": {}, "USING": {}, "GROUP": {}, "ORDER": {}, "HAVING": {},
+	"LIMIT": {}, "OFFSET": {}, "FETCH": {}, "UNION": {}, "INTERSECT": {}, "EXCEPT": {},
+	"WINDOW": {}, "SET": {}, "VALUES": {}, "SELECT": {}, "WHEN": {}, "WITH": {}, "TABLESAMPLE": {},
+	"FOR": {}, "MATCH_RECOGNIZE": {}, "LIKE": {}, "IN": {},
+}
+
+func isReserved(kw string) bool {
+	_, ok := reservedKeywords[kw]
+	return ok
+}
+
+// Reads a dot separated name starting at i, returns its parts & index of the next token
+func qualifiedName(tokens []token, i int) ([]string, int) {
+	var parts []string
+	for i < len(tokens) && tokens[i].isWord {
+		parts = append(parts, tokens[i].text)
+		i++
+		if i < len(tokens) && tokens[i].text == "." {
+			i++
+			continue
+		}
+		break
+	}
+	return parts, i
+}
+
+func resolve(parts []string, defaultCatalog string, defaultSchema string) (catalog, schema, table string) {
+	switch len(parts) {
+	case 1:
+		return defaultCatalog, defaultSchema, normalize(parts[0])
+	case 2:
+		return defaultCatalog, normalize(parts[0]), normalize(parts[1])
+	default:
+		return normalize(parts[0]), normalize(parts[1]), normalize(parts[2])
+	}
+}
+
+// Trino identifiers are case insensitive
+func normalize(ident string) string {
+	return strings.ToLower(ident)
+}
+
+func tokenize(sql string) []token {
+	var tokens []token
+	r := []rune(sql)
+	for i := 0; i < len(r); {
+		c := r[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '-' && i+1 < len(r) && r[i+1] == '-':
+			for i < len(r) && r[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(r) && r[i+1] == '*':
+			i += 2
+			for i+1 < len(r) && !(r[i] == '*' && r[i+1] == '/') {
+				i++
+			}
+			i += 2
+		case c == '\'':
+			// string literals are skipped, '' is an escaped quote
+			i++
+			for i < len(r) {
+				if r[i] == '\'' {
+					if i+1 < len(r) && r[i+1] == '\'' {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+			i++
+		case c == '"':
+			var sb strings.Builder
+			i++
+			for i < len(r) {
+				if r[i] == '"' {
+					if i+1 < len(r) && r[i+1] == '"' {
+						sb.WriteRune('"')
+						i += 2
+						continue
+					}
+					break
+				}
+				sb.WriteRune(r[i])
+				i++
+			}
+			i++
+			tokens = append(tokens, token{text: sb.String(), isWord: true, isQuoted: true})
+		case c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c):
+			start := i
+			for i < len(r) && (r[i] == '_' || unicode.IsLetter(r[i]) || unicode.IsDigit(r[i])) {
+				i++
+			}
+			tokens = append(tokens, token{text: string(r[start:i]), isWord: true})
+		default:
+			tokens = append(tokens, token{text: string(c)})
+			i++
+		}
+	}
+	return tokens
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,160 @@
+package sqlinspect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Inspect(t *testing.T) {
+	tests := []struct {
+		name          string
+		sql           string
+		statementType string
+		catalogs      []string
+		schemas       []string
+		tables        []string
+	}{
+		{
+			name:          "select with default catalog & schema",
+			sql:           "SELECT * FROM orders o JOIN customers c ON o.cid = c.id",
+			statementType: Select,
+			catalogs:      []string{"hive"},
+			schemas:       []string{"hive.default"},
+			tables:        []string{"hive.default.customers", "hive.default.orders"},
+		},
+		{
+			name:          "qualified names are case insensitive",
+			sql:           `select a from Iceberg.Prod."Events", hive.tmp.x AS y where a IS DISTINCT FROM b`,
+			statementType: Select,
+			catalogs:      []string{"hive", "iceberg"},
+			schemas:       []string{"hive.tmp", "iceberg.prod"},
+			tables:        []string{"hive.tmp.x", "iceberg.prod.events"},
+		},
+		{
+			name:          "common table expressions & subqueries",
+			sql:           "WITH recent AS (SELECT * FROM prod.events WHERE ts > now() - interval '1' day) SELECT extract(year FROM ts) FROM recent, (SELECT 1 FROM dim) d",
+			statementType: Select,
+			catalogs:      []string{"hive"},
+			schemas:       []string{"hive.default", "hive.prod"},
+			tables:        []string{"hive.default.dim", "hive.prod.events"},
+		},
+		{
+			name:          "insert",
+			sql:           "-- nightly load\nINSERT INTO hive.prod.events (a, b) SELECT a, b FROM hive.staging.events",
+			statementType: Insert,
+			catalogs:      []string{"hive"},
+			schemas:       []string{"hive.prod", "hive.staging"},
+			tables:        []string{"hive.prod.events", "hive.staging.events"},
+		},
+		{
+			name:          "with insert",
+			sql:           "WITH x AS (SELECT 1) INSERT INTO t SELECT * FROM x",
+			statementType: Insert,
+			catalogs:      []string{"hive"},
+			schemas:       []string{"hive.default"},
+			tables:        []string{"hive.default.t"},
+		},
+		{
+			name:          "ddl",
+			sql:           "CREATE TABLE IF NOT EXISTS iceberg.prod.t (a int) /* comment FROM x */",
+			statementType: Ddl,
+			catalogs:      []string{"iceberg"},
+			schemas:       []string{"iceberg.prod"},
+			tables:        []string{"iceberg.prod.t"},
+		},
+		{
+			name:          "create schema",
+			sql:           "CREATE SCHEMA iceberg.sandbox",
+			statementType: Ddl,
+			catalogs:      []string{"iceberg"},
+			schemas:       []string{"iceberg.sandbox"},
+			tables:        []string{},
+		},
+		{
+			name:          "show schemas",
+			sql:           "SHOW SCHEMAS FROM iceberg",
+			statementType: Show,
+			catalogs:      []string{"iceberg"},
+			schemas:       []string{},
+			tables:        []string{},
+		},
+		{
+			name:          "show tables",
+			sql:           "show tables from prod like 'x%'",
+			statementType: Show,
+			catalogs:      []string{"hive"},
+			schemas:       []string{"hive.prod"},
+			tables:        []string{},
+		},
+		{
+			name:          "describe",
+			sql:           "DESCRIBE hive.prod.events",
+			statementType: Show,
+			catalogs:      []string{"hive"},
+			schemas:       []string{"hive.prod"},
+			tables:        []string{"hive.prod.events"},
+		},
+		{
+			name:          "explain",
+			sql:           "EXPLAIN SELECT * FROM t ORDER BY a DESC LIMIT 10",
+			statementType: Explain,
+			catalogs:      []string{"hive"},
+			schemas:       []string{"hive.default"},
+			tables:        []string{"hive.default.t"},
+		},
+		{
+			name:          "string literals are ignored",
+			sql:           "SELECT 'it''s FROM x' FROM (VALUES 1) t(a)",
+			statementType: Select,
+			catalogs:      []string{},
+			schemas:       []string{},
+			tables:        []string{},
+		},
+		{
+			name:          "other",
+			sql:           "SET SESSION query_max_run_time = '1h'",
+			statementType: Other,
+			catalogs:      []string{},
+			schemas:       []string{},
+			tables:        []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := Inspect(tt.sql, "hive", "default")
+			assert.Equal(t, tt.statementType, res.StatementType)
+			assert.Equal(t, tt.catalogs, res.Catalogs)
+			assert.Equal(t, tt.schemas, res.Schemas)
+			assert.Equal(t, tt.tables, res.Tables)
+		})
+	}
+}
+
+func Test_InspectWithoutDefaults(t *testing.T) {
+	res := Inspect("SELECT * FROM events JOIN prod.users USING (id)", "", "")
+	assert.Equal(t, Select, res.StatementType)
+	assert.Equal(t, []string{}, res.Catalogs)
+	assert.Equal(t, []string{}, res.Tables)
+}
+
+func Test_PreparedStatementName(t *testing.T) {
+	tests := []struct {
+		sql  string
+		name string
+		ok   bool
+	}{
+		{sql: "EXECUTE stmt1 USING 1, 'a'", name: "stmt1", ok: true},
+		{sql: "execute \"My Stmt\"", name: "My Stmt", ok: true},
+		{sql: "DESCRIBE OUTPUT stmt2", name: "stmt2", ok: true},
+		{sql: "DESCRIBE INPUT stmt3", name: "stmt3", ok: true},
+		{sql: "EXECUTE IMMEDIATE 'SELECT 1'", ok: false},
+		{sql: "DESCRIBE orders", ok: false},
+		{sql: "SELECT 1", ok: false},
+	}
+	for _, tt := range tests {
+		name, ok := PreparedStatementName(tt.sql)
+		assert.Equal(t, tt.ok, ok, tt.sql)
+		assert.Equal(t, tt.name, name, tt.sql)
+	}
+}
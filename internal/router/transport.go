@@ -0,0 +1,227 @@
+package router
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/razorpay/trino-gateway/internal/boot"
+	"github.com/razorpay/trino-gateway/internal/tlsconfig"
+	"github.com/razorpay/trino-gateway/internal/utils"
+	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
+)
+
+// transportOptions are settings of the connection pool of each backend
+type transportOptions struct {
+	maxIdleConns          int
+	maxConns              int
+	idleConnTimeout       time.Duration
+	dialTimeout           time.Duration
+	tlsHandshakeTimeout   time.Duration
+	responseHeaderTimeout time.Duration
+	http2                 bool
+}
+
+func transportOptionsFromConfig() (transportOptions, error) {
+	cfg := boot.Config.Gateway.BackendTransport
+	opts := transportOptions{
+		maxIdleConns: cfg.MaxIdleConns,
+		maxConns:     cfg.MaxConns,
+		http2:        cfg.Http2,
+	}
+	durations := []struct {
+		name  string
+		value string
+		dest  *time.Duration
+	}{
+		{"idleConnTimeout", cfg.IdleConnTimeout, &opts.idleConnTimeout},
+		{"dialTimeout", cfg.DialTimeout, &opts.dialTimeout},
+		{"tlsHandshakeTimeout", cfg.TlsHandshakeTimeout, &opts.tlsHandshakeTimeout},
+		{"responseHeaderTimeout", cfg.ResponseHeaderTimeout, &opts.responseHeaderTimeout},
+	}
+	for _, d := range durations {
+		var err error
+		if *d.dest, err = utils.ParseOptionalDuration(d.value); err != nil {
+			return transportOptions{}, fmt.Errorf("invalid gateway.backendTransport.%s: %w", d.name, err)
+		}
+	}
+	return opts, nil
+}
+
+// pooledTransport is the connection pool of a backend
+type pooledTransport struct {
+	backendId string
+	// TLS settings of the backend & modification times of their files the transport was built with
+	tls       tlsconfig.ClientOptions
+	modTimes  []time.Time
+	transport *http.Transport
+}
+
+// backendTransports holds a connection pool per backend, so a backend having many large results
+// being fetched can't exhaust connections to other backends.
+type backendTransports struct {
+	opts transportOptions
+
+	mu        sync.Mutex
+	byBackend map[string]*pooledTransport
+	// for requests not routed to a backend
+	fallback *pooledTransport
+}
+
+func newBackendTransports(opts transportOptions) *backendTransports {
+	t := &backendTransports{
+		opts:      opts,
+		byBackend: make(map[string]*pooledTransport),
+	}
+	t.fallback = t.newPooledTransport("", tlsconfig.ClientOptions{}, nil, nil)
+	return t
+}
+
+// get returns the pool of the backend, replacing it if TLS settings of the backend or their files changed
+func (t *backendTransports) get(backendId string, opts tlsconfig.ClientOptions) (*pooledTransport, error) {
+	if backendId == "" {
+		return t.fallback, nil
+	}
+	// pool is retained while the files can't be read, e.g. while being replaced
+	modTimes, statErr := opts.ModTimes()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p, ok := t.byBackend[backendId]
+	if ok && p.tls == opts && (statErr != nil || slices.EqualFunc(p.modTimes, modTimes, time.Time.Equal)) {
+		return p, nil
+	}
+	tlsConfig, err := tlsconfig.ClientConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	n := t.newPooledTransport(backendId, opts, modTimes, tlsConfig)
+	if ok {
+		p.transport.CloseIdleConnections()
+	}
+	t.byBackend[backendId] = n
+	return n, nil
+}
+
+// retain removes pools of backends other than the given ones, e.g. of deleted backends, closing
+// their idle connections. Requests in flight on removed pools complete on their connections.
+func (t *backendTransports) retain(backends map[string]*gatewayv1.Backend) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id, p := range t.byBackend {
+		if _, ok := backends[id]; !ok {
+			p.transport.CloseIdleConnections()
+			delete(t.byBackend, id)
+		}
+	}
+}
+
+// newPooledTransport returns a pool using tlsConfig, which must not be shared as the transport modifies it
+func (t *backendTransports) newPooledTransport(backendId string, opts tlsconfig.ClientOptions, modTimes []time.Time, tlsConfig *tls.Config) *pooledTransport {
+	dialer := &net.Dialer{Timeout: t.opts.dialTimeout, KeepAlive: 30 * time.Second}
+	return &pooledTransport{
+		backendId: backendId,
+		tls:       opts,
+		modTimes:  modTimes,
+		transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				conn, err := dialer.DialContext(ctx, network, addr)
+				if err != nil {
+					return nil, err
+				}
+				metrics.backendOpenConnections.WithLabelValues(backendId).Inc()
+				return &countedConn{Conn: conn, backendId: backendId}, nil
+			},
+			TLSClientConfig:       tlsConfig,
+			ForceAttemptHTTP2:     t.opts.http2,
+			MaxIdleConns:          t.opts.maxIdleConns,
+			MaxIdleConnsPerHost:   t.opts.maxIdleConns,
+			MaxConnsPerHost:       t.opts.maxConns,
+			IdleConnTimeout:       t.opts.idleConnTimeout,
+			TLSHandshakeTimeout:   t.opts.tlsHandshakeTimeout,
+			ResponseHeaderTimeout: t.opts.responseHeaderTimeout,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+	}
+}
+
+func (p *pooledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			metrics.backendConnectionsAcquiredTotal.WithLabelValues(p.backendId, strconv.FormatBool(info.Reused)).Inc()
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	inFlight := metrics.backendInFlightRequests.WithLabelValues(p.backendId)
+	inFlight.Inc()
+	resp, err := p.transport.RoundTrip(req)
+	if err != nil {
+		inFlight.Dec()
+		return nil, err
+	}
+	// connection is in use till the response body is consumed, e.g. results streamed to clients
+	resp.Body = &inFlightBody{ReadCloser: resp.Body, done: inFlight.Dec}
+	return resp, nil
+}
+
+// countedConn tracks open connections to a backend
+type countedConn struct {
+	net.Conn
+	backendId string
+	closed    atomic.Bool
+}
+
+func (c *countedConn) Close() error {
+	if c.closed.CompareAndSwap(false, true) {
+		metrics.backendOpenConnections.WithLabelValues(c.backendId).Dec()
+	}
+	return c.Conn.Close()
+}
+
+type inFlightBody struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (b *inFlightBody) Close() error {
+	b.once.Do(b.done)
+	return b.ReadCloser.Close()
+}
+
+// backendTransport sends requests via the connection pool of the backend they are routed to
+type backendTransport struct {
+	ctx        *context.Context
+	router     *RouterServer
+	transports *backendTransports
+}
+
+func (t *backendTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctxSharedObj, err := t.router.extractSharedRequestCtxObject(t.ctx, req)
+	if err != nil || ctxSharedObj.backendId == "" {
+		return t.transports.fallback.RoundTrip(req)
+	}
+	var opts tlsconfig.ClientOptions
+	if req.URL.Scheme == "https" {
+		backend, err := t.router.getBackend(t.ctx, ctxSharedObj.backendId)
+		if err != nil {
+			return nil, err
+		}
+		opts = tlsconfig.BackendClientOptions(backend.GetTls())
+	}
+	p, err := t.transports.get(ctxSharedObj.backendId, opts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tls settings of backend %s: %w", ctxSharedObj.backendId, err)
+	}
+	return p.RoundTrip(req)
+}
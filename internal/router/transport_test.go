@@ -0,0 +1,69 @@
+package router
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"github.com/razorpay/trino-gateway/internal/tlsconfig"
+	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
+)
+
+func (suite *HelpersSuite) Test_backendTransports() {
+	var conns atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	transports := newBackendTransports(transportOptions{
+		maxIdleConns:    2,
+		idleConnTimeout: time.Minute,
+		dialTimeout:     time.Second,
+	})
+	p, err := transports.get("b1", tlsconfig.ClientOptions{})
+	suite.Nil(err)
+	same, _ := transports.get("b1", tlsconfig.ClientOptions{})
+	suite.Same(p, same)
+	other, _ := transports.get("b2", tlsconfig.ClientOptions{})
+	suite.NotSame(p, other)
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, server.URL, nil)
+		req.RequestURI = ""
+		resp, err := p.RoundTrip(req)
+		suite.Nil(err)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		suite.Nil(resp.Body.Close())
+	}
+	// both requests are sent on the same pooled connection
+	suite.Equal(int32(1), conns.Load())
+
+	// pool is replaced once TLS settings of the backend change
+	replaced, err := transports.get("b1", tlsconfig.ClientOptions{InsecureSkipVerify: true})
+	suite.Nil(err)
+	suite.NotSame(p, replaced)
+	same, _ = transports.get("b1", tlsconfig.ClientOptions{InsecureSkipVerify: true})
+	suite.Same(replaced, same)
+	_, err = transports.get("b1", tlsconfig.ClientOptions{CertFile: "missing.crt"})
+	suite.NotNil(err)
+
+	// pools of backends with the same TLS settings don't share the config modified by their transports
+	otherTls, _ := transports.get("b3", tlsconfig.ClientOptions{InsecureSkipVerify: true})
+	suite.NotSame(replaced.transport.TLSClientConfig, otherTls.transport.TLSClientConfig)
+
+	// pools of backends removed from the gateway are dropped
+	transports.retain(map[string]*gatewayv1.Backend{"b2": {Id: "b2"}})
+	suite.Len(transports.byBackend, 1)
+	kept, _ := transports.get("b2", tlsconfig.ClientOptions{})
+	suite.Same(other, kept)
+}
@@ -15,17 +15,58 @@ const (
 	TransactionId        = "Transaction-Id"
 	Password             = "Password"
 	Source               = "Source"
+	Catalog              = "Catalog"
+	Schema               = "Schema"
+	Session              = "Session"
+
+	// response headers
+	StartedTransactionId = "Started-Transaction-Id"
+	ClearTransactionId   = "Clear-Transaction-Id"
+	AddedPrepare         = "Added-Prepare"
+	DeallocatedPrepare   = "Deallocated-Prepare"
+	SetSession           = "Set-Session"
+	ClearSession         = "Clear-Session"
+	SetCatalog           = "Set-Catalog"
+	SetSchema            = "Set-Schema"
 )
 
 var allowedPrefixes = [...]string{"Presto", "Trino"}
 
 func Get(key string, req *http.Request) string {
+	return GetHeader(key, req.Header)
+}
+
+// GetResponse returns the header of a response sent by a Trino server
+func GetResponse(key string, resp *http.Response) string {
+	return GetHeader(key, resp.Header)
+}
+
+func GetHeader(key string, header http.Header) string {
 	for _, h := range allowedPrefixes {
 		s := fmt.Sprintf("X-%s-%s", h, key)
 
-		if val := req.Header.Get(s); val != "" {
+		if val := header.Get(s); val != "" {
 			return val
 		}
 	}
 	return ""
 }
+
+// Values returns all values of a header which may be sent multiple times
+func Values(key string, header http.Header) []string {
+	for _, h := range allowedPrefixes {
+		if vals := header.Values(fmt.Sprintf("X-%s-%s", h, key)); len(vals) > 0 {
+			return vals
+		}
+	}
+	return nil
+}
+
+// Set sets the header with the prefix used by the client, i.e. X-Presto- for presto clients
+func Set(key string, value string, req *http.Request) {
+	prefix := allowedPrefixes[1]
+	if req.Header.Get(fmt.Sprintf("X-%s-%s", allowedPrefixes[0], User)) != "" {
+		prefix = allowedPrefixes[0]
+	}
+	req.Header.Set(fmt.Sprintf("X-%s-%s", prefix, key), value)
+}
@@ -26,3 +26,24 @@ func Test_Get(t *testing.T) {
 	assert.Equal(t, Get("User", prestoHttpReq), "user")
 	assert.Equal(t, Get("Connection-Properties", prestoHttpReq), "connProps")
 }
+
+func Test_GetResponse(t *testing.T) {
+	resp := &http.Response{
+		Header: map[string][]string{
+			"X-Trino-Started-Transaction-Id": {"txn"},
+		},
+	}
+	assert.Equal(t, "txn", GetResponse(StartedTransactionId, resp))
+	assert.Equal(t, "", GetResponse(ClearTransactionId, resp))
+}
+
+func Test_Set(t *testing.T) {
+	prestoHttpReq := &http.Request{Header: http.Header{"X-Presto-User": {"user"}}}
+	Set(Catalog, "hive", prestoHttpReq)
+	assert.Equal(t, "hive", prestoHttpReq.Header.Get("X-Presto-Catalog"))
+
+	trinoHttpReq := &http.Request{Header: http.Header{"X-Trino-User": {"user"}}}
+	Set(Catalog, "hive", trinoHttpReq)
+	assert.Equal(t, "hive", trinoHttpReq.Header.Get("X-Trino-Catalog"))
+	assert.Equal(t, []string{"hive"}, Values(Catalog, trinoHttpReq.Header))
+}
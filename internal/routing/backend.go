@@ -0,0 +1,204 @@
+package routing
+
+import (
+	"errors"
+	"sort"
+)
+
+// Routing strategies of groups
+const (
+	StrategyRandom     = "random"
+	StrategyRoundRobin = "round_robin"
+	StrategyLeastLoad  = "least_load"
+	StrategyWeighted   = "weighted"
+)
+
+// Weight of a backend in the group if not specified explicitly
+const DefaultBackendWeight int32 = 1
+
+type Backend struct {
+	ID             string
+	IsEnabled      bool
+	IsHealthy      bool
+	ClusterLoad    int32
+	RunningQueries int32
+	QueuedQueries  int32
+	AvgQueueTimeMs int64
+	ActiveNodes    int32
+	StatsUpdatedAt int64
+}
+
+// IsActive returns whether the backend can serve traffic
+func (b *Backend) IsActive() bool {
+	return b.IsEnabled && b.IsHealthy
+}
+
+type Group struct {
+	ID        string
+	Strategy  string
+	IsEnabled bool
+	Backends  []string
+	// weights of backends for weighted strategy, DefaultBackendWeight if absent
+	BackendWeights    map[string]int32
+	LoadFormula       *LoadFormula
+	LastRoutedBackend string
+	// nil if queries of the group aren't queued at the gateway
+	Admission *Admission
+}
+
+// LoadFormula evaluates load of a backend from its cluster stats for least_load strategy
+type LoadFormula struct {
+	RunningWeight float64 `json:"running_weight"`
+	QueuedWeight  float64 `json:"queued_weight"`
+	// weight per second of avg queue time
+	QueueTimeWeight float64 `json:"queue_time_weight"`
+	// divide the load by active nodes, so larger clusters absorb more queries
+	NormalizeByNodes bool `json:"normalize_by_nodes"`
+}
+
+func (f *LoadFormula) Validate() error {
+	if f.RunningWeight < 0 || f.QueuedWeight < 0 || f.QueueTimeWeight < 0 {
+		return errors.New("weights of load formula can't be negative")
+	}
+	if f.RunningWeight == 0 && f.QueuedWeight == 0 && f.QueueTimeWeight == 0 {
+		return errors.New("load formula requires at least one positive weight")
+	}
+	return nil
+}
+
+// Evaluate returns the load of backend as per its last recorded stats
+func (f *LoadFormula) Evaluate(b *Backend) float64 {
+	load := float64(b.RunningQueries)*f.RunningWeight +
+		float64(b.QueuedQueries)*f.QueuedWeight +
+		float64(b.AvgQueueTimeMs)/1000*f.QueueTimeWeight
+	if f.NormalizeByNodes && b.ActiveNodes > 0 {
+		load /= float64(b.ActiveNodes)
+	}
+	return load
+}
+
+// Admission limits queries of a group, queries beyond them are queued at the gateway.
+// Limits are enforced by each gateway instance independently.
+type Admission struct {
+	// 0 is unlimited
+	MaxConcurrency int32 `json:"max_concurrency"`
+	// 0 disables queueing
+	MaxQueued        int32 `json:"max_queued"`
+	QueueTimeoutSecs int32 `json:"queue_timeout_secs"`
+}
+
+func (a *Admission) Validate() error {
+	if a.MaxConcurrency < 0 || a.MaxQueued < 0 || a.QueueTimeoutSecs < 0 {
+		return errors.New("limits of admission can't be negative")
+	}
+	if a.MaxQueued > 0 && a.QueueTimeoutSecs == 0 {
+		return errors.New("queue timeout of admission is required for queueing queries")
+	}
+	return nil
+}
+
+type SelectOptions struct {
+	// current epoch seconds
+	Now int64
+	// cluster stats older than this are ignored by least_load, 0 means always valid
+	StatsValiditySecs int
+	RandInt63n        func(int64) int64
+}
+
+// SelectBackend chooses one of the active backends of the group as per its strategy.
+// Returns nil if there are no eligible backends.
+func SelectBackend(group *Group, activeBackends []Backend, opts SelectOptions) *string {
+	if len(activeBackends) == 0 {
+		return nil
+	}
+
+	selectedBackendId := activeBackends[0].ID
+	switch group.Strategy {
+	case StrategyRoundRobin:
+		activeBackendIds := make([]string, len(activeBackends))
+		for i, b := range activeBackends {
+			activeBackendIds[i] = b.ID
+		}
+		activeBackendIds = append(activeBackendIds, group.LastRoutedBackend)
+		sort.Strings(activeBackendIds)
+
+		index := 0
+		for i, b := range activeBackendIds {
+			if b == group.LastRoutedBackend {
+				index = i
+			}
+		}
+		index = index + 1
+		if index >= len(activeBackendIds) {
+			index = 0
+		}
+		selectedBackendId = activeBackendIds[index]
+
+	case StrategyLeastLoad:
+		load := func(b *Backend) float64 {
+			if opts.StatsValiditySecs != 0 && opts.Now-b.StatsUpdatedAt > int64(opts.StatsValiditySecs) {
+				// stats too old to be valid, assuming load as 0
+				return 0
+			}
+			if group.LoadFormula != nil {
+				return group.LoadFormula.Evaluate(b)
+			}
+			return float64(b.ClusterLoad)
+		}
+		leastLoaded := &activeBackends[0]
+		for i := range activeBackends {
+			if load(&activeBackends[i]) < load(leastLoaded) {
+				leastLoaded = &activeBackends[i]
+			}
+		}
+		selectedBackendId = leastLoaded.ID
+
+	case StrategyWeighted:
+		activeBackendIds := make([]string, len(activeBackends))
+		for i, b := range activeBackends {
+			activeBackendIds[i] = b.ID
+		}
+		return chooseWeightedBackend(activeBackendIds, group.weights(), opts.RandInt63n)
+
+	default:
+		// random
+	}
+	return &selectedBackendId
+}
+
+func (g *Group) weights() map[string]int32 {
+	weights := make(map[string]int32, len(g.Backends))
+	for _, b := range g.Backends {
+		weights[b] = DefaultBackendWeight
+		if w, ok := g.BackendWeights[b]; ok {
+			weights[b] = w
+		}
+	}
+	return weights
+}
+
+// chooseWeightedBackend picks a backend randomly, with probability proportional to its weight.
+// Returns nil if none of the backends have a positive weight.
+func chooseWeightedBackend(backends []string, weights map[string]int32, randInt63n func(int64) int64) *string {
+	var total int64
+	for _, b := range backends {
+		if w := weights[b]; w > 0 {
+			total += int64(w)
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	r := randInt63n(total)
+	for i, b := range backends {
+		w := weights[b]
+		if w <= 0 {
+			continue
+		}
+		if r < int64(w) {
+			return &backends[i]
+		}
+		r -= int64(w)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_chooseWeightedBackend(t *testing.T) {
+	backends := []string{"a", "b", "c"}
+	weights := map[string]int32{"a": 95, "b": 0, "c": 5}
+
+	pick := func(r int64) string {
+		b := chooseWeightedBackend(backends, weights, func(n int64) int64 {
+			assert.Equal(t, int64(100), n)
+			return r
+		})
+		return *b
+	}
+	assert.Equal(t, "a", pick(0))
+	assert.Equal(t, "a", pick(94))
+	assert.Equal(t, "c", pick(95))
+	assert.Equal(t, "c", pick(99))
+
+	// backends with zero weight are never chosen
+	assert.Nil(t, chooseWeightedBackend([]string{"b"}, weights, func(n int64) int64 { return 0 }))
+	// backends without weight aren't eligible
+	assert.Nil(t, chooseWeightedBackend([]string{"d"}, weights, func(n int64) int64 { return 0 }))
+}
+
+func Test_loadFormula(t *testing.T) {
+	b := &Backend{RunningQueries: 10, QueuedQueries: 6, AvgQueueTimeMs: 30000, ActiveNodes: 4}
+
+	assert.InDelta(t, 22.0, (&LoadFormula{RunningWeight: 2, QueuedWeight: 1.0 / 3}).Evaluate(b), 1e-9)
+	assert.Equal(t, 10.25, (&LoadFormula{RunningWeight: 2, QueuedWeight: 1, QueueTimeWeight: 0.5, NormalizeByNodes: true}).Evaluate(b))
+	// stats not recorded yet
+	assert.Equal(t, 0.0, (&LoadFormula{RunningWeight: 1, NormalizeByNodes: true}).Evaluate(&Backend{}))
+
+	assert.Nil(t, (&LoadFormula{QueueTimeWeight: 1}).Validate())
+	assert.NotNil(t, (&LoadFormula{}).Validate())
+	assert.NotNil(t, (&LoadFormula{RunningWeight: 1, QueuedWeight: -1}).Validate())
+}
+
+func Test_SelectBackend(t *testing.T) {
+	backends := []Backend{
+		{ID: "b1", ClusterLoad: 50, RunningQueries: 2, StatsUpdatedAt: 1000},
+		{ID: "b2", ClusterLoad: 10, RunningQueries: 8, StatsUpdatedAt: 1000},
+		{ID: "b3", ClusterLoad: 30, RunningQueries: 4, StatsUpdatedAt: 900},
+	}
+	opts := SelectOptions{Now: 1010, StatsValiditySecs: 60, RandInt63n: func(n int64) int64 { return 0 }}
+
+	assert.Nil(t, SelectBackend(&Group{Strategy: StrategyRandom}, nil, opts))
+	assert.Equal(t, "b1", *SelectBackend(&Group{Strategy: StrategyRandom}, backends, opts))
+
+	// round robin continues after the last routed backend, even if it isn't active anymore
+	assert.Equal(t, "b2", *SelectBackend(&Group{Strategy: StrategyRoundRobin, LastRoutedBackend: "b1"}, backends, opts))
+	assert.Equal(t, "b1", *SelectBackend(&Group{Strategy: StrategyRoundRobin, LastRoutedBackend: "b3"}, backends, opts))
+	assert.Equal(t, "b3", *SelectBackend(&Group{Strategy: StrategyRoundRobin, LastRoutedBackend: "b2a"}, backends, opts))
+
+	// stats of b3 are too old, its load is assumed as 0
+	assert.Equal(t, "b3", *SelectBackend(&Group{Strategy: StrategyLeastLoad}, backends, opts))
+	assert.Equal(t, "b2", *SelectBackend(&Group{Strategy: StrategyLeastLoad}, backends[:2], opts))
+	formula := &LoadFormula{RunningWeight: 1}
+	assert.Equal(t, "b1", *SelectBackend(&Group{Strategy: StrategyLeastLoad, LoadFormula: formula}, backends[:2], opts))
+
+	weighted := &Group{Strategy: StrategyWeighted, Backends: []string{"b1", "b2", "b3"}, BackendWeights: map[string]int32{"b1": 0}}
+	assert.Equal(t, "b2", *SelectBackend(weighted, backends, opts))
+}
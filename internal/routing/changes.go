@@ -0,0 +1,32 @@
+package routing
+
+import "sync"
+
+// Subscribers notified on changes to entities affecting routing, made via the gateway apis
+// of this instance. Changes made by other instances are picked up on periodic refresh.
+var changes struct {
+	sync.Mutex
+	subscribers []chan struct{}
+}
+
+// SubscribeChanges returns a channel receiving a signal after changes to policies, groups or backends.
+// Signals are coalesced if the subscriber hasn't consumed the previous one.
+func SubscribeChanges() <-chan struct{} {
+	changes.Lock()
+	defer changes.Unlock()
+	ch := make(chan struct{}, 1)
+	changes.subscribers = append(changes.subscribers, ch)
+	return ch
+}
+
+// NotifyChanged signals all the subscribers of a change
+func NotifyChanged() {
+	changes.Lock()
+	defer changes.Unlock()
+	for _, ch := range changes.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,229 @@
+// Package routing evaluates routing of client requests to groups & backends,
+// shared by the gateway apis and the in-memory routing snapshot of the router.
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/razorpay/trino-gateway/internal/utils"
+)
+
+// Rule types supported for matching client requests
+var ruleTypes = []string{
+	"header_connection_properties",
+	"header_client_tags",
+	"header_host",
+	"listening_port",
+	"user",
+	"user_group",
+	"statement_type",
+	"catalog",
+	"schema",
+	"table",
+	"sni",
+}
+
+// Match types for comparing rule value with the value of client request
+const (
+	MatchExact       = "EXACT"
+	MatchPrefix      = "PREFIX"
+	MatchRegex       = "REGEX"
+	MatchGlob        = "GLOB"
+	MatchContainsTag = "CONTAINS_TAG"
+)
+
+var matchTypes = []string{MatchExact, MatchPrefix, MatchRegex, MatchGlob, MatchContainsTag}
+
+// Operators for composing conditions
+const (
+	OperatorRule = "RULE"
+	OperatorAnd  = "AND"
+	OperatorOr   = "OR"
+	OperatorNot  = "NOT"
+)
+
+type Rule struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	// defaults to EXACT if empty
+	MatchType string `json:"match,omitempty"`
+}
+
+// Condition is a boolean expression over rules, stored json encoded with the policy
+type Condition struct {
+	Operator   string      `json:"operator"`
+	Rule       *Rule       `json:"rule,omitempty"`
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+func (r *Rule) Validate() error {
+	if !utils.SliceContains(ruleTypes, r.Type) {
+		return fmt.Errorf("invalid rule type %q", r.Type)
+	}
+	switch r.EffectiveMatchType() {
+	case MatchRegex:
+		if _, err := compileRegex(r.Value); err != nil {
+			return fmt.Errorf("invalid regex %q: %w", r.Value, err)
+		}
+	case MatchGlob:
+		if _, err := path.Match(r.Value, ""); err != nil {
+			return fmt.Errorf("invalid glob %q: %w", r.Value, err)
+		}
+	default:
+		if !utils.SliceContains(matchTypes, r.EffectiveMatchType()) {
+			return fmt.Errorf("invalid match type %q", r.MatchType)
+		}
+	}
+	return nil
+}
+
+// EffectiveMatchType returns the match type of the rule, EXACT if not set
+func (r *Rule) EffectiveMatchType() string {
+	if r.MatchType == "" {
+		return MatchExact
+	}
+	return r.MatchType
+}
+
+func (c *Condition) Validate() error {
+	switch c.Operator {
+	case OperatorRule:
+		if c.Rule == nil {
+			return errors.New("condition with operator RULE requires a rule")
+		}
+		if len(c.Conditions) > 0 {
+			return errors.New("condition with operator RULE can't have nested conditions")
+		}
+		return c.Rule.Validate()
+	case OperatorAnd, OperatorOr:
+		if len(c.Conditions) == 0 {
+			return fmt.Errorf("condition with operator %s requires at least one nested condition", c.Operator)
+		}
+	case OperatorNot:
+		if len(c.Conditions) != 1 {
+			return errors.New("condition with operator NOT requires exactly one nested condition")
+		}
+	default:
+		return fmt.Errorf("invalid condition operator %q", c.Operator)
+	}
+	if c.Rule != nil {
+		return fmt.Errorf("condition with operator %s can't have a rule", c.Operator)
+	}
+	for i := range c.Conditions {
+		if err := c.Conditions[i].Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Evaluate returns whether the client request satisfies the condition
+func (c *Condition) Evaluate(params *ClientParams) bool {
+	switch c.Operator {
+	case OperatorRule:
+		return c.Rule != nil && c.Rule.Matches(params)
+	case OperatorAnd:
+		for i := range c.Conditions {
+			if !c.Conditions[i].Evaluate(params) {
+				return false
+			}
+		}
+		return len(c.Conditions) > 0
+	case OperatorOr:
+		for i := range c.Conditions {
+			if c.Conditions[i].Evaluate(params) {
+				return true
+			}
+		}
+		return false
+	case OperatorNot:
+		return len(c.Conditions) == 1 && !c.Conditions[0].Evaluate(params)
+	default:
+		return false
+	}
+}
+
+// Matches returns whether any value of client request for the rule type matches the rule value.
+func (r *Rule) Matches(params *ClientParams) bool {
+	for _, v := range params.valuesForRuleType(r.Type) {
+		if r.matchValue(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchValue(v string) bool {
+	switch r.EffectiveMatchType() {
+	case MatchExact:
+		// case insensitive, consistent with the lookup of rules in the db
+		return strings.EqualFold(v, r.Value)
+	case MatchPrefix:
+		return strings.HasPrefix(strings.ToLower(v), strings.ToLower(r.Value))
+	case MatchRegex:
+		re, err := compileRegex(r.Value)
+		return err == nil && re.MatchString(v)
+	case MatchGlob:
+		matched, err := path.Match(r.Value, v)
+		return err == nil && matched
+	case MatchContainsTag:
+		for _, tag := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(tag), r.Value) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// ClientParams are the attributes of a client request evaluated by rules
+type ClientParams struct {
+	ListeningPort              int32
+	Hostname                   string
+	Sni                        string // empty for listeners not terminating TLS
+	HeaderConnectionProperties string
+	HeaderClientTags           string
+	User                       string
+	// resolved from the user group membership source if nil
+	UserGroups []string
+	// extracted from the query text
+	StatementType string
+	Catalogs      []string
+	Schemas       []string
+	Tables        []string
+}
+
+func (p *ClientParams) valuesForRuleType(ruleType string) []string {
+	switch ruleType {
+	case "listening_port":
+		return []string{strconv.Itoa(int(p.ListeningPort))}
+	case "header_host":
+		return []string{p.Hostname}
+	case "sni":
+		return []string{p.Sni}
+	case "header_client_tags":
+		return []string{p.HeaderClientTags}
+	case "header_connection_properties":
+		return []string{p.HeaderConnectionProperties}
+	case "user":
+		return []string{p.User}
+	case "user_group":
+		return p.UserGroups
+	case "statement_type":
+		return []string{p.StatementType}
+	case "catalog":
+		return p.Catalogs
+	case "schema":
+		return p.Schemas
+	case "table":
+		return p.Tables
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,129 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_conditionEvaluate(t *testing.T) {
+	params := &ClientParams{
+		ListeningPort:    8080,
+		Hostname:         "trino.example.com",
+		HeaderClientTags: "etl",
+	}
+	port := Condition{Operator: OperatorRule, Rule: &Rule{Type: "listening_port", Value: "8080"}}
+	otherPort := Condition{Operator: OperatorRule, Rule: &Rule{Type: "listening_port", Value: "8081"}}
+	tags := Condition{Operator: OperatorRule, Rule: &Rule{Type: "header_client_tags", Value: "ETL"}}
+
+	assert.True(t, port.Evaluate(params))
+	assert.False(t, otherPort.Evaluate(params))
+	// case insensitive
+	assert.True(t, tags.Evaluate(params))
+
+	assert.True(t, (&Condition{Operator: OperatorAnd, Conditions: []Condition{port, tags}}).Evaluate(params))
+	assert.False(t, (&Condition{Operator: OperatorAnd, Conditions: []Condition{port, otherPort}}).Evaluate(params))
+	assert.True(t, (&Condition{Operator: OperatorOr, Conditions: []Condition{otherPort, tags}}).Evaluate(params))
+	assert.False(t, (&Condition{Operator: OperatorOr, Conditions: []Condition{otherPort}}).Evaluate(params))
+	assert.True(t, (&Condition{Operator: OperatorNot, Conditions: []Condition{otherPort}}).Evaluate(params))
+
+	// nested
+	assert.True(t, (&Condition{
+		Operator: OperatorAnd,
+		Conditions: []Condition{
+			port,
+			{Operator: OperatorNot, Conditions: []Condition{{Operator: OperatorOr, Conditions: []Condition{otherPort}}}},
+		},
+	}).Evaluate(params))
+}
+
+func Test_conditionValidate(t *testing.T) {
+	rule := Condition{Operator: OperatorRule, Rule: &Rule{Type: "listening_port", Value: "8080"}}
+
+	assert.Nil(t, rule.Validate())
+	assert.Nil(t, (&Condition{Operator: OperatorNot, Conditions: []Condition{rule}}).Validate())
+
+	assert.NotNil(t, (&Condition{Operator: OperatorRule}).Validate())
+	assert.NotNil(t, (&Condition{Operator: OperatorRule, Rule: &Rule{Type: "invalid"}}).Validate())
+	assert.NotNil(t, (&Condition{Operator: OperatorAnd}).Validate())
+	assert.NotNil(t, (&Condition{Operator: OperatorNot, Conditions: []Condition{rule, rule}}).Validate())
+	assert.NotNil(t, (&Condition{Operator: "XOR", Conditions: []Condition{rule}}).Validate())
+	// invalid nested condition
+	assert.NotNil(t, (&Condition{Operator: OperatorOr, Conditions: []Condition{{Operator: OperatorRule}}}).Validate())
+}
+
+func Test_ruleMatches(t *testing.T) {
+	params := &ClientParams{
+		ListeningPort:    8080,
+		Hostname:         "etl.trino.example.com",
+		Sni:              "etl.trino.example.com",
+		HeaderClientTags: "looker, dashboards",
+		User:             "svc_airflow",
+		UserGroups:       []string{"etl", "batch"},
+		StatementType:    "INSERT",
+		Catalogs:         []string{"hive", "iceberg"},
+		Schemas:          []string{"hive.prod", "iceberg.staging"},
+		Tables:           []string{"hive.prod.events", "iceberg.staging.events"},
+	}
+	tests := []struct {
+		rule    Rule
+		matches bool
+	}{
+		{Rule{Type: "header_host", Value: "ETL.trino.example.com"}, true},
+		{Rule{Type: "header_host", Value: "etl.trino.example.com", MatchType: MatchExact}, true},
+		{Rule{Type: "header_host", Value: "etl.", MatchType: MatchExact}, false},
+		{Rule{Type: "header_host", Value: "ETL.", MatchType: MatchPrefix}, true},
+		{Rule{Type: "header_host", Value: "adhoc.", MatchType: MatchPrefix}, false},
+		{Rule{Type: "header_host", Value: `[a-z]+\.trino\.example\.com`, MatchType: MatchRegex}, true},
+		// regex is matched against the whole value
+		{Rule{Type: "header_host", Value: `trino`, MatchType: MatchRegex}, false},
+		{Rule{Type: "header_host", Value: "*.trino.example.com", MatchType: MatchGlob}, true},
+		{Rule{Type: "header_host", Value: "*.example.org", MatchType: MatchGlob}, false},
+		{Rule{Type: "sni", Value: "*.trino.example.com", MatchType: MatchGlob}, true},
+		{Rule{Type: "sni", Value: "adhoc.trino.example.com"}, false},
+		{Rule{Type: "header_client_tags", Value: "looker", MatchType: MatchContainsTag}, true},
+		{Rule{Type: "header_client_tags", Value: "Dashboards", MatchType: MatchContainsTag}, true},
+		{Rule{Type: "header_client_tags", Value: "etl", MatchType: MatchContainsTag}, false},
+		{Rule{Type: "header_client_tags", Value: "looker"}, false},
+		{Rule{Type: "listening_port", Value: "80*", MatchType: MatchGlob}, true},
+		{Rule{Type: "user", Value: "svc_airflow"}, true},
+		{Rule{Type: "user", Value: "svc_.*", MatchType: MatchRegex}, true},
+		{Rule{Type: "user", Value: "alice"}, false},
+		{Rule{Type: "user_group", Value: "batch"}, true},
+		{Rule{Type: "user_group", Value: "analysts"}, false},
+		{Rule{Type: "statement_type", Value: "INSERT|UPDATE|DELETE|MERGE", MatchType: MatchRegex}, true},
+		{Rule{Type: "statement_type", Value: "SELECT"}, false},
+		{Rule{Type: "catalog", Value: "iceberg"}, true},
+		{Rule{Type: "schema", Value: "hive.prod"}, true},
+		{Rule{Type: "schema", Value: "hive.staging"}, false},
+		{Rule{Type: "table", Value: "hive.prod.*", MatchType: MatchGlob}, true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.matches, tt.rule.Matches(params), tt.rule)
+	}
+}
+
+func Test_ruleValidate(t *testing.T) {
+	assert.Nil(t, (&Rule{Type: "header_host", Value: "a.*", MatchType: MatchRegex}).Validate())
+	assert.NotNil(t, (&Rule{Type: "header_host", Value: "a(", MatchType: MatchRegex}).Validate())
+	assert.NotNil(t, (&Rule{Type: "header_host", Value: "[a", MatchType: MatchGlob}).Validate())
+	assert.NotNil(t, (&Rule{Type: "header_host", Value: "a", MatchType: "SUFFIX"}).Validate())
+}
+
+func Test_regexCacheEviction(t *testing.T) {
+	c := newRegexCache(2)
+	a, err := c.compile("a.*")
+	assert.Nil(t, err)
+	_, _ = c.compile("b.*")
+	// a is the most recently used, so b is evicted
+	same, _ := c.compile("a.*")
+	assert.Same(t, a, same)
+	_, _ = c.compile("c.*")
+	assert.Equal(t, 2, c.lru.Len())
+	assert.Contains(t, c.entries, "a.*")
+	assert.NotContains(t, c.entries, "b.*")
+
+	_, err = c.compile("(")
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, c.lru.Len())
+}
@@ -0,0 +1,171 @@
+package routing
+
+import (
+	"sort"
+	"strconv"
+
+	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
+)
+
+// Policy is an enabled routing policy
+type Policy struct {
+	ID      string
+	GroupId string
+	// rule of the policy, ignored if the policy has a condition
+	Rule             Rule
+	Condition        *Condition
+	Priority         int32
+	IsAuthDelegated  bool
+	SetRequestSource string
+}
+
+// EvaluateGroups returns groups eligible for the client request, in order of preference.
+//
+// Policies are evaluated in tiers of priority, highest first. Groups of the first tier
+// having a matching policy are returned, lower tiers are only considered otherwise.
+// Within a tier, groups of policies with a condition are matched individually,
+// whereas rule only policies retain their original semantics -
+// intersection of groups matched for each rule type, ignoring rule types with no match.
+// Groups of a tier are ordered by the lowest id of the matched policies routing to them.
+func EvaluateGroups(policies []Policy, params *ClientParams) []string {
+	tiers := make(map[int32][]Policy)
+	var priorities []int32
+	for _, policy := range policies {
+		if _, ok := tiers[policy.Priority]; !ok {
+			priorities = append(priorities, policy.Priority)
+		}
+		tiers[policy.Priority] = append(tiers[policy.Priority], policy)
+	}
+	sort.Slice(priorities, func(i, j int) bool { return priorities[i] > priorities[j] })
+
+	for _, p := range priorities {
+		gids := evaluateGroupsForPolicies(tiers[p], params)
+		if len(gids) > 0 {
+			res := make([]string, 0, len(gids))
+			for k := range gids {
+				res = append(res, k)
+			}
+			sort.Slice(res, func(i, j int) bool {
+				if gids[res[i]] != gids[res[j]] {
+					return gids[res[i]] < gids[res[j]]
+				}
+				return res[i] < res[j]
+			})
+			return res
+		}
+	}
+	return []string{}
+}
+
+// evaluateGroupsForPolicies returns groups matched by the policies, mapped to the lowest id
+// of the matched policies routing to the group.
+func evaluateGroupsForPolicies(policies []Policy, params *ClientParams) map[string]string {
+	// Using a map instead of slice for returning groups, to simulate a 'set' data type
+	ruleGroups := make(map[string]map[string]struct{}, len(ruleTypes))
+	conditionGroups := make(map[string]struct{})
+	firstPolicy := make(map[string]string)
+	matched := func(policy *Policy) {
+		if id, ok := firstPolicy[policy.GroupId]; !ok || policy.ID < id {
+			firstPolicy[policy.GroupId] = policy.ID
+		}
+	}
+	for i := range policies {
+		policy := &policies[i]
+		if policy.Condition != nil {
+			if policy.Condition.Evaluate(params) {
+				conditionGroups[policy.GroupId] = struct{}{}
+				matched(policy)
+			}
+			continue
+		}
+		if policy.Rule.Matches(params) {
+			if ruleGroups[policy.Rule.Type] == nil {
+				ruleGroups[policy.Rule.Type] = make(map[string]struct{})
+			}
+			ruleGroups[policy.Rule.Type][policy.GroupId] = struct{}{}
+			matched(policy)
+		}
+	}
+
+	// take intersections of all non nil grp sets; a nil set = any grp; all sets nil == route to fallbackGrp;
+	var gids map[string]struct{}
+	for _, ruleType := range ruleTypes {
+		gids = setIntersection(gids, ruleGroups[ruleType])
+	}
+
+	for k := range conditionGroups {
+		if gids == nil {
+			gids = make(map[string]struct{})
+		}
+		gids[k] = struct{}{}
+	}
+
+	res := make(map[string]string, len(gids))
+	for k := range gids {
+		res[k] = firstPolicy[k]
+	}
+	return res
+}
+
+// isListeningPortPolicy returns whether the policy has a rule for the listening port,
+// auth delegation & request source are configured on such policies.
+func (p *Policy) isListeningPortPolicy(port int32) bool {
+	return p.Rule.Type == "listening_port" && p.Rule.Value == strconv.Itoa(int(port))
+}
+
+// Implementing "set" collection methods here, :)
+func setIntersection(s1 map[string]struct{}, s2 map[string]struct{}) map[string]struct{} {
+	s_intersection := map[string]struct{}{}
+	if len(s1) > len(s2) {
+		s1, s2 = s2, s1 // better to iterate over a shorter set
+	}
+	if s1 == nil {
+		return s2
+	}
+	for k := range s1 {
+		if _, found := s2[k]; found {
+			s_intersection[k] = struct{}{}
+		}
+	}
+	return s_intersection
+}
+
+// ConditionFromProto converts the condition of a policy, nil if not set
+func ConditionFromProto(cond *gatewayv1.Policy_Condition) *Condition {
+	if cond == nil {
+		return nil
+	}
+	res := Condition{Operator: cond.GetOperator().Enum().String()}
+	if cond.GetRule() != nil {
+		rule := RuleFromProto(cond.GetRule())
+		res.Rule = &rule
+	}
+	for _, c := range cond.GetConditions() {
+		res.Conditions = append(res.Conditions, *ConditionFromProto(c))
+	}
+	return &res
+}
+
+func RuleFromProto(rule *gatewayv1.Policy_Rule) Rule {
+	return Rule{
+		Type:      rule.GetType().Enum().String(),
+		Value:     rule.GetValue(),
+		MatchType: rule.GetMatch().Enum().String(),
+	}
+}
+
+// PolicyFromProto converts a policy as returned by the policy api
+func PolicyFromProto(policy *gatewayv1.Policy) Policy {
+	res := Policy{
+		ID:               policy.GetId(),
+		GroupId:          policy.GetGroup(),
+		Condition:        ConditionFromProto(policy.GetCondition()),
+		Priority:         policy.GetPriority(),
+		IsAuthDelegated:  policy.GetIsAuthDelegated(),
+		SetRequestSource: policy.GetSetRequestSource(),
+	}
+	if policy.GetRule() != nil {
+		res.Rule = RuleFromProto(policy.GetRule())
+	}
+	return res
+}
@@ -1,4 +1,4 @@
-package policyapi
+package routing
 
 import (
 	"testing"
@@ -56,3 +56,22 @@ func Test_setIntersection(t *testing.T) {
 	// nested stuff
 	assert.Equal(t, s13, setIntersection(setIntersection(setIntersection(s1, s_nil), s_nil), s3))
 }
+
+func Test_EvaluateGroupsTieBreak(t *testing.T) {
+	params := &ClientParams{ListeningPort: 8080, User: "alice"}
+	policies := []Policy{
+		{ID: "b-port", GroupId: "adhoc", Rule: Rule{Type: "listening_port", Value: "8080"}},
+		{ID: "a-user", GroupId: "zeta", Condition: &Condition{Operator: OperatorRule, Rule: &Rule{Type: "user", Value: "alice"}}},
+		{ID: "c-user", GroupId: "adhoc", Condition: &Condition{Operator: OperatorRule, Rule: &Rule{Type: "user", Value: "alice"}}},
+	}
+	// groups of equal priority are ordered by the lowest id of their matched policies, not by group id
+	assert.Equal(t, []string{"zeta", "adhoc"}, EvaluateGroups(policies, params))
+
+	policies[1].ID = "d-user"
+	assert.Equal(t, []string{"adhoc", "zeta"}, EvaluateGroups(policies, params))
+
+	// higher priority tiers take precedence irrespective of ids
+	policies[0].Priority = -1
+	policies[2].Priority = -1
+	assert.Equal(t, []string{"zeta"}, EvaluateGroups(policies, params))
+}
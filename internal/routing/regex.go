@@ -0,0 +1,70 @@
+package routing
+
+import (
+	"container/list"
+	"regexp"
+	"sync"
+)
+
+// Max number of compiled regexes of REGEX rules retained, regexes of rules no longer in use are
+// evicted once exceeded
+const maxCompiledRegexes = 1024
+
+var compiledRegexes = newRegexCache(maxCompiledRegexes)
+
+func compileRegex(pattern string) (*regexp.Regexp, error) {
+	return compiledRegexes.compile(pattern)
+}
+
+// regexCache holds compiled regexes keyed by pattern, evicting the least recently used ones
+type regexCache struct {
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// most recently used first
+	lru *list.List
+}
+
+type regexCacheEntry struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func newRegexCache(maxEntries int) *regexCache {
+	return &regexCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+	}
+}
+
+// compile returns the regex matching whole values for the pattern
+func (c *regexCache) compile(pattern string) (*regexp.Regexp, error) {
+	c.mu.Lock()
+	if el, ok := c.entries[pattern]; ok {
+		c.lru.MoveToFront(el)
+		c.mu.Unlock()
+		return el.Value.(*regexCacheEntry).re, nil
+	}
+	c.mu.Unlock()
+
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[pattern]; ok {
+		c.lru.MoveToFront(el)
+		return el.Value.(*regexCacheEntry).re, nil
+	}
+	c.entries[pattern] = c.lru.PushFront(&regexCacheEntry{pattern: pattern, re: re})
+	for c.lru.Len() > c.maxEntries {
+		el := c.lru.Back()
+		c.lru.Remove(el)
+		delete(c.entries, el.Value.(*regexCacheEntry).pattern)
+	}
+	return re, nil
+}
@@ -0,0 +1,193 @@
+package routing
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
+)
+
+// Snapshot is the state required for routing client requests - policies, groups, backends
+// & their health. It is immutable once built, all evaluations are pure functions over it.
+type Snapshot struct {
+	// enabled policies only
+	Policies []Policy
+	Groups   map[string]*Group
+	Backends map[string]*Backend
+	// groups of each user as per the user group membership source
+	UserGroups          map[string][]string
+	DefaultRoutingGroup string
+}
+
+// EvaluateGroups returns groups eligible for the client request
+func (s *Snapshot) EvaluateGroups(params *ClientParams) []string {
+	if params.UserGroups == nil && params.User != "" {
+		params.UserGroups = s.UserGroups[params.User]
+	}
+	return EvaluateGroups(s.Policies, params)
+}
+
+// EvaluateBackend chooses the backend from first group of the provided groups having an eligible
+// backend, retaining their order. Default routing group is used if none of them have one.
+// lastRouted returns the backend last routed to for a group, for round robin strategy.
+//
+// Groups having admission control end the evaluation even if they don't have an eligible backend,
+// backendId is empty in that case - the query is to be queued for the group.
+func (s *Snapshot) EvaluateBackend(groups []string, lastRouted func(groupId string) string, opts SelectOptions) (backendId string, groupId string, err error) {
+	for _, gid := range groups {
+		g, ok := s.Groups[gid]
+		if !ok || !g.IsEnabled {
+			continue
+		}
+		b := s.selectBackend(g, lastRouted, opts)
+		if b != nil {
+			return *b, gid, nil
+		}
+		if g.Admission != nil {
+			return "", gid, nil
+		}
+	}
+
+	g, ok := s.Groups[s.DefaultRoutingGroup]
+	if !ok {
+		return "", "", errors.New("default routing group not found")
+	}
+	b := s.selectBackend(g, lastRouted, opts)
+	if b == nil {
+		if g.Admission != nil {
+			return "", g.ID, nil
+		}
+		return "", "", errors.New("unable to find Backend for Default Routing Group")
+	}
+	return *b, g.ID, nil
+}
+
+// SelectGroupBackend chooses an eligible backend of the group, nil if there are none
+func (s *Snapshot) SelectGroupBackend(groupId string, lastRouted func(groupId string) string, opts SelectOptions) *string {
+	g, ok := s.Groups[groupId]
+	if !ok || !g.IsEnabled {
+		return nil
+	}
+	return s.selectBackend(g, lastRouted, opts)
+}
+
+func (s *Snapshot) selectBackend(g *Group, lastRouted func(groupId string) string, opts SelectOptions) *string {
+	var active []Backend
+	for _, id := range g.Backends {
+		if b, ok := s.Backends[id]; ok && b.IsActive() {
+			active = append(active, *b)
+		}
+	}
+	// same order as backends fetched from the db
+	sort.Slice(active, func(i, j int) bool { return active[i].ID < active[j].ID })
+	group := *g
+	if lastRouted != nil {
+		if last := lastRouted(g.ID); last != "" {
+			group.LastRoutedBackend = last
+		}
+	}
+	return SelectBackend(&group, active, opts)
+}
+
+// IsAuthDelegated returns whether authentication is delegated for requests on the port
+func (s *Snapshot) IsAuthDelegated(port int32) bool {
+	for i := range s.Policies {
+		if s.Policies[i].isListeningPortPolicy(port) && s.Policies[i].IsAuthDelegated {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestSource returns the source to be set on requests received on the port, empty if none
+func (s *Snapshot) RequestSource(port int32) string {
+	for i := range s.Policies {
+		if s.Policies[i].isListeningPortPolicy(port) {
+			return s.Policies[i].SetRequestSource
+		}
+	}
+	return ""
+}
+
+// WithBackendUnhealthy returns a copy of the snapshot with the backend marked unhealthy
+func (s *Snapshot) WithBackendUnhealthy(backendId string) *Snapshot {
+	b, ok := s.Backends[backendId]
+	if !ok || !b.IsHealthy {
+		return s
+	}
+	res := *s
+	res.Backends = make(map[string]*Backend, len(s.Backends))
+	for id, b := range s.Backends {
+		res.Backends[id] = b
+	}
+	unhealthy := *b
+	unhealthy.IsHealthy = false
+	res.Backends[backendId] = &unhealthy
+	return &res
+}
+
+// NewSnapshot builds the snapshot from the entities as returned by the gateway apis
+func NewSnapshot(
+	policies []*gatewayv1.Policy,
+	groups []*gatewayv1.Group,
+	backends []*gatewayv1.Backend,
+	userGroups map[string][]string,
+	defaultRoutingGroup string,
+) *Snapshot {
+	s := &Snapshot{
+		Groups:              make(map[string]*Group, len(groups)),
+		Backends:            make(map[string]*Backend, len(backends)),
+		UserGroups:          userGroups,
+		DefaultRoutingGroup: defaultRoutingGroup,
+	}
+	for _, p := range policies {
+		if p.GetIsEnabled() {
+			s.Policies = append(s.Policies, PolicyFromProto(p))
+		}
+	}
+	// consistent evaluation order of request source across refreshes
+	sort.SliceStable(s.Policies, func(i, j int) bool { return s.Policies[i].ID < s.Policies[j].ID })
+
+	for _, g := range groups {
+		group := &Group{
+			ID: g.GetId(),
+			// stored in lower case, as in the strategy enum of groups table
+			Strategy:          strings.ToLower(g.GetStrategy().String()),
+			IsEnabled:         g.GetIsEnabled(),
+			Backends:          g.GetBackends(),
+			BackendWeights:    g.GetBackendWeights(),
+			LastRoutedBackend: g.GetLastRoutedBackend(),
+		}
+		if a := g.GetAdmission(); a != nil {
+			group.Admission = &Admission{
+				MaxConcurrency:   a.GetMaxConcurrency(),
+				MaxQueued:        a.GetMaxQueued(),
+				QueueTimeoutSecs: a.GetQueueTimeoutSecs(),
+			}
+		}
+		if f := g.GetLoadFormula(); f != nil {
+			group.LoadFormula = &LoadFormula{
+				RunningWeight:    f.GetRunningWeight(),
+				QueuedWeight:     f.GetQueuedWeight(),
+				QueueTimeWeight:  f.GetQueueTimeWeight(),
+				NormalizeByNodes: f.GetNormalizeByNodes(),
+			}
+		}
+		s.Groups[group.ID] = group
+	}
+	for _, b := range backends {
+		s.Backends[b.GetId()] = &Backend{
+			ID:             b.GetId(),
+			IsEnabled:      b.GetIsEnabled(),
+			IsHealthy:      b.GetIsHealthy(),
+			ClusterLoad:    b.GetClusterLoad(),
+			RunningQueries: b.GetRunningQueries(),
+			QueuedQueries:  b.GetQueuedQueries(),
+			AvgQueueTimeMs: b.GetAvgQueueTimeMs(),
+			ActiveNodes:    b.GetActiveNodes(),
+			StatsUpdatedAt: b.GetStatsUpdatedAt(),
+		}
+	}
+	return s
+}
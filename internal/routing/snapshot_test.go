@@ -0,0 +1,106 @@
+package routing
+
+import (
+	"testing"
+
+	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSnapshot() *Snapshot {
+	rule := func(t gatewayv1.Policy_Rule_RuleType, v string) *gatewayv1.Policy_Rule {
+		return &gatewayv1.Policy_Rule{Type: t, Value: v}
+	}
+	policies := []*gatewayv1.Policy{
+		{Id: "p1", Rule: rule(gatewayv1.Policy_Rule_listening_port, "8080"), Group: "adhoc", IsEnabled: true, IsAuthDelegated: true, SetRequestSource: "adhoc-port"},
+		{Id: "p2", Rule: rule(gatewayv1.Policy_Rule_user_group, "etl"), Group: "etl", IsEnabled: true, Priority: 1},
+		{Id: "p3", Rule: rule(gatewayv1.Policy_Rule_user, "alice"), Group: "etl", IsEnabled: false, Priority: 2},
+	}
+	groups := []*gatewayv1.Group{
+		{Id: "adhoc", Backends: []string{"b2", "b1"}, Strategy: gatewayv1.Group_ROUND_ROBIN, LastRoutedBackend: "b1", IsEnabled: true},
+		{Id: "etl", Backends: []string{"b3"}, Strategy: gatewayv1.Group_RANDOM, IsEnabled: true},
+		{Id: "default", Backends: []string{"b1"}, Strategy: gatewayv1.Group_RANDOM, IsEnabled: true},
+	}
+	backends := []*gatewayv1.Backend{
+		{Id: "b1", IsEnabled: true, IsHealthy: true},
+		{Id: "b2", IsEnabled: true, IsHealthy: true},
+		{Id: "b3", IsEnabled: true, IsHealthy: false},
+	}
+	return NewSnapshot(policies, groups, backends, map[string][]string{"airflow": {"etl"}}, "default")
+}
+
+func Test_SnapshotEvaluateGroups(t *testing.T) {
+	s := testSnapshot()
+
+	assert.Equal(t, []string{"adhoc"}, s.EvaluateGroups(&ClientParams{ListeningPort: 8080, User: "alice"}))
+	// user groups resolved from memberships of the snapshot
+	assert.Equal(t, []string{"etl"}, s.EvaluateGroups(&ClientParams{ListeningPort: 8080, User: "airflow"}))
+	assert.Equal(t, []string{}, s.EvaluateGroups(&ClientParams{ListeningPort: 9090}))
+
+	assert.True(t, s.IsAuthDelegated(8080))
+	assert.False(t, s.IsAuthDelegated(9090))
+	assert.Equal(t, "adhoc-port", s.RequestSource(8080))
+	assert.Equal(t, "", s.RequestSource(9090))
+}
+
+func Test_SnapshotEvaluateBackend(t *testing.T) {
+	s := testSnapshot()
+	opts := SelectOptions{RandInt63n: func(n int64) int64 { return 0 }}
+
+	lastRouted := map[string]string{}
+	evaluate := func(groups ...string) (string, string) {
+		b, g, err := s.EvaluateBackend(groups, func(gid string) string { return lastRouted[gid] }, opts)
+		assert.Nil(t, err)
+		lastRouted[g] = b
+		return b, g
+	}
+
+	// round robin starts after the last routed backend of the group
+	b, g := evaluate("adhoc")
+	assert.Equal(t, "b2", b)
+	assert.Equal(t, "adhoc", g)
+	b, _ = evaluate("adhoc")
+	assert.Equal(t, "b1", b)
+
+	// none of the backends of etl are healthy, falls back to the default routing group
+	b, g = evaluate("etl")
+	assert.Equal(t, "b1", b)
+	assert.Equal(t, "default", g)
+
+	// ejected backends aren't routed to, original snapshot is unchanged
+	ejected := s.WithBackendUnhealthy("b1")
+	assert.True(t, s.Backends["b1"].IsHealthy)
+	b, _, err := ejected.EvaluateBackend([]string{"adhoc"}, nil, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, "b2", b)
+	_, _, err = ejected.EvaluateBackend([]string{"etl"}, nil, opts)
+	assert.NotNil(t, err)
+}
+
+func Test_SnapshotEvaluateBackendAdmission(t *testing.T) {
+	groups := []*gatewayv1.Group{
+		{Id: "etl", Backends: []string{"b2"}, Strategy: gatewayv1.Group_RANDOM, IsEnabled: true, Admission: &gatewayv1.Admission{MaxConcurrency: 2}},
+		{Id: "default", Backends: []string{"b1"}, Strategy: gatewayv1.Group_RANDOM, IsEnabled: true},
+	}
+	backends := []*gatewayv1.Backend{
+		{Id: "b1", IsEnabled: true, IsHealthy: true},
+		{Id: "b2", IsEnabled: true, IsHealthy: true},
+	}
+	s := NewSnapshot(nil, groups, backends, nil, "default")
+	opts := SelectOptions{RandInt63n: func(n int64) int64 { return 0 }}
+
+	b, g, err := s.EvaluateBackend([]string{"etl"}, nil, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, "b2", b)
+	assert.Equal(t, "etl", g)
+	assert.Equal(t, int32(2), s.Groups["etl"].Admission.MaxConcurrency)
+
+	// queries are queued for groups having admission control instead of falling back to the default group
+	ejected := s.WithBackendUnhealthy("b2")
+	b, g, err = ejected.EvaluateBackend([]string{"etl"}, nil, opts)
+	assert.Nil(t, err)
+	assert.Equal(t, "", b)
+	assert.Equal(t, "etl", g)
+	assert.Nil(t, ejected.SelectGroupBackend("etl", nil, opts))
+	assert.Equal(t, "b1", *ejected.SelectGroupBackend("default", nil, opts))
+}
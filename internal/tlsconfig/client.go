@@ -0,0 +1,133 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	gatewayv1 "github.com/razorpay/trino-gateway/rpc/gateway"
+)
+
+// ClientOptions are TLS settings for connections of the gateway to a backend
+type ClientOptions struct {
+	CaFile             string `json:"ca_file,omitempty"`
+	CertFile           string `json:"cert_file,omitempty"`
+	KeyFile            string `json:"key_file,omitempty"`
+	ServerName         string `json:"server_name,omitempty"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty"`
+}
+
+// BackendClientOptions returns options of the TLS settings of a backend, zero value if it has none
+func BackendClientOptions(t *gatewayv1.BackendTls) ClientOptions {
+	return ClientOptions{
+		CaFile:             t.GetCaFile(),
+		CertFile:           t.GetCertFile(),
+		KeyFile:            t.GetKeyFile(),
+		ServerName:         t.GetServerName(),
+		InsecureSkipVerify: t.GetInsecureSkipVerify(),
+	}
+}
+
+// IsZero returns whether none of the settings are set, default TLS config applies then
+func (o ClientOptions) IsZero() bool {
+	return o == ClientOptions{}
+}
+
+func (o ClientOptions) Validate() error {
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return errors.New("both cert_file & key_file are required for client certificates")
+	}
+	return nil
+}
+
+// clientConfigEntry is a built config & modification times of the files it was built from
+type clientConfigEntry struct {
+	config   *tls.Config
+	modTimes []time.Time
+}
+
+// Built configs keyed by options, so files are read once per distinct options till they change
+var clientConfigs sync.Map
+
+// ClientConfig returns config for connections to a backend as per the options,
+// nil for zero options so defaults of the transport apply. The config is a copy owned
+// by the caller, as transports modify it, e.g. setting NextProtos for HTTP/2. The config
+// is rebuilt once files of the options change, the last built config is retained if
+// rebuilding fails, e.g. while files are being replaced.
+func ClientConfig(opts ClientOptions) (*tls.Config, error) {
+	if opts.IsZero() {
+		return nil, nil
+	}
+	var cached *clientConfigEntry
+	if e, ok := clientConfigs.Load(opts); ok {
+		cached = e.(*clientConfigEntry)
+	}
+	// modification times are read first, so changes made while building are picked on next call
+	modTimes, err := opts.ModTimes()
+	if err == nil && cached != nil && slices.EqualFunc(modTimes, cached.modTimes, time.Time.Equal) {
+		return cached.config.Clone(), nil
+	}
+	var c *tls.Config
+	if err == nil {
+		c, err = buildClientConfig(opts)
+	}
+	if err != nil {
+		if cached != nil {
+			return cached.config.Clone(), nil
+		}
+		return nil, err
+	}
+	clientConfigs.Store(opts, &clientConfigEntry{config: c, modTimes: modTimes})
+	return c.Clone(), nil
+}
+
+// ModTimes returns modification times of the files of the options, for telling once they change
+func (o ClientOptions) ModTimes() ([]time.Time, error) {
+	var res []time.Time
+	for _, f := range []string{o.CaFile, o.CertFile, o.KeyFile} {
+		if f == "" {
+			continue
+		}
+		// files are followed through symlinks, e.g. of mounted kubernetes secrets
+		info, err := os.Stat(f)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, info.ModTime())
+	}
+	return res, nil
+}
+
+func buildClientConfig(opts ClientOptions) (*tls.Config, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	c := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         opts.ServerName,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+	if opts.CaFile != "" {
+		pem, err := os.ReadFile(opts.CaFile)
+		if err != nil {
+			return nil, err
+		}
+		c.RootCAs = x509.NewCertPool()
+		if !c.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in ca file %s", opts.CaFile)
+		}
+	}
+	if opts.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate %s: %w", opts.CertFile, err)
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	return c, nil
+}
@@ -0,0 +1,95 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_clientConfig(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if len(req.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.crt")
+	assert.Nil(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+	client := writeKeyPair(t, dir, "gateway", time.Now())
+
+	get := func(opts ClientOptions) (int, error) {
+		c, err := ClientConfig(opts)
+		assert.Nil(t, err)
+		resp, err := (&http.Client{Transport: &http.Transport{TLSClientConfig: c}}).Get(server.URL)
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	// certificate of the backend is signed by an unknown CA
+	_, err := get(ClientOptions{})
+	assert.NotNil(t, err)
+	status, err := get(ClientOptions{CaFile: caFile})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, status)
+	status, err = get(ClientOptions{CaFile: caFile, CertFile: client.CertFile, KeyFile: client.KeyFile})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	// certificate of httptest servers is valid for example.com
+	_, err = get(ClientOptions{CaFile: caFile, ServerName: "example.com"})
+	assert.Nil(t, err)
+	_, err = get(ClientOptions{CaFile: caFile, ServerName: "trino.example.org"})
+	assert.NotNil(t, err)
+	_, err = get(ClientOptions{InsecureSkipVerify: true})
+	assert.Nil(t, err)
+
+	c, err := ClientConfig(ClientOptions{})
+	assert.Nil(t, err)
+	assert.Nil(t, c)
+	_, err = ClientConfig(ClientOptions{CertFile: client.CertFile})
+	assert.NotNil(t, err)
+	_, err = ClientConfig(ClientOptions{CaFile: client.KeyFile})
+	assert.NotNil(t, err)
+}
+
+func Test_clientConfigReload(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	client := writeKeyPair(t, dir, "gateway", now.Add(-time.Minute))
+	opts := ClientOptions{CertFile: client.CertFile, KeyFile: client.KeyFile}
+
+	c, err := ClientConfig(opts)
+	assert.Nil(t, err)
+	same, err := ClientConfig(opts)
+	assert.Nil(t, err)
+	assert.Equal(t, c.Certificates, same.Certificates)
+	// callers get their own copy
+	assert.NotSame(t, c, same)
+	c.NextProtos = []string{"h2"}
+	assert.Empty(t, same.NextProtos)
+
+	// rebuilt once the files are rotated
+	writeKeyPair(t, dir, "gateway", now)
+	rotated, err := ClientConfig(opts)
+	assert.Nil(t, err)
+	assert.NotEqual(t, c.Certificates[0].Certificate[0], rotated.Certificates[0].Certificate[0])
+
+	// last built config is retained while files are missing
+	assert.Nil(t, os.Remove(client.KeyFile))
+	retained, err := ClientConfig(opts)
+	assert.Nil(t, err)
+	assert.Equal(t, rotated.Certificates, retained.Certificates)
+}
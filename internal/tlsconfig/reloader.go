@@ -0,0 +1,154 @@
+// Package tlsconfig builds TLS configurations for listeners of the gateway & its connections to backends.
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/razorpay/trino-gateway/internal/provider"
+)
+
+// KeyPair is a PEM encoded certificate chain & its private key
+type KeyPair struct {
+	CertFile string
+	KeyFile  string
+}
+
+// CertReloader serves certificates of its key pairs, reloading them once their files change.
+// Last loaded certificates are retained if reloading fails, e.g. while files are being replaced.
+type CertReloader struct {
+	pairs []KeyPair
+
+	mu    sync.RWMutex
+	certs []*tls.Certificate
+	// latest modification time of files of each pair, as of their last load
+	modTimes []time.Time
+}
+
+// NewCertReloader loads certificates of the key pairs, failing if any of them can't be loaded
+func NewCertReloader(pairs []KeyPair) (*CertReloader, error) {
+	if len(pairs) == 0 {
+		return nil, errors.New("no certificates configured")
+	}
+	r := &CertReloader{
+		pairs:    pairs,
+		certs:    make([]*tls.Certificate, len(pairs)),
+		modTimes: make([]time.Time, len(pairs)),
+	}
+	for i, p := range pairs {
+		cert, modTime, err := loadKeyPair(p)
+		if err != nil {
+			return nil, err
+		}
+		r.certs[i], r.modTimes[i] = cert, modTime
+	}
+	return r, nil
+}
+
+// ServerConfig returns config for listeners serving the certificates of the reloader
+func (r *CertReloader) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.GetCertificate,
+	}
+}
+
+// GetCertificate returns the first certificate valid for the server name sent by the client,
+// the first certificate if none of them are.
+func (r *CertReloader) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if hello.ServerName != "" {
+		for _, cert := range r.certs {
+			if cert.Leaf.VerifyHostname(hello.ServerName) == nil {
+				return cert, nil
+			}
+		}
+	}
+	return r.certs[0], nil
+}
+
+// Reload loads key pairs whose files changed since they were last loaded, returns whether any were reloaded
+func (r *CertReloader) Reload() (bool, error) {
+	reloaded := false
+	for i, p := range r.pairs {
+		modTime, err := keyPairModTime(p)
+		if err != nil {
+			return reloaded, err
+		}
+		r.mu.RLock()
+		changed := !modTime.Equal(r.modTimes[i])
+		r.mu.RUnlock()
+		if !changed {
+			continue
+		}
+
+		cert, modTime, err := loadKeyPair(p)
+		if err != nil {
+			return reloaded, err
+		}
+		r.mu.Lock()
+		r.certs[i], r.modTimes[i] = cert, modTime
+		r.mu.Unlock()
+		reloaded = true
+	}
+	return reloaded, nil
+}
+
+// Run checks for changes to the key pairs on the interval till the context is done
+func (r *CertReloader) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		reloaded, err := r.Reload()
+		if err != nil {
+			provider.Logger(ctx).WithError(err).Error("Unable to reload TLS certificates, retaining the last loaded ones")
+			continue
+		}
+		if reloaded {
+			provider.Logger(ctx).Info("Reloaded TLS certificates")
+		}
+	}
+}
+
+func loadKeyPair(p KeyPair) (*tls.Certificate, time.Time, error) {
+	// modification time is read first, so changes made while loading are picked on next reload
+	modTime, err := keyPairModTime(p)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	cert, err := tls.LoadX509KeyPair(p.CertFile, p.KeyFile)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("unable to load certificate %s: %w", p.CertFile, err)
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return nil, time.Time{}, fmt.Errorf("unable to parse certificate %s: %w", p.CertFile, err)
+	}
+	return &cert, modTime, nil
+}
+
+func keyPairModTime(p KeyPair) (time.Time, error) {
+	var latest time.Time
+	for _, f := range []string{p.CertFile, p.KeyFile} {
+		// files are followed through symlinks, e.g. of mounted kubernetes secrets
+		info, err := os.Stat(f)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
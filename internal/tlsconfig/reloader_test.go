@@ -0,0 +1,94 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeKeyPair writes a self signed certificate for the host, modified at the given time
+func writeKeyPair(t *testing.T, dir string, host string, modTime time.Time) KeyPair {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	p := KeyPair{CertFile: filepath.Join(dir, host+".crt"), KeyFile: filepath.Join(dir, host+".key")}
+	assert.Nil(t, os.WriteFile(p.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	assert.Nil(t, os.WriteFile(p.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+	assert.Nil(t, os.Chtimes(p.CertFile, modTime, modTime))
+	assert.Nil(t, os.Chtimes(p.KeyFile, modTime, modTime))
+	return p
+}
+
+func Test_certReloaderGetCertificate(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	adhoc := writeKeyPair(t, dir, "adhoc.trino.example.com", now)
+	etl := writeKeyPair(t, dir, "etl.trino.example.com", now)
+
+	r, err := NewCertReloader([]KeyPair{adhoc, etl})
+	assert.Nil(t, err)
+
+	cert, _ := r.GetCertificate(&tls.ClientHelloInfo{ServerName: "etl.trino.example.com"})
+	assert.Equal(t, "etl.trino.example.com", cert.Leaf.Subject.CommonName)
+	// first certificate is served for unknown & missing server names
+	cert, _ = r.GetCertificate(&tls.ClientHelloInfo{ServerName: "other.example.com"})
+	assert.Equal(t, "adhoc.trino.example.com", cert.Leaf.Subject.CommonName)
+	cert, _ = r.GetCertificate(&tls.ClientHelloInfo{})
+	assert.Equal(t, "adhoc.trino.example.com", cert.Leaf.Subject.CommonName)
+
+	_, err = NewCertReloader(nil)
+	assert.NotNil(t, err)
+	_, err = NewCertReloader([]KeyPair{{CertFile: filepath.Join(dir, "missing.crt"), KeyFile: adhoc.KeyFile}})
+	assert.NotNil(t, err)
+}
+
+func Test_certReloaderReload(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	p := writeKeyPair(t, dir, "trino.example.com", now)
+
+	r, err := NewCertReloader([]KeyPair{p})
+	assert.Nil(t, err)
+	before, _ := r.GetCertificate(&tls.ClientHelloInfo{})
+
+	reloaded, err := r.Reload()
+	assert.Nil(t, err)
+	assert.False(t, reloaded)
+
+	writeKeyPair(t, dir, "trino.example.com", now.Add(time.Minute))
+	reloaded, err = r.Reload()
+	assert.Nil(t, err)
+	assert.True(t, reloaded)
+	after, _ := r.GetCertificate(&tls.ClientHelloInfo{})
+	assert.NotEqual(t, before.Leaf.SerialNumber, after.Leaf.SerialNumber)
+
+	// last loaded certificate is retained if the files are invalid
+	assert.Nil(t, os.WriteFile(p.CertFile, []byte("invalid"), 0o600))
+	assert.Nil(t, os.Chtimes(p.CertFile, now.Add(2*time.Minute), now.Add(2*time.Minute)))
+	_, err = r.Reload()
+	assert.NotNil(t, err)
+	cert, _ := r.GetCertificate(&tls.ClientHelloInfo{})
+	assert.Equal(t, after, cert)
+}
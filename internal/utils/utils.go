@@ -8,7 +8,6 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httputil"
-	"sync"
 	"time"
 
 	"github.com/razorpay/trino-gateway/internal/provider"
@@ -36,6 +35,14 @@ func IsTimeInCron(ctx *context.Context, t time.Time, sched string) (bool, error)
 	return nextRun.Sub(t).Minutes() <= 1, nil
 }
 
+// ParseOptionalDuration parses a duration of the config, empty parses to 0 which disables the setting
+func ParseOptionalDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
 func SliceContains[T comparable](collection []T, element T) bool {
 	for _, item := range collection {
 		if item == element {
@@ -129,49 +136,3 @@ func ParseHttpPayloadBody(ctx *context.Context, body *io.ReadCloser, encoding st
 		return string(bodyBytes), nil
 	}
 }
-
-type ISimpleCache interface {
-	Get(key string) (string, bool)
-	Update(key, value string)
-}
-
-type InMemorySimpleCache struct {
-	Cache map[string]struct {
-		Timestamp time.Time
-		Value     string
-	}
-	ExpiryInterval time.Duration
-	mu             sync.Mutex
-}
-
-func (authCache *InMemorySimpleCache) Get(key string) (string, bool) {
-	authCache.mu.Lock()
-	defer authCache.mu.Unlock()
-
-	entry, found := authCache.Cache[key]
-
-	if !found {
-		return "", false
-	}
-
-	if authCache.ExpiryInterval > 0 &&
-		time.Since(entry.Timestamp) > authCache.ExpiryInterval {
-		// If entry is older than cachedDuration, then delete the record and return false
-		delete(authCache.Cache, key)
-		return "", false
-	}
-	return entry.Value, true
-}
-
-func (authCache *InMemorySimpleCache) Update(key, value string) {
-	authCache.mu.Lock()
-	defer authCache.mu.Unlock()
-
-	authCache.Cache[key] = struct {
-		Timestamp time.Time
-		Value     string
-	}{
-		Timestamp: time.Now(),
-		Value:     value,
-	}
-}
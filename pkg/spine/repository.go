@@ -77,6 +77,30 @@ func (repo Repo) Update(ctx context.Context, receiver IModel, selectiveList ...s
 	return repo.updateSelective(ctx, receiver, selectiveList...)
 }
 
+// UpdateColumns will update the given columns of the record with respect to primary key / id available
+// in the receiver, and only if it matches the optional condition. Values can be expressions evaluated by
+// the database, e.g. gorm.Expr("count + ?", 1), so updates relative to the stored value are atomic.
+// Note: `updated_at` field is updated as well.
+func (repo Repo) UpdateColumns(ctx context.Context, receiver IModel, columns map[string]interface{}, condition ...interface{}) error {
+	q := repo.DBInstance(ctx).Model(receiver)
+
+	if len(condition) > 0 {
+		q = q.Where(condition[0], condition[1:]...)
+	}
+
+	q = q.Updates(columns)
+
+	if err := GetDBError(q); err != nil {
+		return err
+	}
+
+	if q.RowsAffected == 0 {
+		return NoRowAffected
+	}
+
+	return nil
+}
+
 // Delete deletes the given model
 // Soft or hard delete of model depends on the models implementation
 // if the model composites SoftDeletableModel then it'll be soft deleted
@@ -86,6 +110,14 @@ func (repo Repo) Delete(ctx context.Context, receiver IModel) error {
 	return GetDBError(q)
 }
 
+// DeleteWhere deletes all records of the entity defined by receiver which match the condition
+// Soft or hard delete of records depends on the models implementation
+func (repo Repo) DeleteWhere(ctx context.Context, receiver IModel, query interface{}, args ...interface{}) error {
+	q := repo.DBInstance(ctx).Where(query, args...).Delete(receiver)
+
+	return GetDBError(q)
+}
+
 func (repo Repo) ClearAssociations(ctx context.Context, receiver IModel, name string) error {
 	err := repo.DBInstance(ctx).Model(receiver).Association(name).Clear()
 